@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/env"
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+// adoptProcess resolves the secretinit: variables of the running process pid for --adopt.
+// Resolved variable names are printed to w, values only for the variable named by stdoutName.
+// Returns the process exit code.
+func adoptProcess(ctx context.Context, w io.Writer, pid int, stdoutName string, suffixes processor.Suffixes, timeout time.Duration) int {
+	environ, err := env.ReadProcessEnviron(pid)
+	if err != nil {
+		printError(err, "Error: %v", err)
+		return 1
+	}
+
+	secretEnvVars := env.ScanSecretEnvVarsFrom(environ)
+	debugLog("Found %d secret variables in the environment of process %d", len(secretEnvVars), pid)
+	if stdoutName != "" {
+		address, exists := secretEnvVars[stdoutName]
+		if !exists {
+			printError(nil, "Error: process %d has no secretinit: variable %s", pid, stdoutName)
+			return 1
+		}
+		secretEnvVars = map[string]string{stdoutName: address}
+	}
+
+	proc, err := processor.NewProcessorForSecrets(secretEnvVars)
+	if err != nil {
+		printError(err, "Error initializing processor: %v", err)
+		return exitCodeForError(err)
+	}
+	proc.SetSuffixes(suffixes)
+	retrievedSecrets, err := proc.ProcessSecretsCtx(ctx, secretEnvVars)
+	if err != nil {
+		printResolutionError(err, "Error processing secrets: %v", timeout)
+		return exitCodeForError(err)
+	}
+
+	if stdoutName != "" {
+		value, exists := retrievedSecrets[stdoutName]
+		if !exists {
+			// Multi-credential addresses expand to several variables instead of one value
+			printError(nil, "Error: %s did not resolve to a single value", stdoutName)
+			return 1
+		}
+		fmt.Fprintln(w, value)
+		return 0
+	}
+
+	names := make([]string, 0, len(retrievedSecrets))
+	for name := range retrievedSecrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, name)
+	}
+	fmt.Fprintf(os.Stderr, "Resolved %d variables from %d secret addresses of process %d\n", len(names), len(secretEnvVars), pid)
+	return 0
+}
@@ -0,0 +1,25 @@
+package main
+
+import "github.com/liifi/secretinit/pkg/processor"
+
+// Exit codes used when secretinit itself fails, so orchestrators can tell
+// configuration problems apart from the launched command's own exit codes.
+// Command failures always pass through the command's actual exit code.
+const (
+	exitSecretError        = 10 // A secret could not be retrieved from its backend
+	exitParseError         = 11 // A secret address is invalid
+	exitBackendUnavailable = 12 // A backend is not compiled in or failed to initialize
+	exitSecretRotated      = 50 // --detect-rotation found a changed secret value
+)
+
+// exitCodeForError maps a secret processing error to its exit code
+func exitCodeForError(err error) int {
+	switch processor.ErrorKindOf(err) {
+	case processor.ErrorKindParse:
+		return exitParseError
+	case processor.ErrorKindBackendUnavailable:
+		return exitBackendUnavailable
+	default:
+		return exitSecretError
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto/tls"
+	"errors"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+// failingBackend always fails to retrieve secrets
+type failingBackend struct{}
+
+func (b *failingBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return "", errors.New("access denied")
+}
+
+// staticBackend returns the same value for every secret and accepts client certificates
+type staticBackend string
+
+func (b staticBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return string(b), nil
+}
+
+func (b staticBackend) WithClientCertificate(cert tls.Certificate) (backend.Backend, error) {
+	return b, nil
+}
+
+func TestExitCodeForError(t *testing.T) {
+	proc := processor.NewSecretProcessor()
+	proc.RegisterBackend("aws", &failingBackend{})
+
+	_, retrievalErr := proc.ProcessSecrets(map[string]string{"DB_PASS": "aws:sm:myapp/db"})
+	_, parseErr := proc.ProcessSecrets(map[string]string{"DB_PASS": "aws:myapp"})
+	_, serviceErr := proc.ProcessSecrets(map[string]string{"DB_PASS": "aws:bogus:myapp/db"})
+	_, unregisteredErr := proc.ProcessSecrets(map[string]string{"TOKEN": "git:https://example.com:::password"})
+	_, unavailableErr := processor.NewProcessorWithBackends([]string{"not-a-backend"})
+
+	certProc := processor.NewSecretProcessor()
+	certProc.RegisterBackend("aws", staticBackend("-----BEGIN CERTIFICATE-----\nbm90IGEgY2VydA==\n-----END CERTIFICATE-----\n"))
+	_, clientCertErr := certProc.ProcessSecrets(map[string]string{
+		"CLIENT_CERT": "aws:sm:client-cert",
+		"DB_PASS":     "aws:sm:myapp/db:::?client_cert=CLIENT_CERT",
+	})
+
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "retrieval failure", err: retrievalErr, expected: exitSecretError},
+		{name: "parse failure", err: parseErr, expected: exitParseError},
+		{name: "invalid service", err: serviceErr, expected: exitParseError},
+		{name: "backend not registered", err: unregisteredErr, expected: exitBackendUnavailable},
+		{name: "backend not in build", err: unavailableErr, expected: exitBackendUnavailable},
+		{name: "malformed client certificate", err: clientCertErr, expected: exitSecretError},
+		{name: "unclassified error", err: errors.New("boom"), expected: exitSecretError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("Expected an error to classify")
+			}
+			if got := exitCodeForError(tt.err); got != tt.expected {
+				t.Errorf("exitCodeForError(%v) = %d, want %d", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
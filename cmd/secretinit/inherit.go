@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+)
+
+// minimalInheritedVars are kept by --inherit-only-secrets so the child can still find
+// executables, its home directory, terminal and locale
+var minimalInheritedVars = []string{"PATH", "HOME", "TERM", "LANG"}
+
+// inheritedEnv returns the part of environ passed on to the child, before resolved secrets are added.
+// Processed secret variables are always dropped. With onlySecrets, only minimalInheritedVars and
+// the allowed names are kept.
+func inheritedEnv(environ []string, secretVars map[string]string, onlySecrets bool, allowed []string) []string {
+	keep := make(map[string]bool)
+	for _, name := range append(append([]string{}, minimalInheritedVars...), allowed...) {
+		keep[name] = true
+	}
+
+	inherited := []string{}
+	for _, envVar := range environ {
+		varName, _, found := strings.Cut(envVar, "=")
+		if !found {
+			continue
+		}
+		// Skip variables that were processed (have secretinit: prefix)
+		if _, wasProcessed := secretVars[varName]; wasProcessed {
+			continue
+		}
+		if onlySecrets && !keep[varName] {
+			continue
+		}
+		inherited = append(inherited, envVar)
+	}
+	return inherited
+}
+
+// parseEnvAllow splits a comma-separated --env-allow value into variable names (or --only prefixes)
+func parseEnvAllow(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
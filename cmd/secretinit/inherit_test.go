@@ -0,0 +1,66 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInheritedEnv(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/app",
+		"TERM=xterm",
+		"LANG=C.UTF-8",
+		"AWS_SECRET_ACCESS_KEY=leaked",
+		"DB_PASSWORD=secretinit:aws:sm:db:::password",
+		"TZ=UTC",
+		"MALFORMED",
+	}
+	secretVars := map[string]string{"DB_PASSWORD": "aws:sm:db:::password"}
+
+	tests := []struct {
+		name        string
+		onlySecrets bool
+		allowed     []string
+		expected    []string
+	}{
+		{
+			name:     "inherit everything but processed secrets",
+			expected: []string{"PATH=/usr/bin", "HOME=/home/app", "TERM=xterm", "LANG=C.UTF-8", "AWS_SECRET_ACCESS_KEY=leaked", "TZ=UTC"},
+		},
+		{
+			name:        "only secrets keeps the minimal set",
+			onlySecrets: true,
+			expected:    []string{"PATH=/usr/bin", "HOME=/home/app", "TERM=xterm", "LANG=C.UTF-8"},
+		},
+		{
+			name:        "env allow extends the minimal set",
+			onlySecrets: true,
+			allowed:     []string{"TZ", "MISSING"},
+			expected:    []string{"PATH=/usr/bin", "HOME=/home/app", "TERM=xterm", "LANG=C.UTF-8", "TZ=UTC"},
+		},
+		{
+			name:        "processed secrets are never inherited",
+			onlySecrets: true,
+			allowed:     []string{"DB_PASSWORD"},
+			expected:    []string{"PATH=/usr/bin", "HOME=/home/app", "TERM=xterm", "LANG=C.UTF-8"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inheritedEnv(environ, secretVars, tt.onlySecrets, tt.allowed)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("inheritedEnv() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseEnvAllow(t *testing.T) {
+	got := parseEnvAllow("TZ, SSL_CERT_FILE,,")
+	expected := []string{"TZ", "SSL_CERT_FILE"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("parseEnvAllow() = %v, want %v", got, expected)
+	}
+}
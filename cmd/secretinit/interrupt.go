@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// resolutionContext returns the context secret resolution runs under. It expires after timeout
+// (no limit when 0) and is canceled by Ctrl-C or SIGTERM, so pending backend calls are abandoned
+// and secretinit exits with an error instead of waiting on a slow backend.
+// Calling cancel also stops catching the signals.
+func resolutionContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancelTimeout := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
@@ -0,0 +1,43 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestResolutionContext_Interrupt(t *testing.T) {
+	ctx, cancel := resolutionContext(0)
+	defer cancel()
+
+	if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.Canceled) {
+			t.Errorf("ctx.Err() = %v, want context.Canceled", ctx.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SIGINT did not cancel secret resolution")
+	}
+}
+
+func TestResolutionContext_Timeout(t *testing.T) {
+	ctx, cancel := resolutionContext(10 * time.Millisecond)
+	defer cancel()
+
+	select {
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			t.Errorf("ctx.Err() = %v, want context.DeadlineExceeded", ctx.Err())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("--timeout did not expire")
+	}
+}
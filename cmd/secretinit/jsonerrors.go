@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+// jsonErrors is set by --json-errors so tools wrapping secretinit can parse fatal errors
+var jsonErrors bool
+
+// jsonError is the --json-errors shape of a fatal error
+type jsonError struct {
+	Error    string `json:"error"`
+	Variable string `json:"variable,omitempty"`
+	Backend  string `json:"backend,omitempty"`
+	Code     string `json:"code"`
+}
+
+// printError prints a fatal error to stderr. The formatted message is printed as-is by default;
+// with --json-errors it is printed as a JSON object, with the variable, backend and kind
+// taken from err when it is a processor.SecretError (err may be nil).
+func printError(err error, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if jsonErrors {
+		message = formatJSONError(message, err)
+	}
+	fmt.Fprintln(os.Stderr, message)
+}
+
+// formatJSONError formats message and err as a single-line JSON object.
+// The code is the SecretError kind (e.g. parse_error, retrieval_failed), or "error" otherwise.
+func formatJSONError(message string, err error) string {
+	message = strings.TrimPrefix(message, "Error: ")
+	message = strings.TrimPrefix(message, "Error ")
+
+	info := jsonError{Error: message, Code: "error"}
+	var secretErr *processor.SecretError
+	if errors.As(err, &secretErr) {
+		info.Variable = secretErr.Variable
+		info.Backend = secretErr.Backend
+		info.Code = secretErr.Kind.String()
+	}
+
+	data, _ := json.Marshal(info)
+	return string(data)
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+func TestFormatJSONError(t *testing.T) {
+	proc := processor.NewSecretProcessor()
+	proc.RegisterBackend("aws", &failingBackend{})
+
+	_, parseErr := proc.ProcessSecrets(map[string]string{"DB_PASS": "aws:myapp"})
+	_, retrievalErr := proc.ProcessSecrets(map[string]string{"DB_PASS": "aws:sm:myapp/db"})
+	if parseErr == nil || retrievalErr == nil {
+		t.Fatalf("expected processor errors, got parse=%v retrieval=%v", parseErr, retrievalErr)
+	}
+
+	tests := []struct {
+		name     string
+		message  string
+		err      error
+		expected jsonError
+	}{
+		{
+			name:     "parse failure",
+			message:  "Error processing secrets: " + parseErr.Error(),
+			err:      parseErr,
+			expected: jsonError{Error: "processing secrets: " + parseErr.Error(), Variable: "DB_PASS", Code: "parse_error"},
+		},
+		{
+			name:     "retrieval failure",
+			message:  "Error processing secrets: " + retrievalErr.Error(),
+			err:      retrievalErr,
+			expected: jsonError{Error: "processing secrets: " + retrievalErr.Error(), Variable: "DB_PASS", Backend: "aws", Code: "retrieval_failed"},
+		},
+		{
+			name:     "plain error",
+			message:  "Error: --pre requires a command argument",
+			err:      nil,
+			expected: jsonError{Error: "--pre requires a command argument", Code: "error"},
+		},
+		{
+			name:     "wrapped non-secret error",
+			message:  "Error loading env file: missing",
+			err:      errors.New("missing"),
+			expected: jsonError{Error: "loading env file: missing", Code: "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got jsonError
+			if err := json.Unmarshal([]byte(formatJSONError(tt.message, tt.err)), &got); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %+v, want %+v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,15 +1,28 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/liifi/secretinit/pkg/backend"
 	"github.com/liifi/secretinit/pkg/env"
 	executil "github.com/liifi/secretinit/pkg/exec"
+	"github.com/liifi/secretinit/pkg/lint"
+	"github.com/liifi/secretinit/pkg/logging"
 	"github.com/liifi/secretinit/pkg/mappings"
+	"github.com/liifi/secretinit/pkg/output"
+	"github.com/liifi/secretinit/pkg/parser"
 	"github.com/liifi/secretinit/pkg/processor"
 )
 
@@ -37,14 +50,14 @@ func getLogLevel() string {
 // debugLog prints debug messages to stderr if debug level is enabled.
 func debugLog(format string, args ...interface{}) {
 	if logLevel == "DEBUG" {
-		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+		logging.Printf(logging.LevelDebug, format, args...)
 	}
 }
 
 // infoLog prints info messages to stderr if info level or higher is enabled.
 func infoLog(format string, args ...interface{}) {
 	if logLevel == "INFO" || logLevel == "DEBUG" {
-		fmt.Fprintf(os.Stderr, format+"\n", args...)
+		logging.Printf(logging.LevelInfo, format, args...)
 	}
 }
 
@@ -57,24 +70,98 @@ func main() {
 		os.Exit(1)
 	}
 
+	jsonErrors = hasArg(os.Args[1:], "--json-errors")
+
+	// Handle subcommands
+	if os.Args[1] == "lint" {
+		os.Exit(handleLint(os.Args[2:]))
+	}
+	if os.Args[1] == "backends" {
+		// Only the names, one per line, so scripts can check a build without parsing
+		for _, name := range processor.AvailableBackends() {
+			fmt.Println(name)
+		}
+		return
+	}
+
 	for _, arg := range os.Args[1:] {
 		if arg == "-h" || arg == "--help" {
 			showHelp(binaryName)
 			return
 		}
 		if arg == "-v" || arg == "--version" {
+			if hasArg(os.Args[1:], "--json") {
+				printVersionJSON(binaryName)
+				return
+			}
 			fmt.Printf("%s version %s\n", binaryName, version)
 			return
 		}
+		if arg == "--list-backends" {
+			fmt.Printf("Build: %s\n", processor.BuildVariant())
+			for _, name := range processor.AvailableBackends() {
+				fmt.Println(name)
+			}
+			return
+		}
 	}
 
+	debugLog("Build variant: %s (backends: %s)", processor.BuildVariant(), strings.Join(processor.AvailableBackends(), ", "))
+
 	// Parse command line arguments for various flags
 	var stdout bool
 	var secretAddress string
-	var envFile string
+	var envFiles []string
 	var noEnv bool
 	var preCommand string
 	var postCommand string
+	var postWhen executil.PostCondition
+	var noPostOnSignal bool
+	var reloadSignal os.Signal
+	var verbose bool
+	var scrubOutput bool
+	var scrubMainOutput bool
+	var checkSchema string
+	var defaultsFile string
+	var systemdCredsDir string
+	var requireFile string
+	var printCmd bool
+	var preview bool
+	var postEnvFile string
+	var runAs *executil.RunAs
+	var credsOwner *output.FileOwner
+	var format string
+	var dumpFormat string
+	var shellSyntax string
+	var dryRun bool
+	var checkBackends bool
+	var rotationAddress string
+	var rotationState string
+	var writeEnvPath string
+	var tfvarsPath string
+	var jsonOutputPath string
+	var maskFilePath string
+	var pgpassPath string
+	var myCnfPath string
+	var resolveMapPath string
+	var templateSpecs []string
+	var requiredKeys map[string][]string
+	var nestedMode processor.NestedMode
+	var continueOnError bool
+	var keepFailed bool
+	var regionMatrixPath string
+	var region string
+	var inheritOnlySecrets bool
+	var envAllow []string
+	var timeout time.Duration
+	var retryFailedInterval time.Duration
+	var adoptPID int
+	var suffixSpec string
+	var cacheStats bool
+	var lazy bool
+	var gitAskpass string
+	var onlyPrefixes []string
+	var secretsFile string
 
 	// Parse flags
 	args := os.Args[1:]
@@ -84,19 +171,23 @@ func main() {
 		switch args[i] {
 		case "-o", "--stdout":
 			stdout = true
+			if i+1 < len(args) && strings.HasPrefix(args[i+1], "-") {
+				// Flags like --format may sit between -o and the address (-o --format json ADDRESS)
+				break
+			}
 			if i+1 < len(args) {
 				secretAddress = args[i+1]
 				i++ // Skip the next argument as it's the secret address
 			} else {
-				fmt.Fprintf(os.Stderr, "Error: -o/--stdout requires a secret address argument\n")
+				printError(nil, "Error: -o/--stdout requires a secret address argument")
 				os.Exit(1)
 			}
 		case "-e", "--env-file":
 			if i+1 < len(args) {
-				envFile = args[i+1]
-				i++ // Skip the next argument as it's the file path
+				envFiles = append(envFiles, args[i+1])
+				i++ // Skip the next argument as it's the file path or glob pattern
 			} else {
-				fmt.Fprintf(os.Stderr, "Error: -e/--env-file requires a file path argument\n")
+				printError(nil, "Error: -e/--env-file requires a file path argument")
 				os.Exit(1)
 			}
 		case "-n", "--no-env":
@@ -106,7 +197,7 @@ func main() {
 				preCommand = args[i+1]
 				i++ // Skip the next argument as it's the command
 			} else {
-				fmt.Fprintf(os.Stderr, "Error: --pre requires a command argument\n")
+				printError(nil, "Error: --pre requires a command argument")
 				os.Exit(1)
 			}
 		case "--post":
@@ -114,9 +205,361 @@ func main() {
 				postCommand = args[i+1]
 				i++ // Skip the next argument as it's the command
 			} else {
-				fmt.Fprintf(os.Stderr, "Error: --post requires a command argument\n")
+				printError(nil, "Error: --post requires a command argument")
+				os.Exit(1)
+			}
+		case "--post-on-success", "--post-on-failure":
+			condition := executil.PostOnSuccess
+			if args[i] == "--post-on-failure" {
+				condition = executil.PostOnFailure
+			}
+			if postWhen != executil.PostAlways && postWhen != condition {
+				printError(nil, "Error: --post-on-success and --post-on-failure cannot be combined")
+				os.Exit(1)
+			}
+			postWhen = condition
+		case "--no-post-on-signal":
+			noPostOnSignal = true
+		case "--reload-signal":
+			if i+1 < len(args) {
+				var err error
+				reloadSignal, err = executil.ParseReloadSignal(args[i+1])
+				if err != nil {
+					printError(err, "Error: %v", err)
+					os.Exit(1)
+				}
+				i++ // Skip the next argument as it's the signal name
+			} else {
+				printError(nil, "Error: --reload-signal requires a signal name argument (e.g. SIGHUP)")
+				os.Exit(1)
+			}
+		case "--verbose":
+			verbose = true
+			if logLevel == "WARN" {
+				logLevel = "INFO"
+			}
+		case "--check":
+			if i+1 < len(args) {
+				checkSchema = args[i+1]
+				i++ // Skip the next argument as it's the schema path
+			} else {
+				printError(nil, "Error: --check requires a schema file argument")
+				os.Exit(1)
+			}
+		case "--secrets-file":
+			if i+1 < len(args) {
+				secretsFile = args[i+1]
+				i++ // Skip the next argument as it's the secrets file path
+			} else {
+				printError(nil, "Error: --secrets-file requires a file path argument")
+				os.Exit(1)
+			}
+		case "--defaults-file":
+			if i+1 < len(args) {
+				defaultsFile = args[i+1]
+				i++ // Skip the next argument as it's the file path
+			} else {
+				printError(nil, "Error: --defaults-file requires a file path argument")
+				os.Exit(1)
+			}
+		case "--systemd-creds":
+			if i+1 < len(args) {
+				systemdCredsDir = args[i+1]
+				i++ // Skip the next argument as it's the directory
+			} else {
+				printError(nil, "Error: --systemd-creds requires a directory argument")
+				os.Exit(1)
+			}
+		case "--post-env":
+			if i+1 < len(args) {
+				postEnvFile = args[i+1]
+				i++ // Skip the next argument as it's the file path
+			} else {
+				printError(nil, "Error: --post-env requires a file path argument")
+				os.Exit(1)
+			}
+		case "--run-as":
+			if i+1 < len(args) {
+				var err error
+				runAs, err = executil.ParseRunAs(args[i+1])
+				if err != nil {
+					printError(err, "Error: %v", err)
+					os.Exit(1)
+				}
+				i++ // Skip the next argument as it's the uid:gid
+			} else {
+				printError(nil, "Error: --run-as requires a uid:gid argument")
+				os.Exit(1)
+			}
+		case "--creds-owner":
+			if i+1 < len(args) {
+				owner, err := executil.ParseRunAs(args[i+1])
+				if err != nil {
+					printError(err, "Error: invalid --creds-owner: %v", err)
+					os.Exit(1)
+				}
+				credsOwner = &output.FileOwner{UID: int(owner.UID), GID: int(owner.GID)}
+				i++ // Skip the next argument as it's the uid:gid
+			} else {
+				printError(nil, "Error: --creds-owner requires a uid:gid argument")
+				os.Exit(1)
+			}
+		case "--format":
+			if i+1 < len(args) {
+				format = args[i+1]
+				i++ // Skip the next argument as it's the format
+			} else {
+				printError(nil, "Error: --format requires a format argument (plain, json)")
+				os.Exit(1)
+			}
+		case "--dump":
+			if i+1 < len(args) {
+				dumpFormat = args[i+1]
+				i++ // Skip the next argument as it's the format
+			} else {
+				printError(nil, "Error: --dump requires a format argument (json)")
+				os.Exit(1)
+			}
+		case "--shell":
+			// The shell name is optional: --shell alone prints POSIX export statements
+			shellSyntax = "sh"
+			if i+1 < len(args) {
+				switch args[i+1] {
+				case "sh", "bash", "zsh":
+					i++ // Skip the next argument as it's the shell
+				case "fish":
+					shellSyntax = "fish"
+					i++ // Skip the next argument as it's the shell
+				}
+			}
+		case "--detect-rotation":
+			if i+1 < len(args) {
+				rotationAddress = args[i+1]
+				i++ // Skip the next argument as it's the secret address
+			} else {
+				printError(nil, "Error: --detect-rotation requires a secret address argument")
+				os.Exit(1)
+			}
+		case "--rotation-state":
+			if i+1 < len(args) {
+				rotationState = args[i+1]
+				i++ // Skip the next argument as it's the state file path
+			} else {
+				printError(nil, "Error: --rotation-state requires a file path argument")
+				os.Exit(1)
+			}
+		case "--write-env":
+			if i+1 < len(args) {
+				writeEnvPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --write-env requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--tfvars":
+			if i+1 < len(args) {
+				tfvarsPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --tfvars requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--json-output-file":
+			if i+1 < len(args) {
+				jsonOutputPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --json-output-file requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--mask-file":
+			if i+1 < len(args) {
+				maskFilePath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --mask-file requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--pgpass":
+			if i+1 < len(args) {
+				pgpassPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --pgpass requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--my-cnf":
+			if i+1 < len(args) {
+				myCnfPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --my-cnf requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--resolve-map":
+			if i+1 < len(args) {
+				resolveMapPath = args[i+1]
+				i++ // Skip the next argument as it's the file path (- for stdout)
+			} else {
+				printError(nil, "Error: --resolve-map requires a file path argument (- for stdout)")
+				os.Exit(1)
+			}
+		case "--template":
+			if i+1 < len(args) {
+				templateSpecs = append(templateSpecs, args[i+1])
+				i++ // Skip the next argument as it's the template spec
+			} else {
+				printError(nil, "Error: --template requires a SRC[:DEST] argument")
+				os.Exit(1)
+			}
+		case "--continue-on-error":
+			continueOnError = true
+		case "--keep-failed":
+			keepFailed = true
+		case "--resolve-nested":
+			if i+1 < len(args) {
+				mode, err := processor.ParseNestedMode(args[i+1])
+				if err != nil {
+					printError(err, "Error: %v", err)
+					os.Exit(1)
+				}
+				nestedMode = mode
+				i++ // Skip the next argument as it's the mode
+			} else {
+				printError(nil, "Error: --resolve-nested requires a mode argument (warn, resolve or error)")
+				os.Exit(1)
+			}
+		case "--require-keys":
+			if i+1 < len(args) {
+				varName, keys, err := processor.ParseRequiredKeys(args[i+1])
+				if err != nil {
+					printError(err, "Error: %v", err)
+					os.Exit(1)
+				}
+				if requiredKeys == nil {
+					requiredKeys = make(map[string][]string)
+				}
+				requiredKeys[varName] = append(requiredKeys[varName], keys...)
+				i++ // Skip the next argument as it's the VAR=keys spec
+			} else {
+				printError(nil, "Error: --require-keys requires a VAR=key1,key2 argument")
+				os.Exit(1)
+			}
+		case "--region-matrix":
+			if i+1 < len(args) {
+				regionMatrixPath = args[i+1]
+				i++ // Skip the next argument as it's the file path
+			} else {
+				printError(nil, "Error: --region-matrix requires a file path argument")
+				os.Exit(1)
+			}
+		case "--region":
+			if i+1 < len(args) {
+				region = args[i+1]
+				i++ // Skip the next argument as it's the region
+			} else {
+				printError(nil, "Error: --region requires a region argument")
+				os.Exit(1)
+			}
+		case "--timeout":
+			if i+1 < len(args) {
+				var err error
+				timeout, err = time.ParseDuration(args[i+1])
+				if err != nil || timeout <= 0 {
+					printError(nil, "Error: invalid --timeout '%s': expected a duration like 30s or 2m", args[i+1])
+					os.Exit(1)
+				}
+				i++ // Skip the next argument as it's the duration
+			} else {
+				printError(nil, "Error: --timeout requires a duration argument (e.g. 30s)")
+				os.Exit(1)
+			}
+		case "--retry-failed":
+			if i+1 < len(args) {
+				var err error
+				retryFailedInterval, err = time.ParseDuration(args[i+1])
+				if err != nil || retryFailedInterval <= 0 {
+					printError(nil, "Error: invalid --retry-failed '%s': expected a duration like 30s or 2m", args[i+1])
+					os.Exit(1)
+				}
+				i++ // Skip the next argument as it's the duration
+			} else {
+				printError(nil, "Error: --retry-failed requires a duration argument (e.g. 30s)")
+				os.Exit(1)
+			}
+		case "--adopt":
+			if i+1 < len(args) {
+				pid, err := strconv.Atoi(args[i+1])
+				if err != nil || pid <= 0 {
+					printError(nil, "Error: invalid --adopt '%s': expected a process ID", args[i+1])
+					os.Exit(1)
+				}
+				adoptPID = pid
+				i++ // Skip the next argument as it's the process ID
+			} else {
+				printError(nil, "Error: --adopt requires a process ID argument")
+				os.Exit(1)
+			}
+		case "--suffixes":
+			if i+1 < len(args) {
+				suffixSpec = args[i+1]
+				i++ // Skip the next argument as it's the suffix spec
+			} else {
+				printError(nil, "Error: --suffixes requires a spec like url=_URL,user=_USERNAME,pass=_PASSWORD")
+				os.Exit(1)
+			}
+		case "--git-askpass":
+			if i+1 < len(args) {
+				gitAskpass = args[i+1]
+				i++ // Skip the next argument as it's the variable name
+			} else {
+				printError(nil, "Error: --git-askpass requires the name of a git multi-credential variable")
+				os.Exit(1)
+			}
+		case "--inherit-only-secrets":
+			inheritOnlySecrets = true
+		case "--env-allow":
+			if i+1 < len(args) {
+				envAllow = append(envAllow, parseEnvAllow(args[i+1])...)
+				i++ // Skip the next argument as it's the variable list
+			} else {
+				printError(nil, "Error: --env-allow requires a comma-separated list of variable names")
 				os.Exit(1)
 			}
+		case "--only":
+			if i+1 < len(args) {
+				onlyPrefixes = append(onlyPrefixes, parseEnvAllow(args[i+1])...)
+				i++ // Skip the next argument as it's the prefix list
+			} else {
+				printError(nil, "Error: --only requires a comma-separated list of variable name prefixes")
+				os.Exit(1)
+			}
+		case "--require-file":
+			if i+1 < len(args) {
+				requireFile = args[i+1]
+				i++ // Skip the next argument as it's the sentinel file path
+			} else {
+				printError(nil, "Error: --require-file requires a file path argument")
+				os.Exit(1)
+			}
+		case "--scrub-output":
+			scrubOutput = true
+		case "--scrub-main-output":
+			scrubOutput, scrubMainOutput = true, true
+		case "--print-command":
+			printCmd = true
+		case "--preview":
+			preview = true
+		case "--dry-run":
+			dryRun = true
+		case "--lazy":
+			lazy = true
+		case "--cache-stats":
+			cacheStats = true
+		case "--check-backends":
+			checkBackends = true
+		case "--json-errors":
+			// Already applied before flag parsing so flag errors are reported as JSON too
 		case "--store":
 			// Handle store command immediately
 			handleStore()
@@ -126,29 +569,88 @@ func main() {
 		}
 	}
 
-	if len(filteredArgs) < 1 && !stdout {
+	// -o followed by other flags takes the address from the remaining arguments
+	if stdout && secretAddress == "" {
+		if len(filteredArgs) < 1 {
+			printError(nil, "Error: -o/--stdout requires a secret address argument")
+			os.Exit(1)
+		}
+		secretAddress = filteredArgs[0]
+		filteredArgs = filteredArgs[1:]
+	}
+
+	if format != "" && format != "plain" && format != "json" {
+		printError(nil, "Error: unsupported --format '%s' (supported: plain, json)", format)
+		os.Exit(1)
+	}
+	if dumpFormat != "" && dumpFormat != "json" {
+		printError(nil, "Error: unsupported --dump format '%s' (supported: json)", dumpFormat)
+		os.Exit(1)
+	}
+
+	if len(envAllow) > 0 && !inheritOnlySecrets {
+		printError(nil, "Error: --env-allow requires --inherit-only-secrets")
+		os.Exit(1)
+	}
+
+	if len(filteredArgs) < 1 && !stdout && dumpFormat == "" && shellSyntax == "" && !dryRun && rotationAddress == "" && writeEnvPath == "" && tfvarsPath == "" && jsonOutputPath == "" && maskFilePath == "" && pgpassPath == "" && myCnfPath == "" && resolveMapPath == "" && len(templateSpecs) == 0 && adoptPID == 0 && !preview {
 		showHelp(binaryName)
 		os.Exit(1)
 	}
 
-	// Load .env file early (before mappings parsing)
-	if !noEnv {
-		envFilePath := envFile
-		if envFilePath == "" {
-			envFilePath = ".env" // Default to .env in current directory
+	// Without the sentinel file, run the command with the environment untouched
+	if !sentinelPresent(requireFile) {
+		debugLog("Sentinel file %s not found, skipping secret resolution", requireFile)
+		_, plainCmdStart := mappings.ParseMappingsFromArgs(append([]string{os.Args[0]}, filteredArgs...))
+		if plainCmdStart > 0 {
+			plainCmdStart--
+		}
+		if plainCmdStart >= len(filteredArgs) {
+			showHelp(binaryName)
+			os.Exit(1)
 		}
+		executil.ExecuteCommandWithHooks(filteredArgs[plainCmdStart:], os.Environ(), executil.Options{
+			PreCommand:     preCommand,
+			PostCommand:    postCommand,
+			PostWhen:       postWhen,
+			NoPostOnSignal: noPostOnSignal,
+			Verbose:        verbose,
+			RunAs:          runAs,
+			DebugLog:       debugLog,
+			InfoLog:        infoLog,
+		})
+		return
+	}
 
-		count, err := env.LoadAndSetEnvFileOverride(envFilePath)
-		if err != nil {
-			// Only show error if a specific file was requested
-			if envFile != "" {
-				fmt.Fprintf(os.Stderr, "Error loading env file %s: %v\n", envFilePath, err)
-				os.Exit(1)
+	// Load .env file(s) early (before mappings parsing)
+	if !noEnv {
+		if len(envFiles) == 0 {
+			// Default to .env in current directory, a missing file is not an error
+			if checkBackends && fileExists(".env") {
+				checkEnvFileBackends([]string{".env"})
+			}
+			count, err := env.LoadAndSetEnvFileOverride(".env")
+			if err != nil {
+				debugLog("No .env file found at .env")
+			} else {
+				debugLog("Loaded %d variables from .env", count)
 			}
-			// Default .env file missing is not an error
-			debugLog("No .env file found at %s", envFilePath)
 		} else {
-			debugLog("Loaded %d variables from %s", count, envFilePath)
+			// Explicit files and glob patterns must exist, later files override earlier ones
+			envFilePaths, err := env.ExpandEnvFilePatterns(envFiles)
+			if err != nil {
+				printError(err, "Error loading env file: %v", err)
+				os.Exit(1)
+			}
+			if checkBackends {
+				checkEnvFileBackends(envFilePaths)
+			}
+			count, err := env.LoadAndSetEnvFilesOverride(envFilePaths)
+			if err != nil {
+				printError(err, "Error loading env file %v", err)
+				os.Exit(1)
+			}
+			debugLog("Loaded %d variables from %v", count, envFilePaths)
 		}
 	}
 
@@ -161,59 +663,305 @@ func main() {
 	}
 
 	debugLog("Parsed mappings: %+v, command starts at arg %d", mappingMap, cmdStart)
+	if err := mappings.CheckMappings(mappingMap); err != nil {
+		printError(err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	// Handle secretinit scripts (files starting with "#!/usr/bin/env secretinit")
+	if cmdStart < len(filteredArgs) && env.IsScriptFile(filteredArgs[cmdStart]) {
+		scriptPath := filteredArgs[cmdStart]
+		script, err := env.LoadScriptFile(scriptPath)
+		if err != nil {
+			printError(err, "Error loading script %s: %v", scriptPath, err)
+			os.Exit(1)
+		}
+
+		// Script declarations override existing environment variables (same as .env files)
+		for key, value := range script.Vars {
+			os.Setenv(key, value)
+		}
+
+		// Replace the script path with the script command, keeping any extra arguments
+		scriptArgs := append(executil.ParseCommandLine(script.Command), filteredArgs[cmdStart+1:]...)
+		filteredArgs = append(filteredArgs[:cmdStart], scriptArgs...)
+		debugLog("Loaded %d variables from script %s, command: %v", len(script.Vars), scriptPath, scriptArgs)
+	}
+
+	// Select each matrix variable's address for the active region, overriding env files and scripts
+	if regionMatrixPath != "" {
+		if region == "" {
+			printError(nil, "Error: --region-matrix requires --region")
+			os.Exit(1)
+		}
+		matrix, err := env.LoadRegionMatrix(regionMatrixPath)
+		if err != nil {
+			printError(err, "Error loading region matrix %s: %v", regionMatrixPath, err)
+			os.Exit(1)
+		}
+		addresses, err := matrix.Select(region)
+		if err != nil {
+			printError(err, "Error: %v", err)
+			os.Exit(1)
+		}
+		for key, address := range addresses {
+			os.Setenv(key, address)
+		}
+		debugLog("Selected %d secret addresses for region %s from %s", len(addresses), region, regionMatrixPath)
+	}
+
+	// Expire cached backend values after SECRETINIT_CACHE_TTL and keep at most SECRETINIT_CACHE_MAX
+	// of them (default: never expire, unbounded)
+	cacheTTL, err := cacheTTLFromEnv()
+	if err != nil {
+		printError(err, "Error: %v", err)
+		os.Exit(1)
+	}
+	cacheMax, err := cacheMaxFromEnv()
+	if err != nil {
+		printError(err, "Error: %v", err)
+		os.Exit(1)
+	}
+	if cacheTTL > 0 || cacheMax > 0 {
+		backend.SetGlobalCache(backend.NewCacheWithLimits(cacheTTL, cacheMax))
+		debugLog("Cache entries expire after %v, at most %d entries (0: never, unbounded)", cacheTTL, cacheMax)
+	}
+
+	// Keep the cache in an encrypted file across runs with SECRETINIT_CACHE_FILE and SECRETINIT_CACHE_KEY
+	if err := persistCacheFromEnv(cacheTTL); err != nil {
+		if !errors.Is(err, backend.ErrCacheFileDecrypt) && !errors.Is(err, backend.ErrCacheFileLocked) {
+			printError(err, "Error: %v", err)
+			os.Exit(1)
+		}
+		logging.Printf(logging.LevelWarn, "Warning: %v; caching in memory only", err)
+	}
+
+	// Name the variables git multi-credential addresses expand to (default: _URL, _USER, _PASS, _HOST, _PROTOCOL)
+	if suffixSpec == "" {
+		suffixSpec = os.Getenv("SECRETINIT_SUFFIXES")
+	}
+	suffixes, err := processor.ParseSuffixes(suffixSpec)
+	if err != nil {
+		printError(err, "Error: invalid suffixes: %v", err)
+		os.Exit(1)
+	}
+
+	// Bound secret resolution with --timeout (default: no limit); Ctrl-C and SIGTERM cancel it too
+	ctx, cancelResolution := resolutionContext(timeout)
+	defer cancelResolution()
+
+	// Resolve the secrets of a running process, with -o naming the variable to print
+	if adoptPID != 0 {
+		os.Exit(adoptProcess(ctx, os.Stdout, adoptPID, secretAddress, suffixes, timeout))
+	}
 
 	// Handle -o/--stdout flag
 	if stdout {
-		value, err := processor.ProcessSingleSecret(secretAddress)
+		value, err := processor.ProcessSingleSecretCtx(ctx, secretAddress)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error processing secret: %v\n", err)
-			os.Exit(1)
+			printResolutionError(err, "Error processing secret: %v", timeout)
+			os.Exit(exitCodeForError(err))
+		}
+		if format == "json" {
+			value, err = output.FormatValueJSON(value)
+			if err != nil {
+				printError(err, "Error formatting secret as JSON: %v", err)
+				os.Exit(1)
+			}
 		}
 		fmt.Println(value)
 		return
 	}
 
+	// Handle --detect-rotation: exit 0 when unchanged, exitSecretRotated when the value changed
+	if rotationAddress != "" {
+		os.Exit(detectRotation(ctx, rotationAddress, rotationState, timeout))
+	}
+
+	// Load the environment schema early so an invalid schema fails before any secret is fetched
+	var schema *env.Schema
+	if checkSchema != "" {
+		var err error
+		schema, err = env.LoadSchema(checkSchema)
+		if err != nil {
+			printError(err, "Error loading schema %s: %v", checkSchema, err)
+			os.Exit(1)
+		}
+	}
+
 	// Scan environment variables for the secretinit: prefix
 	secretEnvVars := env.ScanSecretEnvVars()
 
+	// Add the secret catalog, variables set inline take precedence
+	if secretsFile != "" {
+		catalog, err := env.LoadSecretsFile(secretsFile)
+		if err != nil {
+			printError(err, "Error loading secrets file %s: %v", secretsFile, err)
+			os.Exit(1)
+		}
+		secretEnvVars = env.MergeSecretVars(secretEnvVars, catalog)
+		debugLog("Loaded %d secret addresses from %s", len(catalog), secretsFile)
+	}
+
+	// Only resolve the selected variables, the others keep their secretinit: value for a nested call
+	if len(onlyPrefixes) > 0 {
+		total := len(secretEnvVars)
+		secretEnvVars = env.FilterSecretVars(secretEnvVars, onlyPrefixes)
+		debugLog("Resolving %d of %d secret variables matching --only %s", len(secretEnvVars), total, strings.Join(onlyPrefixes, ","))
+	}
+
+	if keepFailed && !continueOnError {
+		printError(nil, "Error: --keep-failed requires --continue-on-error")
+		os.Exit(1)
+	}
+	if retryFailedInterval > 0 && (!continueOnError || reloadSignal == nil) {
+		printError(nil, "Error: --retry-failed requires --continue-on-error and --reload-signal")
+		os.Exit(1)
+	}
+
+	// Every --require-keys variable must be one that is resolved, or its check would silently never run
+	for varName := range requiredKeys {
+		if _, exists := secretEnvVars[varName]; !exists {
+			printError(nil, "Error: --require-keys names %s, which is not a secretinit: variable being resolved", varName)
+			os.Exit(1)
+		}
+	}
+
+	// Validate addresses without contacting any backend
+	if dryRun {
+		os.Exit(reportDryRun(ctx, secretEnvVars, requiredKeys, suffixes, timeout))
+	}
+
 	// Create processor with only needed backends
 	proc, err := processor.NewProcessorForSecrets(secretEnvVars)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error initializing processor: %v\n", err)
-		os.Exit(1)
+		printError(err, "Error initializing processor: %v", err)
+		os.Exit(exitCodeForError(err))
+	}
+	proc.SetSuffixes(suffixes)
+	proc.SetRequiredKeys(requiredKeys)
+	proc.SetNestedMode(nestedMode)
+	if keepFailed {
+		proc.SetErrorMode(processor.ErrorModeKeep)
+	} else if continueOnError {
+		proc.SetErrorMode(processor.ErrorModeRemove)
+	}
+
+	// Load fallback values used when a secret fails to resolve
+	if defaultsFile != "" {
+		defaults, err := env.LoadEnvFile(defaultsFile)
+		if err != nil {
+			printError(err, "Error loading defaults file %s: %v", defaultsFile, err)
+			os.Exit(1)
+		}
+		proc.SetDefaults(defaults)
+		debugLog("Loaded %d default values from %s", len(defaults), defaultsFile)
 	}
 
 	// Process secrets
-	retrievedSecrets, err := proc.ProcessSecrets(secretEnvVars)
+	retrievedSecrets, err := proc.ProcessSecretsCtx(ctx, secretEnvVars)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error processing secrets: %v\n", err)
-		os.Exit(1)
+		printResolutionError(err, "Error processing secrets: %v", timeout)
+		os.Exit(exitCodeForError(err))
+	}
+	cancelResolution() // Let Ctrl-C and SIGTERM reach the main command's signal forwarding again
+	if cacheStats {
+		printCacheStats(proc.GetCacheStats())
 	}
 
 	// Prepare the environment for the new process
-	newEnv := []string{} // Start with empty environment
-
 	// Copy current environment, excluding processed secret variables
 	// This is important for git multi-credential mode: prevents leaving original
-	// "secretinit:git:..." variables behind when they expand to multiple *_URL, *_USER, *_PASS vars
-	for _, envVar := range os.Environ() {
-		parts := strings.SplitN(envVar, "=", 2)
-		if len(parts) == 2 {
-			varName := parts[0]
-			// Skip variables that were processed (have secretinit: prefix)
-			if _, wasProcessed := secretEnvVars[varName]; !wasProcessed {
-				newEnv = append(newEnv, envVar)
-			}
-		}
-	}
+	// "secretinit:git:..." variables behind when they expand to multiple *_URL, *_USER, *_PASS, ... vars
+	// With --inherit-only-secrets only a minimal set (plus --env-allow) is copied
+	newEnv := inheritedEnv(os.Environ(), secretEnvVars, inheritOnlySecrets, envAllow)
 
 	// Add resolved secrets to environment
 	for key, value := range retrievedSecrets {
 		newEnv = append(newEnv, fmt.Sprintf("%s=%s", key, value))
 	}
 
-	// Apply command-line mappings
-	newEnv = mappings.ApplyMappingsToEnv(newEnv, mappingMap)
+	// Print the resolved secrets instead of executing a command
+	if dumpFormat == "json" {
+		if err := output.WriteJSONObject(os.Stdout, retrievedSecrets); err != nil {
+			printError(err, "Error: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Print export statements for eval "$(secretinit --shell)" instead of executing a command
+	if shellSyntax != "" {
+		if err := output.WriteShellExports(os.Stdout, retrievedSecrets, shellSyntax); err != nil {
+			printError(err, "Error: %v", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Write the secret files, and again on each --reload-signal
+	files := &secretFiles{
+		envPath:         writeEnvPath,
+		tfvarsPath:      tfvarsPath,
+		jsonOutputPath:  jsonOutputPath,
+		maskFilePath:    maskFilePath,
+		pgpassPath:      pgpassPath,
+		myCnfPath:       myCnfPath,
+		resolveMapPath:  resolveMapPath,
+		templateSpecs:   templateSpecs,
+		systemdCredsDir: systemdCredsDir,
+		suffixes:        suffixes,
+		owner:           credsOwner,
+	}
+	if err := files.write(retrievedSecrets, false); err != nil {
+		printError(err, "Error: %v", err)
+		os.Exit(1)
+	}
+
+	// Secret files can be written without a command to run afterwards
+	if files.any() && cmdStart >= len(filteredArgs) {
+		return
+	}
+
+	// Point the child at the systemd credential files
+	if systemdCredsDir != "" {
+		newEnv = append(newEnv, "CREDENTIALS_DIRECTORY="+systemdCredsDir)
+	}
+
+	// Apply command-line mappings, failing on keys (DB_USER=DBCREDS:::username) the secrets lack
+	if err := mappings.CheckMappingKeys(newEnv, mappingMap); err != nil {
+		printError(err, "Error: %v", err)
+		os.Exit(1)
+	}
+	newEnv, maskedSecrets := applyMappings(newEnv, mappingMap, retrievedSecrets)
+
+	// Load the post-resolution env file, whose values can reference resolved secrets
+	if postEnvFile != "" {
+		newEnv, err = env.ApplyPostEnvFile(postEnvFile, newEnv)
+		if err != nil {
+			printError(err, "Error loading post env file %s: %v", postEnvFile, err)
+			os.Exit(1)
+		}
+		debugLog("Applied post env file %s", postEnvFile)
+	}
+
+	// Validate the final environment against the schema before launching
+	if schema != nil {
+		if errs := schema.Validate(newEnv); len(errs) > 0 {
+			for _, err := range errs {
+				printError(err, "Error: %v", err)
+			}
+			printError(nil, "Environment check against %s failed, not starting command", checkSchema)
+			os.Exit(1)
+		}
+		debugLog("Environment check against %s passed", checkSchema)
+	}
+
+	// Show how resolution changes the environment instead of running the command
+	if preview {
+		printPreview(os.Stdout, diffEnv(os.Environ(), newEnv, backend.ShortHash))
+		return
+	}
 
 	// Validate we have a command to execute
 	if cmdStart >= len(filteredArgs) {
@@ -221,14 +969,369 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Show what would be executed instead of running it
+	if printCmd {
+		printCommand(os.Stdout, filteredArgs[cmdStart:], newEnv, maskedSecrets)
+		return
+	}
+
 	// Execute the command with pre/post hooks
 	debugLog("Executing command: %v", filteredArgs[cmdStart:])
-	executil.ExecuteCommandWithHooks(filteredArgs[cmdStart:], newEnv, preCommand, postCommand, debugLog, infoLog)
+	execOpts := executil.Options{
+		PreCommand:     preCommand,
+		PostCommand:    postCommand,
+		PostWhen:       postWhen,
+		NoPostOnSignal: noPostOnSignal,
+		Verbose:        verbose,
+		RunAs:          runAs,
+		DebugLog:       debugLog,
+		InfoLog:        infoLog,
+	}
+	// Resolve the secrets again and rewrite the secret files when the reload signal arrives
+	if reloadSignal != nil {
+		// Reloads and --retry-failed attempts share the processor, one at a time
+		var procMutex sync.Mutex
+		resolveCtx := func() (context.Context, context.CancelFunc) {
+			if timeout > 0 {
+				return context.WithTimeout(context.Background(), timeout)
+			}
+			return context.WithCancel(context.Background())
+		}
+		execOpts.ReloadSignal = reloadSignal
+		execOpts.Reload = func() (map[string]string, error) {
+			procMutex.Lock()
+			defer procMutex.Unlock()
+			proc.ClearCache()
+			reloadCtx, cancel := resolveCtx()
+			defer cancel()
+			secrets, err := proc.ProcessSecretsCtx(reloadCtx, secretEnvVars)
+			if err != nil {
+				return nil, err
+			}
+			if err := files.write(secrets, true); err != nil {
+				return nil, err
+			}
+			return reloadedMaskedSecrets(mappingMap, secrets), nil
+		}
+		// Retry the variables --continue-on-error skipped and reload once one of them resolves
+		if retryFailedInterval > 0 && len(proc.FailedVariables()) > 0 {
+			execOpts.RetryInterval = retryFailedInterval
+			execOpts.RetryFailed = func() (bool, bool) {
+				procMutex.Lock()
+				defer procMutex.Unlock()
+				before := proc.FailedVariables()
+				retryCtx, cancel := resolveCtx()
+				defer cancel()
+				if _, err := proc.ProcessSecretsCtx(retryCtx, secretEnvVars); err != nil {
+					debugLog("Retrying failed secrets: %v", err)
+					return false, true
+				}
+				after := proc.FailedVariables()
+				resolved := slices.ContainsFunc(before, func(name string) bool { return !slices.Contains(after, name) })
+				return resolved, len(after) > 0
+			}
+		}
+	}
+	if scrubOutput {
+		for _, value := range maskedSecrets {
+			execOpts.ScrubValues = append(execOpts.ScrubValues, value)
+		}
+		execOpts.ScrubMain = scrubMainOutput
+	}
+	// Hand the main command references instead of values, served only when it asks
+	if lazy {
+		newEnv, execOpts.LazySecrets = executil.LazyEnv(newEnv, maskedSecrets)
+		debugLog("Serving %d secrets lazily on fd %d", len(execOpts.LazySecrets), executil.LazyFD)
+	}
+	// Serve a git credential through a GIT_ASKPASS helper instead of a password variable
+	if gitAskpass != "" {
+		passVar := gitAskpass + suffixes.Pass
+		password, ok := retrievedSecrets[passVar]
+		if !ok {
+			printError(nil, "Error: --git-askpass %s: no %s variable was resolved (expected %s=secretinit:git:URL)", gitAskpass, passVar, gitAskpass)
+			os.Exit(1)
+		}
+		helper, cleanup, err := executil.WriteAskpass(retrievedSecrets[gitAskpass+suffixes.User], password, runAs)
+		if err != nil {
+			printError(err, "Error: %v", err)
+			os.Exit(1)
+		}
+		newEnv = executil.AskpassEnv(newEnv, passVar, helper)
+		execOpts.Cleanup = cleanup
+		debugLog("Serving %s through GIT_ASKPASS helper %s", passVar, helper)
+	}
+	executil.ExecuteCommandWithHooks(filteredArgs[cmdStart:], newEnv, execOpts)
+}
+
+// printCacheStats prints the number of cached entries per backend for --cache-stats to stderr
+func printCacheStats(stats map[string]int) {
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Cache entries per backend:\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-10s %d\n", name, stats[name])
+	}
+}
+
+// reportDryRun prints a per-variable OK/ERROR report for --dry-run to stderr.
+// Only the variables named by --require-keys are retrieved, to check the shape of their JSON;
+// nothing else contacts a backend. Returns the process exit code.
+func reportDryRun(ctx context.Context, secretEnvVars map[string]string, requiredKeys map[string][]string, suffixes processor.Suffixes, timeout time.Duration) int {
+	results := processor.ValidateSecrets(secretEnvVars)
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "ERROR %s: %v\n", result.Variable, result.Err)
+		} else {
+			fmt.Fprintf(os.Stderr, "OK    %s (%s)\n", result.Variable, parser.RedactAddress(result.Address))
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Checked %d secret variables, %d invalid\n", len(results), failed)
+
+	if failed == 0 && len(requiredKeys) > 0 {
+		if err := checkRequiredKeysOnly(ctx, secretEnvVars, requiredKeys, suffixes); err != nil {
+			printResolutionError(err, "ERROR %v", timeout)
+			return exitCodeForError(err)
+		}
+		fmt.Fprintf(os.Stderr, "Checked required JSON keys of %d secret variables\n", len(requiredKeys))
+	}
+
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkRequiredKeysOnly resolves just the variables named by --require-keys and checks their keys
+func checkRequiredKeysOnly(ctx context.Context, secretEnvVars map[string]string, requiredKeys map[string][]string, suffixes processor.Suffixes) error {
+	subset := make(map[string]string, len(requiredKeys))
+	for varName := range requiredKeys {
+		subset[varName] = secretEnvVars[varName]
+	}
+	proc, err := processor.NewProcessorForSecrets(subset)
+	if err != nil {
+		return err
+	}
+	proc.SetSuffixes(suffixes)
+	proc.SetRequiredKeys(requiredKeys)
+	_, err = proc.ProcessSecretsCtx(ctx, subset)
+	return err
+}
+
+// detectRotation resolves address and compares its hash against the stored state file
+// (default: a per-address file in the user cache directory). Returns the process exit code.
+func detectRotation(ctx context.Context, address, statePath string, timeout time.Duration) int {
+	value, err := processor.ProcessSingleSecretCtx(ctx, address)
+	if err != nil {
+		printResolutionError(err, "Error processing secret: %v", timeout)
+		return exitCodeForError(err)
+	}
+
+	if statePath == "" {
+		statePath, err = backend.RotationStatePath(address)
+		if err != nil {
+			printError(err, "Error: %v", err)
+			return 1
+		}
+	}
+
+	rotated, err := backend.DetectRotation(statePath, value)
+	if err != nil {
+		printError(err, "Error: %v", err)
+		return 1
+	}
+	if rotated {
+		infoLog("Secret %s changed since the last check (state: %s)", parser.RedactAddress(address), statePath)
+		return exitSecretRotated
+	}
+	debugLog("Secret %s unchanged (state: %s)", parser.RedactAddress(address), statePath)
+	return 0
+}
+
+// printResolutionError prints a secret resolution error, calling out a --timeout that expired
+func printResolutionError(err error, format string, timeout time.Duration) {
+	if errors.Is(err, context.DeadlineExceeded) {
+		printError(err, "Error: secret resolution did not finish within --timeout %v: %v", timeout, err)
+		return
+	}
+	if errors.Is(err, context.Canceled) {
+		printError(err, "Error: secret resolution interrupted: %v", err)
+		return
+	}
+	printError(err, format, err)
+}
+
+// cacheTTLFromEnv parses SECRETINIT_CACHE_TTL (e.g. "10m"). Unset means entries never expire.
+func cacheTTLFromEnv() (time.Duration, error) {
+	raw := os.Getenv("SECRETINIT_CACHE_TTL")
+	if raw == "" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl < 0 {
+		return 0, fmt.Errorf("invalid SECRETINIT_CACHE_TTL '%s': expected a duration like 30s, 10m or 1h", raw)
+	}
+	return ttl, nil
+}
+
+// defaultCacheFileMaxAge is how long SECRETINIT_CACHE_FILE keeps entries when SECRETINIT_CACHE_TTL is unset
+const defaultCacheFileMaxAge = 10 * time.Minute
+
+// persistCacheFromEnv makes the global cache persist to SECRETINIT_CACHE_FILE (a leading ~/ is the
+// home directory), encrypted with the SECRETINIT_CACHE_KEY passphrase. Entries are kept for the
+// cache TTL, or defaultCacheFileMaxAge without one. Does nothing when SECRETINIT_CACHE_FILE is unset.
+func persistCacheFromEnv(cacheTTL time.Duration) error {
+	path := os.Getenv("SECRETINIT_CACHE_FILE")
+	if path == "" {
+		return nil
+	}
+	if rest, found := strings.CutPrefix(path, "~/"); found {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to expand SECRETINIT_CACHE_FILE: %w", err)
+		}
+		path = filepath.Join(home, rest)
+	}
+	passphrase := os.Getenv("SECRETINIT_CACHE_KEY")
+	if passphrase == "" {
+		return fmt.Errorf("SECRETINIT_CACHE_FILE requires SECRETINIT_CACHE_KEY, the passphrase the cache is encrypted with")
+	}
+
+	maxAge := cacheTTL
+	if maxAge <= 0 {
+		maxAge = defaultCacheFileMaxAge
+	}
+	if err := backend.GetGlobalCache().PersistTo(path, passphrase, maxAge); err != nil {
+		return err
+	}
+	debugLog("Persisting the cache to %s, entries kept for at most %v", path, maxAge)
+	return nil
+}
+
+// cacheMaxFromEnv parses SECRETINIT_CACHE_MAX, the most entries the cache keeps. Unset or 0 means unbounded.
+func cacheMaxFromEnv() (int, error) {
+	raw := os.Getenv("SECRETINIT_CACHE_MAX")
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid SECRETINIT_CACHE_MAX '%s': expected a number of entries (0 for unbounded)", raw)
+	}
+	return n, nil
+}
+
+// applyMappings applies mappingMap to environ. Along with the new environment it returns the values
+// masked like secrets: the secrets and the values mapped out of them (DB_PASS=DBCREDS:::password,
+// TOKEN_B64=TOKEN|base64).
+func applyMappings(environ []string, mappingMap, secrets map[string]string) ([]string, map[string]string) {
+	environ, mappedSecrets := mappings.ApplyMappingsToEnv(environ, mappingMap, secrets)
+	masked := make(map[string]string, len(secrets)+len(mappedSecrets))
+	maps.Copy(masked, secrets)
+	maps.Copy(masked, mappedSecrets)
+	return environ, masked
+}
+
+// reloadedMaskedSecrets returns the values to mask after a reload resolved secrets, mapped the same
+// way as at startup (see applyMappings)
+func reloadedMaskedSecrets(mappingMap, secrets map[string]string) map[string]string {
+	environ := make([]string, 0, len(secrets))
+	for name, value := range secrets {
+		environ = append(environ, name+"="+value)
+	}
+	_, masked := applyMappings(environ, mappingMap, secrets)
+	return masked
+}
+
+// hasArg reports whether args contains flag
+func hasArg(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// printVersionJSON prints version and build information as JSON for --version --json
+func printVersionJSON(binaryName string) {
+	info := struct {
+		Name     string   `json:"name"`
+		Version  string   `json:"version"`
+		Build    string   `json:"build"`
+		Backends []string `json:"backends"`
+	}{
+		Name:     binaryName,
+		Version:  version,
+		Build:    processor.BuildVariant(),
+		Backends: processor.AvailableBackends(),
+	}
+	data, _ := json.Marshal(info)
+	fmt.Println(string(data))
+}
+
+// checkEnvFileBackends exits if any env file references a backend that isn't compiled into this build
+func checkEnvFileBackends(paths []string) {
+	available := processor.RegisterAllBackends()
+	for _, path := range paths {
+		err := env.CheckEnvFileBackends(path, func(name string) bool {
+			_, exists := available[name]
+			return exists
+		})
+		if err != nil {
+			printError(err, "Error checking env file backends (build: %s):\n%v", processor.BuildVariant(), err)
+			os.Exit(exitBackendUnavailable)
+		}
+	}
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// sentinelPresent reports whether secrets should be resolved for --require-file.
+// An empty path means no sentinel is required.
+func sentinelPresent(path string) bool {
+	return path == "" || fileExists(path)
+}
+
+// handleLint validates every secretinit: reference in a directory tree (default: current directory).
+// Returns the process exit code: 0 when all references are valid, 1 otherwise.
+func handleLint(args []string) int {
+	dir := "."
+	if len(args) > 0 {
+		dir = args[0]
+	}
+
+	findings, checked, err := lint.LintDir(dir)
+	if err != nil {
+		printError(err, "Error scanning %s: %v", dir, err)
+		return 1
+	}
+
+	for _, finding := range findings {
+		fmt.Println(finding)
+	}
+	fmt.Fprintf(os.Stderr, "Checked %d secret references, %d invalid\n", checked, len(findings))
+
+	if len(findings) > 0 {
+		return 1
+	}
+	return 0
 }
 
 // handleStore manages the storage of credentials using git credential helper.
 func handleStore() {
 	var url, user string
+	var opts backend.StoreOptions
 
 	for i, arg := range os.Args {
 		if arg == "--url" && i+1 < len(os.Args) {
@@ -237,13 +1340,21 @@ func handleStore() {
 		if arg == "--user" && i+1 < len(os.Args) {
 			user = os.Args[i+1]
 		}
+		if arg == "--url-prompt" && i+1 < len(os.Args) {
+			opts.URLPrompt = os.Args[i+1]
+		}
+		if arg == "--user-prompt" && i+1 < len(os.Args) {
+			opts.UsernamePrompt = os.Args[i+1]
+		}
+		if arg == "--no-prompt" {
+			opts.NoPrompt = true
+		}
 	}
 
-	// Use git backend to store credentials (will prompt for URL if empty)
+	// Use git backend to store credentials (will prompt for URL if empty, unless --no-prompt)
 	gitBackend := &backend.GitBackend{}
-	if err := gitBackend.StoreCredential(url, user); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to store credentials: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Make sure you have a git credential helper configured\n")
+	if err := gitBackend.StoreCredentialWithOptions(url, user, opts); err != nil {
+		printError(err, "Failed to store credentials: %v\nMake sure you have a git credential helper configured", err)
 		os.Exit(1)
 	}
 
@@ -253,21 +1364,88 @@ func handleStore() {
 // showHelp displays the help message for secretinit
 func showHelp(binaryName string) {
 	fmt.Fprintf(os.Stderr, "Usage: %s [-h|--help] [-v|--version] [-o|--stdout SECRET_ADDRESS] [-e|--env-file PATH] [-n|--no-env] [--store --url URL --user USER] [--mappings|-m TARGET=SOURCE,TARGET2=SOURCE2] <command> [args...]\n", binaryName)
+	fmt.Fprintf(os.Stderr, "       %s lint [DIR]\n", binaryName)
+	fmt.Fprintf(os.Stderr, "       %s backends\n", binaryName)
 	fmt.Fprintf(os.Stderr, "\nOptions:\n")
 	fmt.Fprintf(os.Stderr, "  -h, --help              Show this help message\n")
-	fmt.Fprintf(os.Stderr, "  -v, --version           Show version information\n")
+	fmt.Fprintf(os.Stderr, "  -v, --version           Show version information (add --json for build variant and backends)\n")
+	fmt.Fprintf(os.Stderr, "  --list-backends         Show the build variant and the backends compiled into it\n")
 	fmt.Fprintf(os.Stderr, "  -o, --stdout ADDRESS    Output a single secret to stdout\n")
-	fmt.Fprintf(os.Stderr, "  -e, --env-file PATH     Load environment variables from custom .env file\n")
+	fmt.Fprintf(os.Stderr, "  --format FORMAT         Output format for -o: plain (default) or json\n")
+	fmt.Fprintf(os.Stderr, "  --dump json             Print resolved secrets as a JSON object instead of running a command\n")
+	fmt.Fprintf(os.Stderr, "  --shell [fish]          Print export statements for eval \"$(%s --shell)\" instead of running a command\n", binaryName)
+	fmt.Fprintf(os.Stderr, "  -e, --env-file PATH     Load environment variables from a .env, .json or .toml file (glob patterns and repeats allowed)\n")
 	fmt.Fprintf(os.Stderr, "  -n, --no-env            Disable automatic .env file loading\n")
+	fmt.Fprintf(os.Stderr, "  --check-backends        Fail while loading env files if a secretinit: value needs a backend missing from this build\n")
 	fmt.Fprintf(os.Stderr, "  --store                 Store credentials using git credential helper\n")
 	fmt.Fprintf(os.Stderr, "  --url URL               URL for credential storage\n")
 	fmt.Fprintf(os.Stderr, "  --user USER             Username for credential storage\n")
-	fmt.Fprintf(os.Stderr, "  -m, --mappings MAP      Environment variable mappings\n")
+	fmt.Fprintf(os.Stderr, "  --url-prompt TEXT       Prompt shown by --store for a missing URL (default \"URL: \")\n")
+	fmt.Fprintf(os.Stderr, "  --user-prompt TEXT      Prompt shown by --store for a missing username (default \"Username: \")\n")
+	fmt.Fprintf(os.Stderr, "  --no-prompt             Make --store fail instead of prompting for missing values\n")
+	fmt.Fprintf(os.Stderr, "  --suffixes SPEC         Git multi-credential variable suffixes (default: url=_URL,user=_USER,pass=_PASS,host=_HOST,protocol=_PROTOCOL)\n")
+	fmt.Fprintf(os.Stderr, "  -m, --mappings MAP      Environment variable mappings (SOURCE|base64, |upper, |lower, |trim; TARGET:=default)\n")
 	fmt.Fprintf(os.Stderr, "  --pre COMMAND           Execute command before main process\n")
-	fmt.Fprintf(os.Stderr, "  --post COMMAND          Execute command after main process (always runs)\n")
+	fmt.Fprintf(os.Stderr, "  --post COMMAND          Execute command after main process (always runs unless limited below)\n")
+	fmt.Fprintf(os.Stderr, "  --post-on-success       Only run --post when the main command exits 0\n")
+	fmt.Fprintf(os.Stderr, "  --post-on-failure       Only run --post when the main command fails\n")
+	fmt.Fprintf(os.Stderr, "  --no-post-on-signal     Skip --post when the main command was killed by a signal\n")
+	fmt.Fprintf(os.Stderr, "  --reload-signal SIG     On SIG (SIGHUP, SIGUSR1, SIGUSR2), resolve again, rewrite secret files, then pass SIG on (Unix)\n")
+	fmt.Fprintf(os.Stderr, "                          secretinit exits with the main command's exit code either way\n")
+	fmt.Fprintf(os.Stderr, "  --check SCHEMA          Validate the final environment against a JSON schema before launching\n")
+	fmt.Fprintf(os.Stderr, "  --only PREFIXES         Only resolve secret variables starting with one of these comma-separated prefixes\n")
+	fmt.Fprintf(os.Stderr, "  --secrets-file PATH     Read NAME: address entries from PATH (inline variables take precedence)\n")
+	fmt.Fprintf(os.Stderr, "  --defaults-file PATH    Fallback values (VAR=default) for secrets that fail to resolve\n")
+	fmt.Fprintf(os.Stderr, "  --region-matrix PATH    JSON file mapping variables to a secret address per region (or \"default\")\n")
+	fmt.Fprintf(os.Stderr, "  --region REGION         Region whose addresses --region-matrix selects\n")
+	fmt.Fprintf(os.Stderr, "  --inherit-only-secrets  Pass the command only resolved secrets plus PATH, HOME, TERM and LANG\n")
+	fmt.Fprintf(os.Stderr, "  --env-allow VARS        Also keep these comma-separated variables with --inherit-only-secrets\n")
+	fmt.Fprintf(os.Stderr, "  --post-env PATH         Load a .env file after resolution; values may reference secrets as ${VAR}\n")
+	fmt.Fprintf(os.Stderr, "  --write-env PATH        Write resolved secrets to PATH (0600) in .env format (- for stdout); the command is optional\n")
+	fmt.Fprintf(os.Stderr, "  --pgpass PATH           Write database logins to PATH (0600) in PostgreSQL .pgpass format (- for stdout)\n")
+	fmt.Fprintf(os.Stderr, "  --my-cnf PATH           Write database logins to PATH (0600) as a MySQL .my.cnf option file (- for stdout)\n")
+	fmt.Fprintf(os.Stderr, "  --tfvars PATH           Write resolved secrets to PATH (0600) as Terraform string variables (- for stdout)\n")
+	fmt.Fprintf(os.Stderr, "  --json-output-file PATH Write resolved secrets to PATH (0600) as one JSON object (- for stdout)\n")
+	fmt.Fprintf(os.Stderr, "  --mask-file PATH        Write each distinct secret value on its own line for CI log masking (0600)\n")
+	fmt.Fprintf(os.Stderr, "  --resolve-map PATH      Write a JSON object of variable name to short hash of its value (- for stdout)\n")
+	fmt.Fprintf(os.Stderr, "  --template SRC[:DEST]   Render a Go template with resolved secrets to DEST (default stdout, repeatable)\n")
+	fmt.Fprintf(os.Stderr, "  --systemd-creds DIR     Write each resolved secret to DIR/NAME (0400) and set CREDENTIALS_DIRECTORY\n")
+	fmt.Fprintf(os.Stderr, "  --require-file PATH     Only resolve secrets if PATH exists, otherwise run the command untouched\n")
+	fmt.Fprintf(os.Stderr, "  --run-as UID:GID        Run the main command as another user/group after resolving secrets (Unix)\n")
+	fmt.Fprintf(os.Stderr, "  --creds-owner UID:GID   Give the files secretinit writes (--write-env, --pgpass, --systemd-creds, ...) to UID:GID\n")
+	fmt.Fprintf(os.Stderr, "  --scrub-output          Mask resolved secret values in pre and post command output\n")
+	fmt.Fprintf(os.Stderr, "  --scrub-main-output     Also mask them in the main command's output (it then writes to pipes, not the terminal)\n")
+	fmt.Fprintf(os.Stderr, "  --timeout DURATION      Abort if secret resolution takes longer than DURATION (e.g. 30s)\n")
+	fmt.Fprintf(os.Stderr, "  --adopt PID             Resolve the secretinit: variables of a running process (Linux), printing names only\n")
+	fmt.Fprintf(os.Stderr, "  --git-askpass NAME      Serve NAME's git credential via a temporary GIT_ASKPASS helper instead of NAME_PASS\n")
+	fmt.Fprintf(os.Stderr, "  --lazy                  Pass secret references and serve values over fd 3 only when the command asks (Unix)\n")
+	fmt.Fprintf(os.Stderr, "  --cache-stats           Print the number of cached entries per backend after resolution\n")
+	fmt.Fprintf(os.Stderr, "  --dry-run               Validate secret addresses and backends without retrieving anything\n")
+	fmt.Fprintf(os.Stderr, "  --continue-on-error     Warn about secrets that fail to resolve and run the command without them\n")
+	fmt.Fprintf(os.Stderr, "  --keep-failed           With --continue-on-error, keep their secretinit: value instead\n")
+	fmt.Fprintf(os.Stderr, "  --retry-failed DURATION With --reload-signal, retry those secrets in the background and reload once one resolves\n")
+	fmt.Fprintf(os.Stderr, "  --resolve-nested MODE   For values that are secretinit: addresses: warn (default), resolve or error\n")
+	fmt.Fprintf(os.Stderr, "  --require-keys VAR=K,K  Fail unless VAR resolves to JSON with these keys (repeatable; checked by --dry-run too)\n")
+	fmt.Fprintf(os.Stderr, "  --detect-rotation ADDR  Resolve ADDR and exit 50 if its value changed since the last check, 0 otherwise\n")
+	fmt.Fprintf(os.Stderr, "  --rotation-state PATH   State file holding the last hash for --detect-rotation (default: user cache dir)\n")
+	fmt.Fprintf(os.Stderr, "  --verbose               Log info messages, and the [MAIN] Running: line even when stdout is piped\n")
+	fmt.Fprintf(os.Stderr, "  --json-errors           Print fatal errors to stderr as JSON objects (error, variable, backend, code)\n")
+	fmt.Fprintf(os.Stderr, "  --preview               Show which variables resolution adds, changes or removes (hashed values)\n")
+	fmt.Fprintf(os.Stderr, "  --print-command         Resolve secrets, print the command and redacted environment, then exit\n")
 	fmt.Fprintf(os.Stderr, "\nEnvironment Variables:\n")
 	fmt.Fprintf(os.Stderr, "  SECRETINIT_MAPPINGS     Environment variable mappings (same format as -m)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_SUFFIXES     Git multi-credential suffixes (same format as --suffixes)\n")
 	fmt.Fprintf(os.Stderr, "  SECRETINIT_LOG_LEVEL    Set to DEBUG for detailed logging\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_LOG_FORMAT   Set to json for one JSON object (level, msg, ts) per log line\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_AWS_REGION   AWS region override (wins over AWS_REGION)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_AWS_ASSUME_ROLE IAM role ARN to assume for AWS requests\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_GCP_PROJECT  GCP project override (wins over GOOGLE_CLOUD_PROJECT)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_AZURE_TENANT Azure tenant override (wins over AZURE_TENANT_ID)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_GIT_CREDENTIAL_CMD Command run instead of git credential; reject/approve are passed as its last argument\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_CACHE_TTL    Default lifetime of cached backend values (e.g. 10m)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_CACHE_MAX    Most cached backend values kept, least recently used evicted first (default: unbounded)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_CACHE_FILE   Encrypted file the cache is kept in across runs (needs SECRETINIT_CACHE_KEY)\n")
+	fmt.Fprintf(os.Stderr, "  SECRETINIT_TTL_<BACKEND> Cache lifetime per backend (e.g. SECRETINIT_TTL_GIT=1h)\n")
 	fmt.Fprintf(os.Stderr, "\nExamples:\n")
 	fmt.Fprintf(os.Stderr, "  %s --store --url https://api.example.com --user myuser\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  \n")
@@ -283,10 +1461,12 @@ func showHelp(binaryName string) {
 	fmt.Fprintf(os.Stderr, "  # Environment variable mappings\n")
 	fmt.Fprintf(os.Stderr, "  %s -m \"DB_USERNAME=MYAPP_USER,DB_PASSWORD=MYAPP_PASS\" myapp arg1\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  SECRETINIT_MAPPINGS=\"DB_USERNAME=MYAPP_USER,DB_PASSWORD=MYAPP_PASS\" %s myapp arg1\n", binaryName)
+	fmt.Fprintf(os.Stderr, "  %s -m \"DB_USERNAME=MYAPP_USER,DB_SSLMODE=:require\" myapp arg1   # ':' sets a literal value\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  \n")
 	fmt.Fprintf(os.Stderr, "  # .env file support\n")
 	fmt.Fprintf(os.Stderr, "  %s myapp arg1                          # Loads .env from current directory\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  %s -e prod.env myapp arg1               # Load custom .env file\n", binaryName)
+	fmt.Fprintf(os.Stderr, "  %s -e 'config/*.env' myapp arg1         # Load all matching files in lexical order\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  %s -n myapp arg1                        # Disable .env loading\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  \n")
 	fmt.Fprintf(os.Stderr, "  # Output single secret to stdout\n")
@@ -295,6 +1475,9 @@ func showHelp(binaryName string) {
 	fmt.Fprintf(os.Stderr, "  %s --stdout \"gcp:sm:my-project/secret\"\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  %s --stdout \"azure:kv:my-vault/api-token\"\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  \n")
+	fmt.Fprintf(os.Stderr, "  # Validate all secretinit: references in a directory (for CI)\n")
+	fmt.Fprintf(os.Stderr, "  %s lint ./deploy\n", binaryName)
+	fmt.Fprintf(os.Stderr, "  \n")
 	fmt.Fprintf(os.Stderr, "  # Debug mode\n")
 	fmt.Fprintf(os.Stderr, "  SECRETINIT_LOG_LEVEL=DEBUG %s myapp arg1\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  \n")
@@ -302,12 +1485,32 @@ func showHelp(binaryName string) {
 	fmt.Fprintf(os.Stderr, "  %s --pre \"echo Starting\" --post \"echo Finished\" myapp arg1\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  %s --pre \"docker start database\" --post \"docker stop database\" test-suite\n", binaryName)
 	fmt.Fprintf(os.Stderr, "  %s --post \"cleanup.sh\" build-script\n", binaryName)
+	fmt.Fprintf(os.Stderr, "  \n")
+	fmt.Fprintf(os.Stderr, "  # Secretinit scripts (secret declarations, then a '--- command ---' section)\n")
+	fmt.Fprintf(os.Stderr, "  %s ./deploy.secretinit extra-arg         # or run the script directly via its shebang\n", binaryName)
+	fmt.Fprintf(os.Stderr, "\nExit Codes:\n")
+	fmt.Fprintf(os.Stderr, "  10               Secret retrieval failed\n")
+	fmt.Fprintf(os.Stderr, "  11               Invalid secret address\n")
+	fmt.Fprintf(os.Stderr, "  12               Backend unavailable in this build or failed to initialize\n")
+	fmt.Fprintf(os.Stderr, "  50               Secret changed since the last --detect-rotation check\n")
+	fmt.Fprintf(os.Stderr, "  other            Exit code of the launched command (1 for other secretinit errors)\n")
 	fmt.Fprintf(os.Stderr, "\nSupported Backends:\n")
 	fmt.Fprintf(os.Stderr, "  git              Git credential helper (supports multi-credential mode)\n")
 	fmt.Fprintf(os.Stderr, "  aws:sm           AWS Secrets Manager\n")
 	fmt.Fprintf(os.Stderr, "  aws:ps           AWS Parameter Store\n")
+	fmt.Fprintf(os.Stderr, "  aws:kms          AWS KMS decrypt (aws:kms:KEY:::BASE64 or :::@/path)\n")
 	fmt.Fprintf(os.Stderr, "  gcp:sm           GCP Secret Manager\n")
 	fmt.Fprintf(os.Stderr, "  azure:kv         Azure Key Vault\n")
+	fmt.Fprintf(os.Stderr, "  azure:cert       Azure Key Vault certificate as PEM (:::private_key for the key)\n")
+	fmt.Fprintf(os.Stderr, "  azure:key        Azure Key Vault public key as PEM (:::public_pem)\n")
+	fmt.Fprintf(os.Stderr, "  azure:appconfig  Azure App Configuration key (STORE/KEY[/LABEL]), resolving Key Vault references\n")
+	fmt.Fprintf(os.Stderr, "  bitwarden:item   Bitwarden item via the bw CLI (needs BW_SESSION)\n")
+	fmt.Fprintf(os.Stderr, "  remote           Remote secretinit over SSH (remote:ssh://host/ADDRESS)\n")
+	fmt.Fprintf(os.Stderr, "  wincred          Windows Credential Manager (wincred:TargetName:::username|password)\n")
+	fmt.Fprintf(os.Stderr, "  exec             External helper printing the secret (exec:/path/to/helper NAME)\n")
+	fmt.Fprintf(os.Stderr, "  auto             Secret store of the detected cloud: aws:sm, gcp:sm or azure:kv (auto:NAME/SECRET)\n")
+	fmt.Fprintf(os.Stderr, "  file             File contents, e.g. a Kubernetes token or secret volume (file:/path:::key)\n")
+	fmt.Fprintf(os.Stderr, "  csv              Row of a CSV/TSV file by first column (csv:/path/creds.csv:ROW:::column)\n")
 	fmt.Fprintf(os.Stderr, "\nGit Multi-Credential Mode:\n")
 	fmt.Fprintf(os.Stderr, "When no keyPath is specified for git backend, creates multiple variables:\n")
 	fmt.Fprintf(os.Stderr, "  export GITHUB=\"secretinit:git:https://github.com/org/repo\"\n")
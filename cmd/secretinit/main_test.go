@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSentinelPresent(t *testing.T) {
+	dir := t.TempDir()
+	present := filepath.Join(dir, "enable-secrets")
+	if err := os.WriteFile(present, nil, 0644); err != nil {
+		t.Fatalf("failed to create sentinel: %v", err)
+	}
+
+	if !sentinelPresent("") {
+		t.Error("Expected no sentinel requirement to resolve secrets")
+	}
+	if !sentinelPresent(present) {
+		t.Error("Expected present sentinel file to resolve secrets")
+	}
+	if sentinelPresent(filepath.Join(dir, "missing")) {
+		t.Error("Expected absent sentinel file to skip secret resolution")
+	}
+}
+
+func TestCacheTTLFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"10m", 10 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"ten minutes", 0, true},
+		{"-5m", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("SECRETINIT_CACHE_TTL", tt.value)
+		got, err := cacheTTLFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SECRETINIT_CACHE_TTL=%q: error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.expected {
+			t.Errorf("SECRETINIT_CACHE_TTL=%q: got %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestCacheMaxFromEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+		wantErr  bool
+	}{
+		{"", 0, false},
+		{"0", 0, false},
+		{"500", 500, false},
+		{"-1", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("SECRETINIT_CACHE_MAX", tt.value)
+		got, err := cacheMaxFromEnv()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SECRETINIT_CACHE_MAX=%q: error = %v, wantErr %v", tt.value, err, tt.wantErr)
+		}
+		if got != tt.expected {
+			t.Errorf("SECRETINIT_CACHE_MAX=%q: got %v, want %v", tt.value, got, tt.expected)
+		}
+	}
+}
+
+func TestReloadedMaskedSecrets(t *testing.T) {
+	mappingMap := map[string]string{
+		"DB_PASSWORD": "DB:::password",
+		"TOKEN_B64":   "TOKEN|base64",
+		"REGION":      ":=us-east-1",
+	}
+	reloaded := map[string]string{
+		"DB":    `{"username":"app","password":"rotated-pw"}`,
+		"TOKEN": "rotated-token",
+	}
+
+	masked := reloadedMaskedSecrets(mappingMap, reloaded)
+	expected := map[string]string{
+		"DB":          reloaded["DB"],
+		"TOKEN":       "rotated-token",
+		"DB_PASSWORD": "rotated-pw",
+		"TOKEN_B64":   "cm90YXRlZC10b2tlbg==",
+	}
+	if !maps.Equal(masked, expected) {
+		t.Errorf("reloadedMaskedSecrets() = %v, want %v", masked, expected)
+	}
+}
+
+// TestMainHelper runs main with the arguments in SECRETINIT_TEST_ARGS (one per line).
+// It is started as a separate process by the tests that run the whole command line.
+func TestMainHelper(t *testing.T) {
+	args := os.Getenv("SECRETINIT_TEST_ARGS")
+	if args == "" {
+		t.Skip("helper process for tests that run main")
+	}
+	os.Args = append([]string{"secretinit"}, strings.Split(args, "\n")...)
+	main()
+	os.Exit(0)
+}
+
+func TestMain_JSONErrorsBeforeFlag(t *testing.T) {
+	// The bad --timeout comes before --json-errors and is still reported as JSON
+	args := []string{"--timeout", "soon", "--json-errors", "echo", "hello"}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainHelper$")
+	cmd.Env = append(os.Environ(), "SECRETINIT_TEST_ARGS="+strings.Join(args, "\n"))
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("secretinit %v: expected exit code 1, got %v", args, err)
+	}
+
+	var got jsonError
+	if err := json.Unmarshal([]byte(strings.TrimSpace(stderr.String())), &got); err != nil {
+		t.Fatalf("stderr %q is not a JSON error: %v", stderr.String(), err)
+	}
+	expected := jsonError{Error: "invalid --timeout 'soon': expected a duration like 30s or 2m", Code: "error"}
+	if got != expected {
+		t.Errorf("got %+v, want %+v", got, expected)
+	}
+}
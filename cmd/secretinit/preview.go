@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// envChangeKind classifies how resolution changed one environment variable
+type envChangeKind string
+
+const (
+	envAdded     envChangeKind = "+"
+	envChanged   envChangeKind = "~"
+	envRemoved   envChangeKind = "-"
+	envUnchanged envChangeKind = "="
+)
+
+// envChange is one variable of a --preview diff, with hashed values
+type envChange struct {
+	Name    string
+	Kind    envChangeKind
+	OldHash string // Empty for added variables
+	NewHash string // Empty for removed variables
+}
+
+// diffEnv compares the environment before and after resolution, sorted by variable name.
+// Values are only kept as hashes.
+func diffEnv(before, after []string, hash func(string) string) []envChange {
+	beforeMap := environMap(before)
+	afterMap := environMap(after)
+
+	names := make([]string, 0, len(beforeMap)+len(afterMap))
+	for name := range beforeMap {
+		names = append(names, name)
+	}
+	for name := range afterMap {
+		if _, exists := beforeMap[name]; !exists {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := make([]envChange, 0, len(names))
+	for _, name := range names {
+		oldValue, hadOld := beforeMap[name]
+		newValue, hasNew := afterMap[name]
+		change := envChange{Name: name}
+		switch {
+		case !hadOld:
+			change.Kind, change.NewHash = envAdded, hash(newValue)
+		case !hasNew:
+			change.Kind, change.OldHash = envRemoved, hash(oldValue)
+		case oldValue != newValue:
+			change.Kind, change.OldHash, change.NewHash = envChanged, hash(oldValue), hash(newValue)
+		default:
+			change.Kind, change.OldHash, change.NewHash = envUnchanged, hash(oldValue), hash(newValue)
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// printPreview writes the --preview report: every added, changed or removed variable
+// with value hashes, followed by the number of unchanged variables
+func printPreview(w io.Writer, changes []envChange) {
+	unchanged := 0
+	for _, change := range changes {
+		switch change.Kind {
+		case envAdded:
+			fmt.Fprintf(w, "+ %s (new %s)\n", change.Name, change.NewHash)
+		case envChanged:
+			fmt.Fprintf(w, "~ %s %s -> %s\n", change.Name, change.OldHash, change.NewHash)
+		case envRemoved:
+			fmt.Fprintf(w, "- %s (was %s)\n", change.Name, change.OldHash)
+		default:
+			unchanged++
+		}
+	}
+	fmt.Fprintf(w, "%d variables unchanged\n", unchanged)
+}
+
+// environMap turns KEY=VALUE entries into a map, later entries winning
+func environMap(environ []string) map[string]string {
+	values := make(map[string]string, len(environ))
+	for _, entry := range environ {
+		if name, value, found := strings.Cut(entry, "="); found {
+			values[name] = value
+		}
+	}
+	return values
+}
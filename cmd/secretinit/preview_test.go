@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDiffEnv(t *testing.T) {
+	before := []string{
+		"PATH=/usr/bin",
+		"DB_PASS=secretinit:aws:sm:myapp/db:::password",
+		"API=secretinit:git:https://api.example.com",
+	}
+	after := []string{
+		"PATH=/usr/bin",
+		"DB_PASS=s3cret",
+		"API_USER=bob",
+		"API_PASS=hunter2",
+	}
+	hash := func(value string) string { return "h(" + value + ")" }
+
+	changes := diffEnv(before, after, hash)
+
+	expected := map[string]envChange{
+		"API":      {Name: "API", Kind: envRemoved, OldHash: "h(secretinit:git:https://api.example.com)"},
+		"API_PASS": {Name: "API_PASS", Kind: envAdded, NewHash: "h(hunter2)"},
+		"API_USER": {Name: "API_USER", Kind: envAdded, NewHash: "h(bob)"},
+		"DB_PASS":  {Name: "DB_PASS", Kind: envChanged, OldHash: "h(secretinit:aws:sm:myapp/db:::password)", NewHash: "h(s3cret)"},
+		"PATH":     {Name: "PATH", Kind: envUnchanged, OldHash: "h(/usr/bin)", NewHash: "h(/usr/bin)"},
+	}
+	if len(changes) != len(expected) {
+		t.Fatalf("diffEnv() returned %d changes, want %d: %v", len(changes), len(expected), changes)
+	}
+	for i, change := range changes {
+		if i > 0 && changes[i-1].Name > change.Name {
+			t.Errorf("changes not sorted: %s before %s", changes[i-1].Name, change.Name)
+		}
+		if change != expected[change.Name] {
+			t.Errorf("%s: got %+v, want %+v", change.Name, change, expected[change.Name])
+		}
+	}
+}
+
+func TestPrintPreview_HidesValues(t *testing.T) {
+	before := []string{"PATH=/usr/bin", "DB_PASS=secretinit:aws:sm:myapp/db"}
+	after := []string{"PATH=/usr/bin", "DB_PASS=s3cret-value", "DATABASE_PASSWORD=s3cret-value"}
+
+	var buf bytes.Buffer
+	printPreview(&buf, diffEnv(before, after, func(string) string { return "abcd1234" }))
+
+	expected := `+ DATABASE_PASSWORD (new abcd1234)
+~ DB_PASS abcd1234 -> abcd1234
+1 variables unchanged
+`
+	if buf.String() != expected {
+		t.Errorf("printPreview() =\n%s\nwant\n%s", buf.String(), expected)
+	}
+	if strings.Contains(buf.String(), "s3cret-value") {
+		t.Error("preview exposes a secret value")
+	}
+}
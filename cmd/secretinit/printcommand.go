@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	executil "github.com/liifi/secretinit/pkg/exec"
+)
+
+// safeShellWord matches arguments that can be printed without quoting
+var safeShellWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// printCommand writes the command and environment that would be executed for --print-command.
+// Variables holding a resolved secret (directly, through a mapping or embedded in a post-env value) are redacted.
+func printCommand(w io.Writer, args []string, environ []string, secrets map[string]string) {
+	secretValues := make(map[string]bool)
+	for _, value := range secrets {
+		if value != "" {
+			secretValues[value] = true
+		}
+	}
+
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuoteArg(arg)
+	}
+	fmt.Fprintf(w, "# command\n%s\n", strings.Join(quoted, " "))
+
+	sortedEnv := append([]string(nil), environ...)
+	sort.Strings(sortedEnv)
+
+	fmt.Fprintf(w, "# environment\n")
+	for _, envVar := range sortedEnv {
+		key, value, _ := strings.Cut(envVar, "=")
+		if _, isSecret := secrets[key]; isSecret || containsSecret(value, secretValues) {
+			value = executil.ScrubMask
+		}
+		fmt.Fprintf(w, "%s=%s\n", key, value)
+	}
+}
+
+// containsSecret reports whether value contains any of the secret values
+func containsSecret(value string, secretValues map[string]bool) bool {
+	for secret := range secretValues {
+		if strings.Contains(value, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+// shellQuoteArg quotes an argument for POSIX shells when needed
+func shellQuoteArg(arg string) string {
+	if safeShellWord.MatchString(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
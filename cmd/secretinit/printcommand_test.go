@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrintCommand(t *testing.T) {
+	args := []string{"myapp", "--name", "hello world", "it's"}
+	environ := []string{
+		"PATH=/usr/bin",
+		"DB_PASS=s3cret-value",
+		"DATABASE_PASSWORD=s3cret-value",
+		"LOG_LEVEL=debug",
+		"API_USER=bob",
+		"DATABASE_URL=postgres://app:s3cret-value@db/app",
+	}
+	secrets := map[string]string{
+		"DB_PASS":  "s3cret-value",
+		"API_USER": "bob",
+	}
+
+	var buf bytes.Buffer
+	printCommand(&buf, args, environ, secrets)
+
+	expected := `# command
+myapp --name 'hello world' 'it'\''s'
+# environment
+API_USER=****
+DATABASE_PASSWORD=****
+DATABASE_URL=****
+DB_PASS=****
+LOG_LEVEL=debug
+PATH=/usr/bin
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestPrintCommand_EmptySecretNotMatchedByValue(t *testing.T) {
+	var buf bytes.Buffer
+	printCommand(&buf, []string{"app"}, []string{"EMPTY=", "TOKEN="}, map[string]string{"TOKEN": ""})
+
+	expected := "# command\napp\n# environment\nEMPTY=\nTOKEN=****\n"
+	if buf.String() != expected {
+		t.Errorf("Unexpected output:\n%s\nwant:\n%s", buf.String(), expected)
+	}
+}
+
+func TestMain_PrintCommandMasksMappedSecrets(t *testing.T) {
+	credsFile := filepath.Join(t.TempDir(), "creds.json")
+	if err := os.WriteFile(credsFile, []byte(`{"username":"app","password":"hunter2pw"}`), 0600); err != nil {
+		t.Fatalf("failed to write secret file: %v", err)
+	}
+
+	args := []string{"--print-command", "-m", "DB_PASS=DB:::password,DB_B64=DB|base64", "app"}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainHelper$")
+	cmd.Env = append(os.Environ(), "SECRETINIT_TEST_ARGS="+strings.Join(args, "\n"), "DB=secretinit:file:"+credsFile)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("secretinit %v failed: %v", args, err)
+	}
+
+	if strings.Contains(string(out), "hunter2pw") {
+		t.Errorf("--print-command printed the mapped secret key:\n%s", out)
+	}
+	for _, name := range []string{"DB_PASS", "DB_B64"} {
+		if !strings.Contains(string(out), "\n"+name+"=****\n") {
+			t.Errorf("--print-command output lacks a masked %s:\n%s", name, out)
+		}
+	}
+}
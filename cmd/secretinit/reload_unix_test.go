@@ -0,0 +1,67 @@
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestMain_ReloadScrubsMappedSecrets(t *testing.T) {
+	dir := t.TempDir()
+	credsFile := filepath.Join(dir, "creds.json")
+	ready := filepath.Join(dir, "ready")
+	if err := os.WriteFile(credsFile, []byte(`{"password":"startup-pw"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// On the reload signal the main command prints the rotated password it reads from the file
+	main := "trap 'cut -d\\\" -f4 " + credsFile + "; exit 0' HUP; touch " + ready + "; while :; do sleep 0.05; done"
+	args := []string{"--reload-signal", "SIGHUP", "--scrub-main-output", "-m", "DB_PASSWORD=DB:::password", "sh", "-c", main}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainHelper$")
+	cmd.Env = append(os.Environ(), "SECRETINIT_TEST_ARGS="+strings.Join(args, "\n"), "DB=secretinit:file:"+credsFile)
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			t.Fatal("main command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := os.WriteFile(credsFile, []byte(`{"password":"rotated-pw"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("secretinit exited with %v, want 0", err)
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("main command was not signaled after the reload")
+	}
+
+	if got := strings.TrimSpace(stdout.String()); got != "****" {
+		t.Errorf("main command printed %q after the reload, want the rotated password masked", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/output"
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+// secretFiles are the files resolved secrets are written to: --write-env, --tfvars, --json-output-file,
+// --mask-file, --pgpass, --my-cnf, --resolve-map, --template and --systemd-creds. A path of "-" writes
+// to stdout instead.
+type secretFiles struct {
+	envPath         string
+	tfvarsPath      string
+	jsonOutputPath  string
+	maskFilePath    string
+	pgpassPath      string
+	myCnfPath       string
+	resolveMapPath  string
+	templateSpecs   []string
+	systemdCredsDir string
+	suffixes        processor.Suffixes
+	owner           *output.FileOwner // Owner given to every file (--creds-owner), nil keeps ours
+}
+
+// secretSink is one configured output. Every sink is written from the same resolved map,
+// so combining outputs never resolves a secret twice or lets the outputs disagree.
+type secretSink struct {
+	name   string // Used in errors: "failed to write <name>"
+	path   string // "-" writes to stdout
+	stream func(w io.Writer, secrets map[string]string) error
+	file   func(path string, secrets map[string]string) error
+}
+
+// any reports whether a file other than --systemd-creds is written, which makes the command optional
+func (f *secretFiles) any() bool {
+	return f.envPath != "" || f.tfvarsPath != "" || f.jsonOutputPath != "" || f.maskFilePath != "" ||
+		f.pgpassPath != "" || f.myCnfPath != "" || f.resolveMapPath != "" || len(f.templateSpecs) > 0
+}
+
+// sinks returns the configured outputs in the order they are written
+func (f *secretFiles) sinks() []secretSink {
+	var sinks []secretSink
+	add := func(name, path string, stream func(io.Writer, map[string]string) error, file func(string, map[string]string) error) {
+		if path != "" {
+			sinks = append(sinks, secretSink{name: name, path: path, stream: stream, file: file})
+		}
+	}
+
+	add("env file", f.envPath, output.WriteDotenv, func(path string, secrets map[string]string) error {
+		return output.WriteDotenvFile(path, secrets, f.owner)
+	})
+	add("tfvars file", f.tfvarsPath, output.WriteTfvars, func(path string, secrets map[string]string) error {
+		return output.WriteTfvarsFile(path, secrets, f.owner)
+	})
+	add("JSON output file", f.jsonOutputPath, output.WriteJSONObject, func(path string, secrets map[string]string) error {
+		return output.WriteJSONObjectFile(path, secrets, f.owner)
+	})
+	add("mask file", f.maskFilePath, output.WriteMasks, func(path string, secrets map[string]string) error {
+		return output.WriteMaskFile(path, secrets, f.owner)
+	})
+
+	// Database logins for psql and mysql
+	add("pgpass file", f.pgpassPath, func(w io.Writer, secrets map[string]string) error {
+		return output.WritePgpass(w, f.dbCredentials(secrets))
+	}, func(path string, secrets map[string]string) error {
+		return output.WritePgpassFile(path, f.dbCredentials(secrets), f.owner)
+	})
+	add("my.cnf file", f.myCnfPath, func(w io.Writer, secrets map[string]string) error {
+		return output.WriteMyCnf(w, f.dbCredentials(secrets))
+	}, func(path string, secrets map[string]string) error {
+		return output.WriteMyCnfFile(path, f.dbCredentials(secrets), f.owner)
+	})
+
+	// A hash of each resolved value, to compare deployments without exposing secrets
+	add("resolve map", f.resolveMapPath, func(w io.Writer, secrets map[string]string) error {
+		return output.WriteResolveMap(w, secrets, backend.ShortHash)
+	}, func(path string, secrets map[string]string) error {
+		return output.WriteResolveMapFile(path, secrets, backend.ShortHash, f.owner)
+	})
+
+	// Config files rendered from templates; WriteTemplateFile handles "-" itself
+	for _, spec := range f.templateSpecs {
+		src, dest := output.ParseTemplateSpec(spec)
+		render := func(path string, secrets map[string]string) error {
+			return output.WriteTemplateFile(src, path, secrets, f.owner)
+		}
+		add("template "+src, dest, func(_ io.Writer, secrets map[string]string) error { return render("-", secrets) }, render)
+	}
+
+	add("systemd credentials", f.systemdCredsDir, nil, func(dir string, secrets map[string]string) error {
+		return output.WriteSystemdCredentials(dir, secrets, f.owner)
+	})
+	return sinks
+}
+
+// dbCredentials extracts the database logins written by --pgpass and --my-cnf
+func (f *secretFiles) dbCredentials(secrets map[string]string) []output.DBCredential {
+	return output.DBCredentials(secrets, output.DBSuffixes{URL: f.suffixes.URL, User: f.suffixes.User, Pass: f.suffixes.Pass})
+}
+
+// write writes secrets to every configured sink. With skipStdout, "-" targets are left out,
+// so a reload rewrites the files without printing to the main command's output.
+func (f *secretFiles) write(secrets map[string]string, skipStdout bool) error {
+	if (f.pgpassPath != "" || f.myCnfPath != "") && len(f.dbCredentials(secrets)) == 0 {
+		return fmt.Errorf("no database credentials resolved (expected NAME%s and NAME%s, or JSON with username and password)", f.suffixes.User, f.suffixes.Pass)
+	}
+
+	for _, sink := range f.sinks() {
+		var err error
+		if sink.path == "-" && sink.stream != nil {
+			if skipStdout {
+				continue
+			}
+			err = sink.stream(os.Stdout, secrets)
+		} else {
+			err = sink.file(sink.path, secrets)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to write %s: %w", sink.name, err)
+		}
+		if sink.path != "-" {
+			debugLog("Wrote %d secrets to %s (%s)", len(secrets), sink.path, sink.name)
+		}
+	}
+	return nil
+}
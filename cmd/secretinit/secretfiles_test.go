@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/output"
+	"github.com/liifi/secretinit/pkg/processor"
+)
+
+// countingBackend returns a different value on every call, so an output written from a second
+// resolution would disagree with the others
+type countingBackend struct {
+	calls int
+}
+
+func (b *countingBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	b.calls++
+	return fmt.Sprintf("%s-%d", resource, b.calls), nil
+}
+
+func TestSecretFiles_AllSinksFromOneResolution(t *testing.T) {
+	backend.ClearGlobalCache()
+	t.Cleanup(backend.ClearGlobalCache)
+	mock := &countingBackend{}
+	proc := processor.NewSecretProcessor()
+	proc.RegisterBackend("aws", mock)
+
+	secrets, err := proc.ProcessSecrets(map[string]string{
+		"API_KEY": "aws:sm:api",
+		"DB_PASS": "aws:sm:db",
+	})
+	if err != nil {
+		t.Fatalf("ProcessSecrets() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "app.conf.tmpl")
+	if err := os.WriteFile(tmpl, []byte("key={{ .API_KEY }}\npass={{ .DB_PASS }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	files := &secretFiles{
+		envPath:        filepath.Join(dir, "out.env"),
+		jsonOutputPath: filepath.Join(dir, "out.json"),
+		maskFilePath:   filepath.Join(dir, "masks.txt"),
+		resolveMapPath: filepath.Join(dir, "map.json"),
+		templateSpecs:  []string{tmpl + ":" + filepath.Join(dir, "app.conf")},
+	}
+	if !files.any() {
+		t.Fatal("any() = false with outputs configured")
+	}
+	if err := files.write(secrets, false); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+
+	if mock.calls != 2 {
+		t.Errorf("expected one backend call per secret, got %d", mock.calls)
+	}
+
+	read := func(name string) string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	var jsonOut map[string]string
+	if err := json.Unmarshal([]byte(read("out.json")), &jsonOut); err != nil {
+		t.Fatalf("out.json is not a JSON object: %v", err)
+	}
+	var resolveMap map[string]string
+	if err := json.Unmarshal([]byte(read("map.json")), &resolveMap); err != nil {
+		t.Fatalf("map.json is not a JSON object: %v", err)
+	}
+	env := read("out.env")
+	masks := strings.Split(strings.TrimSpace(read("masks.txt")), "\n")
+	conf := read("app.conf")
+
+	for name, value := range secrets {
+		if jsonOut[name] != value {
+			t.Errorf("out.json %s = %q, want %q", name, jsonOut[name], value)
+		}
+		if resolveMap[name] != backend.ShortHash(value) {
+			t.Errorf("map.json %s = %q, want hash of %q", name, resolveMap[name], value)
+		}
+		if line := output.FormatDotenvLine(name, value); !strings.Contains(env, line+"\n") {
+			t.Errorf("out.env is missing %q:\n%s", line, env)
+		}
+		if !strings.Contains(conf, "="+value+"\n") {
+			t.Errorf("app.conf is missing %q:\n%s", value, conf)
+		}
+	}
+	if len(masks) != len(secrets) {
+		t.Errorf("expected %d masks, got %q", len(secrets), masks)
+	}
+	for _, mask := range masks {
+		if mask != secrets["API_KEY"] && mask != secrets["DB_PASS"] {
+			t.Errorf("unexpected mask %q", mask)
+		}
+	}
+}
+
+func TestSecretFiles_SkipStdout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.json")
+	files := &secretFiles{envPath: "-", maskFilePath: "-", jsonOutputPath: path}
+	if err := files.write(map[string]string{"TOKEN": "abc"}, true); err != nil {
+		t.Fatalf("write() error = %v", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || !strings.Contains(string(data), `"abc"`) {
+		t.Errorf("out.json = %q, %v", data, err)
+	}
+}
+
+func TestSecretFiles_NoDBCredentials(t *testing.T) {
+	files := &secretFiles{pgpassPath: filepath.Join(t.TempDir(), ".pgpass"), suffixes: processor.DefaultSuffixes}
+	if err := files.write(map[string]string{"TOKEN": "abc"}, false); err == nil {
+		t.Error("expected an error without database credentials")
+	}
+}
@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// autoServices is the secret store service each platform's backend uses for auto: addresses
+var autoServices = map[string]string{
+	"aws":   "sm",
+	"gcp":   "sm",
+	"azure": "kv",
+}
+
+// autoProbeTimeout bounds each metadata probe, so detection outside a cloud stays fast
+const autoProbeTimeout = 500 * time.Millisecond
+
+// AutoBackend implements the Backend interface by delegating to the secret store of the cloud
+// it runs on: AWS Secrets Manager on EC2, GCP Secret Manager on GCE and Azure Key Vault on Azure.
+// The same "auto:NAME/SECRET" address then names an AWS secret, a GCP project and secret,
+// or an Azure vault and secret. The platform is detected once, on the first secret.
+type AutoBackend struct {
+	platforms map[string]func() (Backend, error) // Backends in this build, keyed by platform
+	detect    func(ctx context.Context) (string, error)
+
+	once     sync.Once
+	platform string
+	delegate Backend
+	err      error
+}
+
+// NewAutoBackend creates an AutoBackend choosing between the given platform backends
+// ("aws", "gcp" and "azure"), which are only constructed once their platform is detected.
+func NewAutoBackend(platforms map[string]func() (Backend, error)) (*AutoBackend, error) {
+	return &AutoBackend{platforms: platforms, detect: DetectPlatform}, nil
+}
+
+// RetrieveSecret retrieves a secret from the detected platform's secret store.
+// The service parameter is empty for auto; the resource and keyPath are passed on unchanged.
+func (b *AutoBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context, which also bounds platform detection
+func (b *AutoBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	delegate, platform, err := b.resolve(ctx)
+	if err != nil {
+		return "", err
+	}
+	debugLog("Auto backend: using %s:%s for %s", platform, autoServices[platform], resource)
+	if contextBackend, ok := delegate.(ContextBackend); ok {
+		return contextBackend.RetrieveSecretCtx(ctx, autoServices[platform], resource, keyPath)
+	}
+	return delegate.RetrieveSecret(autoServices[platform], resource, keyPath)
+}
+
+// resolve detects the platform and constructs its backend, once
+func (b *AutoBackend) resolve(ctx context.Context) (Backend, string, error) {
+	b.once.Do(func() {
+		b.platform, b.err = b.detect(ctx)
+		if b.err != nil {
+			b.err = fmt.Errorf("auto backend: %w", b.err)
+			return
+		}
+		factory, ok := b.platforms[b.platform]
+		if !ok {
+			b.err = fmt.Errorf("auto backend: detected %s, but its backend is not available in this build", b.platform)
+			return
+		}
+		b.delegate, b.err = factory()
+		if b.err != nil {
+			b.err = fmt.Errorf("auto backend: failed to initialize %s backend: %w", b.platform, b.err)
+		}
+	})
+	return b.delegate, b.platform, b.err
+}
+
+// DetectPlatform returns the cloud secretinit runs on: "aws", "gcp" or "azure".
+// SECRETINIT_AUTO_PLATFORM overrides detection. Otherwise serverless environment variables are
+// checked first, then the instance metadata endpoints are probed concurrently.
+func DetectPlatform(ctx context.Context) (string, error) {
+	if platform := os.Getenv("SECRETINIT_AUTO_PLATFORM"); platform != "" {
+		if _, ok := autoServices[platform]; !ok {
+			return "", fmt.Errorf("invalid SECRETINIT_AUTO_PLATFORM '%s': expected aws, gcp or azure", platform)
+		}
+		return platform, nil
+	}
+	if platform := platformFromEnv(os.Getenv); platform != "" {
+		debugLog("Auto backend: detected %s from the environment", platform)
+		return platform, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, autoProbeTimeout)
+	defer cancel()
+	platform, err := probePlatforms(ctx, http.DefaultClient, defaultMetadataProbes)
+	if err != nil {
+		return "", err
+	}
+	debugLog("Auto backend: detected %s from its metadata endpoint", platform)
+	return platform, nil
+}
+
+// platformFromEnv recognizes serverless and container platforms by the variables they set
+func platformFromEnv(getenv func(string) string) string {
+	switch {
+	case getenv("AWS_LAMBDA_FUNCTION_NAME") != "" || getenv("ECS_CONTAINER_METADATA_URI_V4") != "":
+		return "aws"
+	case getenv("K_SERVICE") != "" || getenv("CLOUD_RUN_JOB") != "":
+		return "gcp"
+	case getenv("WEBSITE_INSTANCE_ID") != "" || getenv("CONTAINER_APP_NAME") != "":
+		return "azure"
+	}
+	return ""
+}
+
+// metadataProbe is a request that only succeeds against one platform's metadata endpoint
+type metadataProbe struct {
+	method  string
+	url     string
+	headers map[string]string
+	// ok reports whether the response came from the platform's metadata server
+	ok func(*http.Response) bool
+}
+
+// defaultMetadataProbes are the instance metadata endpoints of each platform
+var defaultMetadataProbes = map[string]metadataProbe{
+	"aws": {
+		// IMDSv2 token request, which works whether or not IMDSv1 is disabled
+		method:  http.MethodPut,
+		url:     "http://169.254.169.254/latest/api/token",
+		headers: map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"},
+		ok:      func(resp *http.Response) bool { return resp.StatusCode == http.StatusOK },
+	},
+	"gcp": {
+		method:  http.MethodGet,
+		url:     "http://metadata.google.internal/computeMetadata/v1/",
+		headers: map[string]string{"Metadata-Flavor": "Google"},
+		ok:      func(resp *http.Response) bool { return resp.Header.Get("Metadata-Flavor") == "Google" },
+	},
+	"azure": {
+		method:  http.MethodGet,
+		url:     "http://169.254.169.254/metadata/instance?api-version=2021-02-01",
+		headers: map[string]string{"Metadata": "true"},
+		ok:      func(resp *http.Response) bool { return resp.StatusCode == http.StatusOK },
+	},
+}
+
+// probePlatforms runs the probes concurrently and returns the platform whose probe succeeded
+func probePlatforms(ctx context.Context, client *http.Client, probes map[string]metadataProbe) (string, error) {
+	found := make(chan string, len(probes))
+	var wg sync.WaitGroup
+	for platform, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if runProbe(ctx, client, probe) {
+				found <- platform
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	if platform, ok := <-found; ok {
+		return platform, nil
+	}
+	names := make([]string, 0, len(probes))
+	for platform := range probes {
+		names = append(names, platform)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("could not detect the cloud platform (no %s metadata endpoint answered); set SECRETINIT_AUTO_PLATFORM", strings.Join(names, ", "))
+}
+
+// runProbe sends probe and reports whether the expected metadata server answered
+func runProbe(ctx context.Context, client *http.Client, probe metadataProbe) bool {
+	req, err := http.NewRequestWithContext(ctx, probe.method, probe.url, nil)
+	if err != nil {
+		return false
+	}
+	for name, value := range probe.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return probe.ok(resp)
+}
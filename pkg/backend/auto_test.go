@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingBackend returns "platform:service:resource:keyPath" for every secret
+type recordingBackend struct {
+	platform string
+}
+
+func (b *recordingBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return strings.Join([]string{b.platform, service, resource, keyPath}, ":"), nil
+}
+
+func testAutoPlatforms(constructed *[]string) map[string]func() (Backend, error) {
+	platforms := make(map[string]func() (Backend, error))
+	for _, platform := range []string{"aws", "gcp", "azure"} {
+		platforms[platform] = func() (Backend, error) {
+			*constructed = append(*constructed, platform)
+			return &recordingBackend{platform: platform}, nil
+		}
+	}
+	return platforms
+}
+
+func TestAutoBackend_DelegatesToDetectedPlatform(t *testing.T) {
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{"aws", "aws:sm:myapp/db:password"},
+		{"gcp", "gcp:sm:myapp/db:password"},
+		{"azure", "azure:kv:myapp/db:password"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.platform, func(t *testing.T) {
+			var constructed []string
+			detections := 0
+			b, _ := NewAutoBackend(testAutoPlatforms(&constructed))
+			b.detect = func(ctx context.Context) (string, error) {
+				detections++
+				return tt.platform, nil
+			}
+
+			for i := 0; i < 2; i++ {
+				got, err := b.RetrieveSecret("", "myapp/db", "password")
+				if err != nil {
+					t.Fatalf("RetrieveSecret() error = %v", err)
+				}
+				if got != tt.want {
+					t.Errorf("RetrieveSecret() = %q, want %q", got, tt.want)
+				}
+			}
+
+			// Detection and construction happen once, and only for the detected platform
+			if detections != 1 {
+				t.Errorf("detected %d times, want once", detections)
+			}
+			if len(constructed) != 1 || constructed[0] != tt.platform {
+				t.Errorf("constructed backends %v, want only %s", constructed, tt.platform)
+			}
+		})
+	}
+}
+
+func TestAutoBackend_Errors(t *testing.T) {
+	var constructed []string
+	platforms := testAutoPlatforms(&constructed)
+	delete(platforms, "azure")
+
+	b, _ := NewAutoBackend(platforms)
+	b.detect = func(ctx context.Context) (string, error) { return "azure", nil }
+	if _, err := b.RetrieveSecret("", "vault/secret", ""); err == nil || !strings.Contains(err.Error(), "detected azure, but its backend is not available in this build") {
+		t.Errorf("expected a missing backend error, got %v", err)
+	}
+
+	b, _ = NewAutoBackend(platforms)
+	b.detect = func(ctx context.Context) (string, error) {
+		return "", errors.New("could not detect the cloud platform")
+	}
+	if _, err := b.RetrieveSecret("", "myapp/db", ""); err == nil || !strings.Contains(err.Error(), "auto backend: could not detect") {
+		t.Errorf("expected a detection error, got %v", err)
+	}
+}
+
+func TestProbePlatforms(t *testing.T) {
+	gcp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Metadata-Flavor", "Google")
+	}))
+	defer gcp.Close()
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	probes := map[string]metadataProbe{
+		"aws":   {method: http.MethodPut, url: notFound.URL, ok: defaultMetadataProbes["aws"].ok},
+		"gcp":   {method: http.MethodGet, url: gcp.URL, headers: defaultMetadataProbes["gcp"].headers, ok: defaultMetadataProbes["gcp"].ok},
+		"azure": {method: http.MethodGet, url: notFound.URL, ok: defaultMetadataProbes["azure"].ok},
+	}
+
+	platform, err := probePlatforms(context.Background(), http.DefaultClient, probes)
+	if err != nil || platform != "gcp" {
+		t.Errorf("probePlatforms() = %q, %v, want gcp", platform, err)
+	}
+
+	delete(probes, "gcp")
+	if _, err := probePlatforms(context.Background(), http.DefaultClient, probes); err == nil || !strings.Contains(err.Error(), "no aws, azure metadata endpoint answered") {
+		t.Errorf("expected a detection error, got %v", err)
+	}
+}
+
+func TestPlatformFromEnv(t *testing.T) {
+	tests := []struct {
+		env  map[string]string
+		want string
+	}{
+		{map[string]string{"AWS_LAMBDA_FUNCTION_NAME": "fn"}, "aws"},
+		{map[string]string{"ECS_CONTAINER_METADATA_URI_V4": "http://169.254.170.2/v4/x"}, "aws"},
+		{map[string]string{"K_SERVICE": "api"}, "gcp"},
+		{map[string]string{"WEBSITE_INSTANCE_ID": "abc"}, "azure"},
+		{map[string]string{"HOME": "/root"}, ""},
+	}
+
+	for _, tt := range tests {
+		getenv := func(name string) string { return tt.env[name] }
+		if got := platformFromEnv(getenv); got != tt.want {
+			t.Errorf("platformFromEnv(%v) = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestDetectPlatform_Override(t *testing.T) {
+	t.Setenv("SECRETINIT_AUTO_PLATFORM", "azure")
+	if platform, err := DetectPlatform(context.Background()); err != nil || platform != "azure" {
+		t.Errorf("DetectPlatform() = %q, %v, want azure", platform, err)
+	}
+
+	t.Setenv("SECRETINIT_AUTO_PLATFORM", "oracle")
+	if _, err := DetectPlatform(context.Background()); err == nil {
+		t.Error("expected an error for an unknown platform")
+	}
+}
@@ -2,40 +2,239 @@ package backend
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	smtypes "github.com/aws/aws-sdk-go-v2/service/secretsmanager/types"
 	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+
+	"github.com/liifi/secretinit/pkg/parser"
 )
 
-// AWSBackend implements the Backend interface for AWS services (Secrets Manager and Parameter Store).
+// iamRoleARNPattern matches a resource that starts with an IAM role ARN (ROLE_ARN@SECRET)
+var iamRoleARNPattern = regexp.MustCompile(`^arn:aws[a-z-]*:iam::\d+:role/`)
+
+// regionPrefixPattern matches a resource with an explicit region prefix (REGION:SECRET, e.g. "eu-west-1:myapp/db")
+var regionPrefixPattern = regexp.MustCompile(`^([a-z]{2}(?:-[a-z]+)+-\d+):(.+)$`)
+
+// AWSBackend implements the Backend interface for AWS services (Secrets Manager, Parameter Store and KMS).
 type AWSBackend struct {
+	cfg           aws.Config
 	secretsClient *secretsmanager.Client
 	ssmClient     *ssm.Client
+	kmsClient     *kms.Client
+
+	rolesMutex   sync.Mutex
+	roleBackends map[string]*AWSBackend // Backends using assumed role credentials, keyed by role ARN
+
+	regionsMutex   sync.Mutex
+	regionBackends map[string]*AWSBackend // Region-scoped backends, keyed by region
+
+	arnsMutex    sync.Mutex
+	resolvedARNs map[string]string // Full Secrets Manager ARNs found by name lookup, keyed by requested resource
 }
 
 // NewAWSBackend creates a new AWSBackend using default AWS SDK configuration.
 // This uses the standard AWS SDK credential and region discovery mechanism.
+// SECRETINIT_AWS_REGION takes precedence over AWS_REGION/AWS_DEFAULT_REGION and the shared config,
+// so secretinit can target a different region without changing other AWS tools.
+// SECRETINIT_AWS_ASSUME_ROLE assumes the given role ARN for all requests (e.g. to read secrets from another account).
 func NewAWSBackend() (*AWSBackend, error) {
-	cfg, err := config.LoadDefaultConfig(context.Background())
+	var opts []func(*config.LoadOptions) error
+	if region := os.Getenv("SECRETINIT_AWS_REGION"); region != "" {
+		debugLog("Using AWS region from SECRETINIT_AWS_REGION: %s", region)
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	secretsClient := secretsmanager.NewFromConfig(cfg)
-	ssmClient := ssm.NewFromConfig(cfg)
+	if roleARN := os.Getenv("SECRETINIT_AWS_ASSUME_ROLE"); roleARN != "" {
+		debugLog("Assuming AWS role from SECRETINIT_AWS_ASSUME_ROLE: %s", roleARN)
+		cfg.Credentials = assumeRoleCredentials(cfg, roleARN)
+	}
+
+	return newAWSBackendFromConfig(cfg), nil
+}
+
+// newAWSBackendFromConfig creates a backend with clients for every supported AWS service
+func newAWSBackendFromConfig(cfg aws.Config) *AWSBackend {
 	return &AWSBackend{
-		secretsClient: secretsClient,
-		ssmClient:     ssmClient,
-	}, nil
+		cfg:           cfg,
+		secretsClient: secretsmanager.NewFromConfig(cfg),
+		ssmClient:     ssm.NewFromConfig(cfg),
+		kmsClient:     kms.NewFromConfig(cfg),
+	}
+}
+
+// WithClientCertificate returns a copy of the backend whose AWS API calls present the given
+// TLS client certificate, e.g. for VPC endpoints or proxies that require mTLS.
+func (b *AWSBackend) WithClientCertificate(cert tls.Certificate) (Backend, error) {
+	cfg := b.cfg.Copy()
+	cfg.HTTPClient = awshttp.NewBuildableClient().WithTransportOptions(func(tr *http.Transport) {
+		if tr.TLSClientConfig == nil {
+			tr.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		tr.TLSClientConfig.Certificates = []tls.Certificate{cert}
+	})
+
+	return newAWSBackendFromConfig(cfg), nil
+}
+
+// assumeRoleCredentials returns credentials for roleARN, assumed with the credentials of cfg.
+// The assumed credentials are cached (and refreshed before they expire) for the lifetime of the process.
+func assumeRoleCredentials(cfg aws.Config, roleARN string) aws.CredentialsProvider {
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "secretinit"
+	})
+	return aws.NewCredentialsCache(provider)
+}
+
+// forRole returns a backend whose clients use credentials of the assumed role, creating it on first use
+func (b *AWSBackend) forRole(roleARN string) *AWSBackend {
+	b.rolesMutex.Lock()
+	defer b.rolesMutex.Unlock()
+
+	if roleBackend, exists := b.roleBackends[roleARN]; exists {
+		return roleBackend
+	}
+
+	debugLog("Assuming AWS role %s", roleARN)
+	cfg := b.cfg.Copy()
+	cfg.Credentials = assumeRoleCredentials(b.cfg, roleARN)
+	roleBackend := newAWSBackendFromConfig(cfg)
+
+	if b.roleBackends == nil {
+		b.roleBackends = make(map[string]*AWSBackend)
+	}
+	b.roleBackends[roleARN] = roleBackend
+	return roleBackend
+}
+
+// forRegion returns a backend whose clients target region, creating it on first use.
+// The backend itself is returned when it already targets region.
+func (b *AWSBackend) forRegion(region string) *AWSBackend {
+	if region == b.cfg.Region {
+		return b
+	}
+
+	b.regionsMutex.Lock()
+	defer b.regionsMutex.Unlock()
+
+	if regionBackend, exists := b.regionBackends[region]; exists {
+		return regionBackend
+	}
+
+	debugLog("Creating AWS clients for region %s", region)
+	cfg := b.cfg.Copy()
+	cfg.Region = region
+	regionBackend := newAWSBackendFromConfig(cfg)
+
+	if b.regionBackends == nil {
+		b.regionBackends = make(map[string]*AWSBackend)
+	}
+	b.regionBackends[region] = regionBackend
+	return regionBackend
+}
+
+// splitRegionResource returns the region a resource must be read from and the resource to request.
+// Secrets Manager, Parameter Store and KMS ARNs carry their region and are returned unchanged;
+// a "REGION:" prefix (e.g. "eu-west-1:myapp/db-creds") is removed. Returns an empty region otherwise.
+func splitRegionResource(resource string) (string, string) {
+	if strings.HasPrefix(resource, "arn:") {
+		// arn:partition:service:region:account:resource
+		parts := strings.SplitN(resource, ":", 6)
+		if len(parts) == 6 && (parts[2] == "secretsmanager" || parts[2] == "ssm" || parts[2] == "kms") {
+			return parts[3], resource
+		}
+		return "", resource
+	}
+	if matches := regionPrefixPattern.FindStringSubmatch(resource); matches != nil {
+		return matches[1], matches[2]
+	}
+	return "", resource
+}
+
+// splitRoleResource splits an optional "ROLE_ARN@" prefix from a resource,
+// e.g. "arn:aws:iam::123456789012:role/reader@myapp/db-creds". Resources without a role ARN are returned unchanged.
+// The first '@' ends the role ARN, so role names containing '@' must use SECRETINIT_AWS_ASSUME_ROLE instead.
+func splitRoleResource(resource string) (string, string, error) {
+	if !iamRoleARNPattern.MatchString(resource) {
+		return "", resource, nil
+	}
+	roleARN, secretResource, found := strings.Cut(resource, "@")
+	if !found || secretResource == "" {
+		return "", "", fmt.Errorf("invalid AWS resource '%s': expected ROLE_ARN@SECRET", resource)
+	}
+	return roleARN, secretResource, nil
 }
 
-// RetrieveSecret retrieves a secret from AWS services (Secrets Manager or Parameter Store).
-// The service parameter specifies which AWS service to use: "sm" for Secrets Manager, "ps" for Parameter Store.
-// The resource can be either a simple name or a full ARN for Secrets Manager, or parameter name/path for Parameter Store.
+// AWSServices lists the services supported by the AWS backend, with their descriptions for error messages
+const AWSServices = "'sm' (Secrets Manager), 'ps' (Parameter Store), 'kms' (KMS decrypt)"
+
+// AWSServiceNames lists the service names in AWSServices, used to suggest a fix for typos
+var AWSServiceNames = []string{"sm", "ps", "kms"}
+
+// IsAWSService reports whether service is supported by the AWS backend
+func IsAWSService(service string) bool {
+	return slices.Contains(AWSServiceNames, service)
+}
+
+// resolveTarget returns the backend that must serve resource (for an assumed role and/or
+// another region) and the resource with its role and region prefixes removed
+func (b *AWSBackend) resolveTarget(resource string) (*AWSBackend, string, error) {
+	roleARN, secretResource, err := splitRoleResource(resource)
+	if err != nil {
+		return nil, "", err
+	}
+	target := b
+	if roleARN != "" {
+		target = b.forRole(roleARN)
+	}
+	region, secretResource := splitRegionResource(secretResource)
+	if region != "" {
+		target = target.forRegion(region)
+	}
+	return target, secretResource, nil
+}
+
+// RetrieveSecret retrieves a secret from AWS services (Secrets Manager, Parameter Store or KMS).
+// The service parameter specifies which AWS service to use: "sm" for Secrets Manager, "ps" for Parameter Store,
+// "kms" to decrypt a ciphertext with KMS.
+// The resource can be either a simple name or a full ARN for Secrets Manager, or parameter name/path for Parameter Store,
+// optionally prefixed with "ROLE_ARN@" to read it with the credentials of an assumed role.
+// Region-bearing ARNs and a "REGION:" prefix route the request to clients for that region.
 // The keyPath is optional and used for JSON key extraction from the secret value.
+// For "kms" the resource is the key ID, ARN or alias and the keyPath is the base64 ciphertext (or @/path to a file).
 func (b *AWSBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context that bounds the AWS API calls
+func (b *AWSBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	if service == "kms" {
+		return b.decryptWithKMS(ctx, resource, keyPath)
+	}
+
 	cache := GetGlobalCache()
 
 	// Create cache key for the raw secret (without keyPath since that's just parsing)
@@ -46,15 +245,19 @@ func (b *AWSBackend) RetrieveSecret(service, resource, keyPath string) (string,
 	if cached, exists := cache.Get(cacheKey); exists {
 		rawSecretValue = cached
 	} else {
-		// Cache miss - retrieve from AWS
-		var err error
+		// Cache miss - retrieve from AWS, using an assumed role or another region if the resource names one
+		target, secretResource, err := b.resolveTarget(resource)
+		if err != nil {
+			return "", err
+		}
+
 		switch service {
 		case "sm":
-			rawSecretValue, err = b.retrieveFromSecretsManager(resource)
+			rawSecretValue, err = target.retrieveFromSecretsManager(ctx, secretResource)
 		case "ps":
-			rawSecretValue, err = b.retrieveFromParameterStore(resource)
+			rawSecretValue, err = target.retrieveFromParameterStore(ctx, secretResource)
 		default:
-			return "", fmt.Errorf("unsupported AWS service '%s'. Supported services: 'sm' (Secrets Manager), 'ps' (Parameter Store)", service)
+			return "", fmt.Errorf("unsupported AWS service '%s'%s. Supported services: %s", service, parser.DidYouMean(service, AWSServiceNames), AWSServices)
 		}
 
 		if err != nil {
@@ -62,7 +265,7 @@ func (b *AWSBackend) RetrieveSecret(service, resource, keyPath string) (string,
 		}
 
 		// Cache the raw secret value
-		cache.Set(cacheKey, rawSecretValue)
+		cache.SetWithTTL(cacheKey, rawSecretValue, BackendTTL("aws"))
 	}
 
 	// Apply keyPath parsing to the raw value
@@ -75,16 +278,51 @@ func (b *AWSBackend) RetrieveSecret(service, resource, keyPath string) (string,
 }
 
 // retrieveFromSecretsManager retrieves a secret from AWS Secrets Manager.
-func (b *AWSBackend) retrieveFromSecretsManager(resource string) (string, error) {
-	ctx := context.Background()
+// The resource is requested as given first. If Secrets Manager doesn't find it (e.g. a partial ARN
+// missing the random suffix), the secret name is looked up with ListSecrets and the request is
+// retried with the full ARN, which is remembered for later requests of the same resource.
+func (b *AWSBackend) retrieveFromSecretsManager(ctx context.Context, resource string) (string, error) {
+	secretID := resource
+	b.arnsMutex.Lock()
+	if arn, exists := b.resolvedARNs[resource]; exists {
+		secretID = arn
+	}
+	b.arnsMutex.Unlock()
 
 	input := &secretsmanager.GetSecretValueInput{
-		SecretId: &resource,
+		SecretId: &secretID,
 	}
 
 	result, err := b.secretsClient.GetSecretValue(ctx, input)
+	var secretNotFound *smtypes.ResourceNotFoundException
+	var lookupErr error
+	if err != nil && secretID == resource && errors.As(err, &secretNotFound) {
+		var arn string
+		arn, lookupErr = b.lookupSecretARN(ctx, resource)
+		if arn != "" {
+			debugLog("Resolved Secrets Manager resource '%s' to %s", resource, arn)
+			b.arnsMutex.Lock()
+			if b.resolvedARNs == nil {
+				b.resolvedARNs = make(map[string]string)
+			}
+			b.resolvedARNs[resource] = arn
+			b.arnsMutex.Unlock()
+
+			input.SecretId = &arn
+			result, err = b.secretsClient.GetSecretValue(ctx, input)
+		}
+	}
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve secret from AWS Secrets Manager for resource '%s': %w", resource, err)
+		// A failed lookup (e.g. ListSecrets denied) is only context, the secret is still not found
+		if lookupErr != nil {
+			err = fmt.Errorf("failed to retrieve secret from AWS Secrets Manager for resource '%s': %w (name lookup: %v)", resource, err, lookupErr)
+		} else {
+			err = fmt.Errorf("failed to retrieve secret from AWS Secrets Manager for resource '%s': %w", resource, err)
+		}
+		if errors.As(err, &secretNotFound) {
+			return "", notFound(err)
+		}
+		return "", err
 	}
 
 	// AWS Secrets Manager can return either SecretString or SecretBinary
@@ -100,9 +338,113 @@ func (b *AWSBackend) retrieveFromSecretsManager(resource string) (string, error)
 	return secretValue, nil
 }
 
+// lookupSecretARN finds the full ARN of the secret a friendly name or partial ARN refers to.
+// Returns an empty ARN if no secret has exactly that name, and an error if several do.
+func (b *AWSBackend) lookupSecretARN(ctx context.Context, resource string) (string, error) {
+	name := resource
+	if strings.HasPrefix(resource, "arn:") {
+		// arn:partition:secretsmanager:region:account:secret:NAME
+		parts := strings.SplitN(resource, ":", 7)
+		if len(parts) != 7 || parts[5] != "secret" {
+			return "", nil
+		}
+		name = parts[6]
+	}
+
+	input := &secretsmanager.ListSecretsInput{
+		Filters: []smtypes.Filter{{Key: smtypes.FilterNameStringTypeName, Values: []string{name}}},
+	}
+
+	var matches []string
+	paginator := secretsmanager.NewListSecretsPaginator(b.secretsClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", err
+		}
+		// The name filter matches prefixes, so only keep exact names
+		for _, entry := range page.SecretList {
+			if aws.ToString(entry.Name) == name && entry.ARN != nil {
+				matches = append(matches, *entry.ARN)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", nil
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("secret name '%s' is ambiguous: %s", name, strings.Join(matches, ", "))
+	}
+}
+
+// decryptWithKMS decrypts a KMS ciphertext with the key named by resource (key ID, ARN or alias).
+// Plaintexts are cached by a hash of the ciphertext, so repeated decrypts of a blob call KMS once.
+func (b *AWSBackend) decryptWithKMS(ctx context.Context, resource, ciphertextRef string) (string, error) {
+	if ciphertextRef == "" {
+		return "", fmt.Errorf("missing ciphertext for KMS key '%s': expected aws:kms:KEY:::BASE64 or aws:kms:KEY:::@/path", resource)
+	}
+	ciphertext, err := readKMSCiphertext(ciphertextRef)
+	if err != nil {
+		return "", err
+	}
+
+	cache := GetGlobalCache()
+	cacheKey := fmt.Sprintf("aws:kms:%s:%s", resource, hashValue(string(ciphertext)))
+	if cached, exists := cache.Get(cacheKey); exists {
+		return cached, nil
+	}
+
+	target, keyID, err := b.resolveTarget(resource)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := target.kmsClient.Decrypt(ctx, &kms.DecryptInput{
+		CiphertextBlob: ciphertext,
+		KeyId:          &keyID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt with AWS KMS key '%s': %w", keyID, err)
+	}
+
+	plaintext := string(result.Plaintext)
+	cache.SetWithTTL(cacheKey, plaintext, BackendTTL("aws"))
+	return plaintext, nil
+}
+
+// readKMSCiphertext decodes a base64 ciphertext, or reads it from a file for "@/path".
+// A file may hold the ciphertext as base64 text or as the raw binary blob.
+func readKMSCiphertext(ref string) ([]byte, error) {
+	if path, isFile := strings.CutPrefix(ref, "@"); isFile {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read KMS ciphertext file: %w", err)
+		}
+		if decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err == nil {
+			return decoded, nil
+		}
+		return data, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(ref)
+	if err != nil {
+		return nil, fmt.Errorf("invalid KMS ciphertext: expected base64: %w", err)
+	}
+	return ciphertext, nil
+}
+
+// parameterPathWildcard ends a Parameter Store resource that selects every parameter below a path
+const parameterPathWildcard = "/*"
+
 // retrieveFromParameterStore retrieves a parameter from AWS Systems Manager Parameter Store.
-func (b *AWSBackend) retrieveFromParameterStore(resource string) (string, error) {
-	ctx := context.Background()
+// A resource ending in "/*" (e.g. "/myapp/config/*") retrieves every parameter below that path instead.
+func (b *AWSBackend) retrieveFromParameterStore(ctx context.Context, resource string) (string, error) {
+	if path, isWildcard := strings.CutSuffix(resource, parameterPathWildcard); isWildcard {
+		return b.retrieveParameterPath(ctx, path)
+	}
 
 	input := &ssm.GetParameterInput{
 		Name:           &resource,
@@ -111,7 +453,12 @@ func (b *AWSBackend) retrieveFromParameterStore(resource string) (string, error)
 
 	result, err := b.ssmClient.GetParameter(ctx, input)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve parameter from AWS Parameter Store for resource '%s': %w", resource, err)
+		err = fmt.Errorf("failed to retrieve parameter from AWS Parameter Store for resource '%s': %w", resource, err)
+		var paramNotFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &paramNotFound) {
+			return "", notFound(err)
+		}
+		return "", err
 	}
 
 	if result.Parameter == nil || result.Parameter.Value == nil {
@@ -121,3 +468,42 @@ func (b *AWSBackend) retrieveFromParameterStore(resource string) (string, error)
 	paramValue := *result.Parameter.Value
 	return paramValue, nil
 }
+
+// retrieveParameterPath retrieves all parameters below path (recursively, decrypting SecureStrings)
+// as a JSON object keyed by their name relative to path, e.g. {"api_key": "...", "db/host": "..."},
+// so a keyPath picks a single one ("aws:ps:/myapp/config/*:::db/host")
+func (b *AWSBackend) retrieveParameterPath(ctx context.Context, path string) (string, error) {
+	if path == "" {
+		path = "/"
+	}
+	input := &ssm.GetParametersByPathInput{
+		Path:           &path,
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true), // Always decrypt SecureString parameters
+	}
+
+	parameters := make(map[string]string)
+	prefix := strings.TrimSuffix(path, "/") + "/"
+	paginator := ssm.NewGetParametersByPathPaginator(b.ssmClient, input)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to retrieve parameters from AWS Parameter Store below path '%s': %w", path, err)
+		}
+		for _, parameter := range page.Parameters {
+			if parameter.Name == nil || parameter.Value == nil {
+				continue
+			}
+			parameters[strings.TrimPrefix(*parameter.Name, prefix)] = *parameter.Value
+		}
+	}
+	if len(parameters) == 0 {
+		return "", notFound(fmt.Errorf("no parameters found in AWS Parameter Store below path '%s'", path))
+	}
+
+	data, err := json.Marshal(parameters)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
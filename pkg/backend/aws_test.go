@@ -1,7 +1,25 @@
 package backend
 
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 )
 
 func TestAWSBackend_extractJSONKey(t *testing.T) {
@@ -103,3 +121,492 @@ func TestNewAWSBackend(t *testing.T) {
 		}
 	}
 }
+
+func TestNewAWSBackend_SecretinitRegionOverride(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("AWS_DEFAULT_REGION", "us-east-1")
+	t.Setenv("SECRETINIT_AWS_REGION", "eu-west-1")
+
+	backend, err := NewAWSBackend()
+	if err != nil {
+		t.Skipf("NewAWSBackend() failed (expected if AWS config is broken): %v", err)
+	}
+
+	if region := backend.secretsClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("Expected SECRETINIT_AWS_REGION to win for Secrets Manager, got region %s", region)
+	}
+	if region := backend.ssmClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("Expected SECRETINIT_AWS_REGION to win for Parameter Store, got region %s", region)
+	}
+}
+
+func TestAWSBackend_WithClientCertificate(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	b, err := NewAWSBackend()
+	if err != nil {
+		t.Skipf("NewAWSBackend() failed (expected if AWS config is broken): %v", err)
+	}
+
+	cert := tls.Certificate{Certificate: [][]byte{[]byte("test-cert")}}
+	derived, err := b.WithClientCertificate(cert)
+	if err != nil {
+		t.Fatalf("WithClientCertificate() failed: %v", err)
+	}
+
+	awsDerived, ok := derived.(*AWSBackend)
+	if !ok {
+		t.Fatalf("Expected *AWSBackend, got %T", derived)
+	}
+	if awsDerived == b {
+		t.Fatal("Expected a new backend, got the original")
+	}
+
+	for name, httpClient := range map[string]interface{}{
+		"Secrets Manager": awsDerived.secretsClient.Options().HTTPClient,
+		"Parameter Store": awsDerived.ssmClient.Options().HTTPClient,
+	} {
+		client, ok := httpClient.(*awshttp.BuildableClient)
+		if !ok {
+			t.Fatalf("%s: expected *BuildableClient, got %T", name, httpClient)
+		}
+		certs := client.GetTransport().TLSClientConfig.Certificates
+		if len(certs) != 1 || string(certs[0].Certificate[0]) != "test-cert" {
+			t.Errorf("%s: client certificate not applied to transport", name)
+		}
+	}
+
+	// The original backend must not be affected
+	if client, ok := b.secretsClient.Options().HTTPClient.(*awshttp.BuildableClient); ok {
+		if tr := client.GetTransport(); tr.TLSClientConfig != nil && len(tr.TLSClientConfig.Certificates) != 0 {
+			t.Error("Original backend transport was modified")
+		}
+	}
+}
+
+func TestSplitRoleResource(t *testing.T) {
+	tests := []struct {
+		name         string
+		resource     string
+		expectedRole string
+		expectedRest string
+		wantErr      bool
+	}{
+		{
+			name:         "simple name",
+			resource:     "myapp/db-creds",
+			expectedRest: "myapp/db-creds",
+		},
+		{
+			name:         "secret ARN",
+			resource:     "arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db-creds-AbCdEf",
+			expectedRest: "arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db-creds-AbCdEf",
+		},
+		{
+			name:         "secret name containing @",
+			resource:     "team@example/db",
+			expectedRest: "team@example/db",
+		},
+		{
+			name:         "role with simple name",
+			resource:     "arn:aws:iam::123:role/reader@myapp/db-creds",
+			expectedRole: "arn:aws:iam::123:role/reader",
+			expectedRest: "myapp/db-creds",
+		},
+		{
+			name:         "role with secret ARN",
+			resource:     "arn:aws:iam::123456789012:role/path/reader@arn:aws:secretsmanager:us-east-1:210987654321:secret:db",
+			expectedRole: "arn:aws:iam::123456789012:role/path/reader",
+			expectedRest: "arn:aws:secretsmanager:us-east-1:210987654321:secret:db",
+		},
+		{
+			name:         "role with parameter path in another partition",
+			resource:     "arn:aws-us-gov:iam::123:role/reader@/myapp/config",
+			expectedRole: "arn:aws-us-gov:iam::123:role/reader",
+			expectedRest: "/myapp/config",
+		},
+		{
+			name:     "role without secret",
+			resource: "arn:aws:iam::123:role/reader",
+			wantErr:  true,
+		},
+		{
+			name:     "role with empty secret",
+			resource: "arn:aws:iam::123:role/reader@",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			role, rest, err := splitRoleResource(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if role != tt.expectedRole || rest != tt.expectedRest {
+				t.Errorf("got (%q, %q), want (%q, %q)", role, rest, tt.expectedRole, tt.expectedRest)
+			}
+		})
+	}
+}
+
+func TestAWSBackend_ForRole(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	b, err := NewAWSBackend()
+	if err != nil {
+		t.Skipf("NewAWSBackend() failed (expected if AWS config is broken): %v", err)
+	}
+
+	reader := b.forRole("arn:aws:iam::123:role/reader")
+	if reader == b {
+		t.Fatal("Expected a separate backend for the assumed role")
+	}
+	if !aws.IsCredentialsProvider(reader.cfg.Credentials, &stscreds.AssumeRoleProvider{}) {
+		t.Errorf("Expected assumed role credentials, got %T", reader.cfg.Credentials)
+	}
+	if aws.IsCredentialsProvider(b.cfg.Credentials, &stscreds.AssumeRoleProvider{}) {
+		t.Error("Expected the base backend to keep its own credentials")
+	}
+	if b.forRole("arn:aws:iam::123:role/reader") != reader {
+		t.Error("Expected the assumed role backend to be reused for the same role")
+	}
+	if b.forRole("arn:aws:iam::123:role/writer") == reader {
+		t.Error("Expected a different backend for a different role")
+	}
+}
+
+func TestNewAWSBackend_AssumeRoleEnv(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+	t.Setenv("SECRETINIT_AWS_ASSUME_ROLE", "arn:aws:iam::123:role/reader")
+
+	b, err := NewAWSBackend()
+	if err != nil {
+		t.Skipf("NewAWSBackend() failed (expected if AWS config is broken): %v", err)
+	}
+	if !aws.IsCredentialsProvider(b.cfg.Credentials, &stscreds.AssumeRoleProvider{}) {
+		t.Errorf("Expected assumed role credentials, got %T", b.cfg.Credentials)
+	}
+}
+
+func TestSplitRegionResource(t *testing.T) {
+	tests := []struct {
+		name           string
+		resource       string
+		expectedRegion string
+		expectedRest   string
+	}{
+		{"simple name", "myapp/db-creds", "", "myapp/db-creds"},
+		{"parameter path", "/myapp/config", "", "/myapp/config"},
+		{"region prefix", "eu-west-1:myapp/db-creds", "eu-west-1", "myapp/db-creds"},
+		{"region prefix with parameter path", "us-gov-west-1:/myapp/config", "us-gov-west-1", "/myapp/config"},
+		{
+			"secrets manager ARN",
+			"arn:aws:secretsmanager:eu-west-1:123456789012:secret:myapp/db-AbCdEf",
+			"eu-west-1",
+			"arn:aws:secretsmanager:eu-west-1:123456789012:secret:myapp/db-AbCdEf",
+		},
+		{
+			"parameter store ARN",
+			"arn:aws:ssm:ap-southeast-2:123456789012:parameter/myapp/config",
+			"ap-southeast-2",
+			"arn:aws:ssm:ap-southeast-2:123456789012:parameter/myapp/config",
+		},
+		{"other ARN", "arn:aws:s3:::bucket/key", "", "arn:aws:s3:::bucket/key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			region, rest := splitRegionResource(tt.resource)
+			if region != tt.expectedRegion || rest != tt.expectedRest {
+				t.Errorf("got (%q, %q), want (%q, %q)", region, rest, tt.expectedRegion, tt.expectedRest)
+			}
+		})
+	}
+}
+
+func TestAWSBackend_ForRegion(t *testing.T) {
+	t.Setenv("AWS_REGION", "us-east-1")
+
+	b, err := NewAWSBackend()
+	if err != nil {
+		t.Skipf("NewAWSBackend() failed (expected if AWS config is broken): %v", err)
+	}
+
+	if b.forRegion("us-east-1") != b {
+		t.Error("Expected the default backend for its own region")
+	}
+
+	eu := b.forRegion("eu-west-1")
+	if region := eu.secretsClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("Expected Secrets Manager client for eu-west-1, got %s", region)
+	}
+	if region := eu.ssmClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("Expected Parameter Store client for eu-west-1, got %s", region)
+	}
+	if b.forRegion("eu-west-1") != eu {
+		t.Error("Expected region clients to be reused")
+	}
+	if region := b.secretsClient.Options().Region; region != "us-east-1" {
+		t.Errorf("Expected the default backend to keep us-east-1, got %s", region)
+	}
+
+	// Role and region routing combine
+	roleEU := b.forRole("arn:aws:iam::123:role/reader").forRegion("eu-west-1")
+	if region := roleEU.secretsClient.Options().Region; region != "eu-west-1" {
+		t.Errorf("Expected assumed role client for eu-west-1, got %s", region)
+	}
+	if !aws.IsCredentialsProvider(roleEU.cfg.Credentials, &stscreds.AssumeRoleProvider{}) {
+		t.Error("Expected region backend of an assumed role to keep the role credentials")
+	}
+}
+
+// fakeSecretsManager answers Secrets Manager (and KMS Decrypt and SSM GetParametersByPath) JSON API calls.
+// GetSecretValue only knows full ARNs, so friendly names and partial ARNs need the ListSecrets fallback.
+type fakeSecretsManager struct {
+	secrets    map[string]string // Full ARN -> secret string
+	plaintexts map[string]string // Base64 KMS ciphertext -> plaintext
+	parameters map[string]string // Parameter name -> value, returned one per page
+	denyList   bool              // ListSecrets fails with AccessDeniedException, like under least-privilege IAM
+	calls      []string
+}
+
+func (f *fakeSecretsManager) Do(req *http.Request) (*http.Response, error) {
+	operation := strings.TrimPrefix(req.Header.Get("X-Amz-Target"), "secretsmanager.")
+	operation = strings.TrimPrefix(operation, "TrentService.")
+	operation = strings.TrimPrefix(operation, "AmazonSSM.")
+	f.calls = append(f.calls, operation)
+
+	var body map[string]interface{}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	switch operation {
+	case "GetSecretValue":
+		arn, _ := body["SecretId"].(string)
+		if value, exists := f.secrets[arn]; exists {
+			return fakeAWSResponse(200, map[string]interface{}{"ARN": arn, "SecretString": value}), nil
+		}
+		return fakeAWSResponse(400, map[string]interface{}{"__type": "ResourceNotFoundException", "message": "Secrets Manager can't find the specified secret."}), nil
+	case "ListSecrets":
+		if f.denyList {
+			return fakeAWSResponse(400, map[string]interface{}{"__type": "AccessDeniedException", "message": "not authorized to perform: secretsmanager:ListSecrets"}), nil
+		}
+		filters, _ := body["Filters"].([]interface{})
+		prefix := filters[0].(map[string]interface{})["Values"].([]interface{})[0].(string)
+		var list []map[string]string
+		for arn := range f.secrets {
+			name := strings.SplitN(arn, ":", 7)[6]
+			name = name[:len(name)-7] // Drop the random "-XXXXXX" suffix
+			if strings.HasPrefix(name, prefix) {
+				list = append(list, map[string]string{"ARN": arn, "Name": name})
+			}
+		}
+		return fakeAWSResponse(200, map[string]interface{}{"SecretList": list}), nil
+	case "GetParametersByPath":
+		path, _ := body["Path"].(string)
+		if recursive, _ := body["Recursive"].(bool); !recursive || body["WithDecryption"] != true {
+			return fakeAWSResponse(400, map[string]interface{}{"__type": "ValidationException", "message": "expected a recursive, decrypted lookup"}), nil
+		}
+		var names []string
+		for name := range f.parameters {
+			if strings.HasPrefix(name, strings.TrimSuffix(path, "/")+"/") {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+		start := 0
+		if token, _ := body["NextToken"].(string); token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		response := map[string]interface{}{"Parameters": []map[string]string{}}
+		if start < len(names) {
+			response["Parameters"] = []map[string]string{{"Name": names[start], "Value": f.parameters[names[start]]}}
+			if start+1 < len(names) {
+				response["NextToken"] = strconv.Itoa(start + 1)
+			}
+		}
+		return fakeAWSResponse(200, response), nil
+	case "Decrypt":
+		blob, _ := body["CiphertextBlob"].(string)
+		if plaintext, exists := f.plaintexts[blob]; exists {
+			return fakeAWSResponse(200, map[string]interface{}{"KeyId": body["KeyId"], "Plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext))}), nil
+		}
+		return fakeAWSResponse(400, map[string]interface{}{"__type": "InvalidCiphertextException", "message": "invalid ciphertext"}), nil
+	}
+	return fakeAWSResponse(400, map[string]interface{}{"__type": "InvalidRequestException", "message": operation}), nil
+}
+
+func fakeAWSResponse(status int, body interface{}) *http.Response {
+	data, _ := json.Marshal(body)
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{"Content-Type": []string{"application/x-amz-json-1.1"}},
+		Body:       io.NopCloser(bytes.NewReader(data)),
+	}
+}
+
+func newFakeSecretsManagerBackend(fake *fakeSecretsManager) *AWSBackend {
+	cfg := aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKIDEXAMPLE", "secret", ""),
+		HTTPClient:  fake,
+		Retryer:     func() aws.Retryer { return aws.NopRetryer{} },
+	}
+	return newAWSBackendFromConfig(cfg)
+}
+
+func TestAWSBackend_SecretsManagerNameLookup(t *testing.T) {
+	const fullARN = "arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db-AbCdEf"
+	fake := &fakeSecretsManager{secrets: map[string]string{
+		fullARN: "s3cret",
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db-replica-GhIjKl": "other",
+	}}
+	b := newFakeSecretsManagerBackend(fake)
+
+	// Exact ARNs take the fast path without a lookup
+	value, err := b.retrieveFromSecretsManager(context.Background(), fullARN)
+	if err != nil || value != "s3cret" {
+		t.Fatalf("full ARN: got %q, %v", value, err)
+	}
+	if strings.Join(fake.calls, ",") != "GetSecretValue" {
+		t.Errorf("full ARN: expected a single GetSecretValue, got %v", fake.calls)
+	}
+
+	// A partial ARN falls back to ListSecrets, ignoring names that merely share the prefix
+	fake.calls = nil
+	partialARN := "arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db"
+	value, err = b.retrieveFromSecretsManager(context.Background(), partialARN)
+	if err != nil || value != "s3cret" {
+		t.Fatalf("partial ARN: got %q, %v", value, err)
+	}
+	if strings.Join(fake.calls, ",") != "GetSecretValue,ListSecrets,GetSecretValue" {
+		t.Errorf("partial ARN: expected list-then-get fallback, got %v", fake.calls)
+	}
+
+	// The resolved ARN is cached for the same resource
+	fake.calls = nil
+	if value, err = b.retrieveFromSecretsManager(context.Background(), partialARN); err != nil || value != "s3cret" {
+		t.Fatalf("cached partial ARN: got %q, %v", value, err)
+	}
+	if strings.Join(fake.calls, ",") != "GetSecretValue" {
+		t.Errorf("cached partial ARN: expected a single GetSecretValue, got %v", fake.calls)
+	}
+
+	// A friendly name uses the same fallback
+	fake.calls = nil
+	if value, err = b.retrieveFromSecretsManager(context.Background(), "myapp/db-replica"); err != nil || value != "other" {
+		t.Fatalf("friendly name: got %q, %v", value, err)
+	}
+
+	// Unknown names still fail with the original error
+	fake.calls = nil
+	if _, err = b.retrieveFromSecretsManager(context.Background(), "missing/secret"); err == nil || !strings.Contains(err.Error(), "ResourceNotFoundException") {
+		t.Errorf("missing secret: expected not found error, got %v", err)
+	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("missing secret: expected ErrSecretNotFound, got %v", err)
+	}
+	if strings.Join(fake.calls, ",") != "GetSecretValue,ListSecrets" {
+		t.Errorf("missing secret: expected lookup without retry, got %v", fake.calls)
+	}
+}
+
+func TestAWSBackend_SecretsManagerNotFoundListDenied(t *testing.T) {
+	fake := &fakeSecretsManager{secrets: map[string]string{}, denyList: true}
+	b := newFakeSecretsManagerBackend(fake)
+
+	// A denied lookup stays in the message but doesn't hide that the secret is missing
+	_, err := b.retrieveFromSecretsManager(context.Background(), "missing/secret")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "name lookup") || !strings.Contains(err.Error(), "AccessDeniedException") {
+		t.Errorf("expected the lookup error as context, got %v", err)
+	}
+}
+
+func TestAWSBackend_KMSDecrypt(t *testing.T) {
+	ciphertext := base64.StdEncoding.EncodeToString([]byte("kms-ciphertext-blob"))
+	fake := &fakeSecretsManager{plaintexts: map[string]string{ciphertext: "decrypted"}}
+	b := newFakeSecretsManagerBackend(fake)
+	GetGlobalCache().Clear()
+	defer GetGlobalCache().Clear()
+
+	value, err := b.RetrieveSecret("kms", "alias/app", ciphertext)
+	if err != nil || value != "decrypted" {
+		t.Fatalf("inline ciphertext: got %q, %v", value, err)
+	}
+
+	// Repeated decrypts of the same blob are served from the cache
+	if value, err = b.RetrieveSecret("kms", "alias/app", ciphertext); err != nil || value != "decrypted" {
+		t.Fatalf("cached ciphertext: got %q, %v", value, err)
+	}
+	if strings.Join(fake.calls, ",") != "Decrypt" {
+		t.Errorf("expected a single Decrypt call, got %v", fake.calls)
+	}
+
+	// Ciphertext files may hold base64 text or the raw blob
+	dir := t.TempDir()
+	base64File := filepath.Join(dir, "secret.b64")
+	rawFile := filepath.Join(dir, "secret.bin")
+	if err := os.WriteFile(base64File, []byte(ciphertext+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(rawFile, []byte("kms-ciphertext-blob"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	GetGlobalCache().Clear()
+	for _, path := range []string{base64File, rawFile} {
+		if value, err = b.RetrieveSecret("kms", "alias/app", "@"+path); err != nil || value != "decrypted" {
+			t.Errorf("ciphertext file %s: got %q, %v", path, value, err)
+		}
+	}
+
+	if _, err = b.RetrieveSecret("kms", "alias/app", ""); err == nil {
+		t.Error("expected an error for a missing ciphertext")
+	}
+	if _, err = b.RetrieveSecret("kms", "alias/app", "not base64!"); err == nil {
+		t.Error("expected an error for invalid base64")
+	}
+}
+
+func TestAWSBackend_ParameterStorePath(t *testing.T) {
+	fake := &fakeSecretsManager{parameters: map[string]string{
+		"/myapp/config/api_key":   "k3y",
+		"/myapp/config/db/host":   "db.internal",
+		"/myapp/config/db/pass":   "s3cret",
+		"/myapp/configuration/no": "sibling path, not below /myapp/config",
+		"/other/value":            "unrelated",
+	}}
+	b := newFakeSecretsManagerBackend(fake)
+	GetGlobalCache().Clear()
+	defer GetGlobalCache().Clear()
+
+	value, err := b.RetrieveSecret("ps", "/myapp/config/*", "")
+	if err != nil {
+		t.Fatalf("RetrieveSecret() error = %v", err)
+	}
+	if want := `{"api_key":"k3y","db/host":"db.internal","db/pass":"s3cret"}`; value != want {
+		t.Errorf("RetrieveSecret() = %s, want %s", value, want)
+	}
+	// Every page is fetched, one parameter per page in the fake
+	if strings.Join(fake.calls, ",") != "GetParametersByPath,GetParametersByPath,GetParametersByPath" {
+		t.Errorf("expected three paginated calls, got %v", fake.calls)
+	}
+
+	// A keyPath picks one parameter from the cached path lookup
+	fake.calls = nil
+	if value, err = b.RetrieveSecret("ps", "/myapp/config/*", "db/pass"); err != nil || value != "s3cret" {
+		t.Errorf("keyPath db/pass: got %q, %v", value, err)
+	}
+	if len(fake.calls) != 0 {
+		t.Errorf("expected the path lookup to be cached, got %v", fake.calls)
+	}
+
+	_, err = b.RetrieveSecret("ps", "/missing/*", "")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("empty path: expected ErrSecretNotFound, got %v", err)
+	}
+}
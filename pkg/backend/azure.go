@@ -2,42 +2,93 @@ package backend
 
 import (
 	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
+	neturl "net/url"
+	"os"
 	"strings"
 
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azcertificates"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
 	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"golang.org/x/crypto/pkcs12"
 )
 
 // AzureBackend implements the Backend interface for Azure services.
 type AzureBackend struct {
-	keyVaultClients map[string]*azsecrets.Client
+	keyVaultClients map[string]interface{} // Clients keyed by "service:vault-name", or "appconfig:endpoint"
+	tenantID        string                 // Tenant override from SECRETINIT_AZURE_TENANT (empty uses the SDK default)
+}
+
+// azureSecretClient is the part of *azsecrets.Client used to read Key Vault secrets
+type azureSecretClient interface {
+	GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+}
+
+// azureAppConfigClient is the part of *azappconfig.Client used to read App Configuration settings
+type azureAppConfigClient interface {
+	GetSetting(ctx context.Context, key string, options *azappconfig.GetSettingOptions) (azappconfig.GetSettingResponse, error)
+}
+
+// azureKeyClient is the part of *azkeys.Client used to read Key Vault keys
+type azureKeyClient interface {
+	GetKey(ctx context.Context, name string, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error)
 }
 
 // NewAzureBackend creates a new AzureBackend using default Azure SDK configuration.
 // This uses the standard Azure SDK credential chain (environment variables,
 // managed identity, Azure CLI, etc.).
+// SECRETINIT_AZURE_TENANT takes precedence over AZURE_TENANT_ID for the credential's tenant.
 func NewAzureBackend() (*AzureBackend, error) {
 	return &AzureBackend{
-		keyVaultClients: make(map[string]*azsecrets.Client),
+		keyVaultClients: make(map[string]interface{}),
+		tenantID:        os.Getenv("SECRETINIT_AZURE_TENANT"),
 	}, nil
 }
 
 // RetrieveSecret retrieves a secret from Azure services.
-// The service parameter specifies which Azure service to use: "kv" for Key Vault.
+// The service parameter specifies which Azure service to use: "kv" for Key Vault secrets, "cert" for Key Vault certificates,
+// "key" for Key Vault keys, "appconfig" for App Configuration settings.
 // The resource should be in the format "vault-name/secret-name" or "vault-name/secret-name/version".
 // The keyPath is optional and used for JSON key extraction from the secret value.
+// For certificates the keyPath selects the material: "certificate" (default, PEM) or "private_key" (PEM).
+// For keys the keyPath must be "public_pem" (default), the public key as PEM; private keys never leave Key Vault.
+// App Configuration resources are "store/key" or "store/key/label", where store is a store name or endpoint URL.
 func (b *AzureBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context that bounds the Key Vault calls
+func (b *AzureBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
 	switch service {
 	case "kv":
-		return b.retrieveFromKeyVault(resource, keyPath)
+		return b.retrieveFromKeyVault(ctx, resource, keyPath)
+	case "cert":
+		return b.retrieveCertificate(ctx, resource, keyPath)
+	case "key":
+		return b.retrieveFromKey(ctx, resource, keyPath)
+	case "appconfig":
+		return b.retrieveFromAppConfig(ctx, resource, keyPath)
 	default:
-		return "", fmt.Errorf("unsupported Azure service '%s'. Supported services: 'kv' (Key Vault)", service)
+		return "", fmt.Errorf("unsupported Azure service '%s'. Supported services: 'kv' (Key Vault), 'cert' (Key Vault certificates), 'key' (Key Vault keys), 'appconfig' (App Configuration)", service)
 	}
 }
 
 // retrieveFromKeyVault retrieves a secret from Azure Key Vault.
-func (b *AzureBackend) retrieveFromKeyVault(resource, keyPath string) (string, error) {
+func (b *AzureBackend) retrieveFromKeyVault(ctx context.Context, resource, keyPath string) (string, error) {
 	// Parse the resource to extract vault name, secret name, and optional version
 	vaultName, secretName, version, err := b.parseKeyVaultResource(resource)
 	if err != nil {
@@ -63,8 +114,6 @@ func (b *AzureBackend) retrieveFromKeyVault(resource, keyPath string) (string, e
 	}
 
 	// Cache miss - retrieve from Azure Key Vault
-	ctx := context.Background()
-
 	// Get or create client for this vault
 	client, err := b.getKeyVaultClient(vaultName)
 	if err != nil {
@@ -80,7 +129,7 @@ func (b *AzureBackend) retrieveFromKeyVault(resource, keyPath string) (string, e
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve secret '%s' from Azure Key Vault '%s': %w", secretName, vaultName, err)
+		return "", azureError(fmt.Errorf("failed to retrieve secret '%s' from Azure Key Vault '%s': %w", secretName, vaultName, err))
 	}
 
 	if response.Value == nil {
@@ -89,7 +138,7 @@ func (b *AzureBackend) retrieveFromKeyVault(resource, keyPath string) (string, e
 
 	// Store raw secret value in cache
 	secretValue := *response.Value
-	cache.Set(cacheKey, secretValue)
+	cache.SetWithTTL(cacheKey, secretValue, BackendTTL("azure"))
 
 	// Parse keyPath from the raw secret value
 	if keyPath == "" {
@@ -99,6 +148,360 @@ func (b *AzureBackend) retrieveFromKeyVault(resource, keyPath string) (string, e
 	return extractJSONKey(secretValue, keyPath)
 }
 
+// retrieveCertificate retrieves a certificate from Azure Key Vault as PEM. The public certificate comes
+// from the certificates API; the private key is read from the certificate's backing secret, which holds
+// the full PFX or PEM bundle and requires secret read access.
+func (b *AzureBackend) retrieveCertificate(ctx context.Context, resource, keyPath string) (string, error) {
+	vaultName, certName, version, err := b.parseKeyVaultResource(resource)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse Key Vault certificate resource '%s': %w", resource, err)
+	}
+	if keyPath == "" {
+		keyPath = "certificate"
+	}
+	if keyPath != "certificate" && keyPath != "private_key" {
+		return "", fmt.Errorf("unsupported certificate keyPath '%s'. Supported: 'certificate' (default), 'private_key'", keyPath)
+	}
+
+	// Cache each material separately, including the version if specified
+	cacheKey := fmt.Sprintf("azure:cert:%s/%s", vaultName, certName)
+	if version != "" {
+		cacheKey += "/" + version
+	}
+	cacheKey += ":" + keyPath
+
+	cache := GetGlobalCache()
+	if cached, exists := cache.Get(cacheKey); exists {
+		return cached, nil
+	}
+
+	var value string
+	if keyPath == "certificate" {
+		value, err = b.getCertificatePEM(ctx, vaultName, certName, version)
+	} else {
+		value, err = b.getCertificatePrivateKey(ctx, vaultName, certName, version)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cache.SetWithTTL(cacheKey, value, BackendTTL("azure"))
+	return value, nil
+}
+
+// getCertificatePEM returns the public certificate as a PEM block
+func (b *AzureBackend) getCertificatePEM(ctx context.Context, vaultName, certName, version string) (string, error) {
+	client, err := b.getCertificateClient(vaultName)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Key Vault certificate client for vault '%s': %w", vaultName, err)
+	}
+
+	response, err := client.GetCertificate(ctx, certName, version, nil)
+	if err != nil {
+		return "", azureError(fmt.Errorf("failed to retrieve certificate '%s' from Azure Key Vault '%s': %w", certName, vaultName, err))
+	}
+	if len(response.CER) == 0 {
+		return "", fmt.Errorf("no certificate value found for '%s' in vault '%s'", certName, vaultName)
+	}
+
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: response.CER})), nil
+}
+
+// getCertificatePrivateKey returns the certificate's private key as a PKCS#8 PEM block
+func (b *AzureBackend) getCertificatePrivateKey(ctx context.Context, vaultName, certName, version string) (string, error) {
+	client, err := b.getKeyVaultClient(vaultName)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Key Vault client for vault '%s': %w", vaultName, err)
+	}
+
+	response, err := client.GetSecret(ctx, certName, version, nil)
+	if err != nil {
+		return "", azureError(fmt.Errorf("failed to retrieve private key of certificate '%s' from Azure Key Vault '%s': %w", certName, vaultName, err))
+	}
+	if response.Value == nil {
+		return "", fmt.Errorf("no private key found for certificate '%s' in vault '%s' (is the key exportable?)", certName, vaultName)
+	}
+
+	var contentType string
+	if response.ContentType != nil {
+		contentType = *response.ContentType
+	}
+	key, err := certificatePrivateKeyPEM(*response.Value, contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract private key of certificate '%s' in vault '%s': %w", certName, vaultName, err)
+	}
+	return key, nil
+}
+
+// retrieveFromKey retrieves the public part of a Key Vault key as a PEM "PUBLIC KEY" block
+func (b *AzureBackend) retrieveFromKey(ctx context.Context, resource, keyPath string) (string, error) {
+	vaultName, keyName, version, err := b.parseKeyVaultResource(resource)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse Key Vault key resource '%s': %w", resource, err)
+	}
+	if keyPath != "" && keyPath != "public_pem" {
+		return "", fmt.Errorf("unsupported key keyPath '%s'. Supported: 'public_pem' (default)", keyPath)
+	}
+
+	cacheKey := fmt.Sprintf("azure:key:%s/%s", vaultName, keyName)
+	if version != "" {
+		cacheKey += "/" + version
+	}
+
+	cache := GetGlobalCache()
+	if cached, exists := cache.Get(cacheKey); exists {
+		return cached, nil
+	}
+
+	client, err := b.getKeysClient(vaultName)
+	if err != nil {
+		return "", fmt.Errorf("failed to create Key Vault keys client for vault '%s': %w", vaultName, err)
+	}
+
+	response, err := client.GetKey(ctx, keyName, version, nil)
+	if err != nil {
+		return "", azureError(fmt.Errorf("failed to retrieve key '%s' from Azure Key Vault '%s': %w", keyName, vaultName, err))
+	}
+	if response.Key == nil {
+		return "", fmt.Errorf("no key material found for '%s' in vault '%s'", keyName, vaultName)
+	}
+
+	value, err := publicKeyPEM(response.Key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode public key of '%s' in vault '%s': %w", keyName, vaultName, err)
+	}
+
+	cache.SetWithTTL(cacheKey, value, BackendTTL("azure"))
+	return value, nil
+}
+
+// keyVaultRefContentType marks App Configuration settings that reference a Key Vault secret
+const keyVaultRefContentType = "application/vnd.microsoft.appconfig.keyvaultref+json"
+
+// retrieveFromAppConfig retrieves a setting from Azure App Configuration. Key Vault references are
+// resolved through retrieveFromKeyVault, so the keyPath applies to the referenced secret's value.
+func (b *AzureBackend) retrieveFromAppConfig(ctx context.Context, resource, keyPath string) (string, error) {
+	endpoint, store, key, label, err := parseAppConfigResource(resource)
+	if err != nil {
+		return "", err
+	}
+
+	setting := fmt.Sprintf("%s/%s", store, key)
+	if label != "" {
+		setting += "/" + label
+	}
+	cacheKey := "azure:appconfig:" + setting
+	// References are cached as the Key Vault resource they point to, so the secret keeps its own cache entry
+	refCacheKey := "azure:appconfig-ref:" + setting
+
+	cache := GetGlobalCache()
+	if cached, exists := cache.Get(refCacheKey); exists {
+		return b.retrieveFromKeyVault(ctx, cached, keyPath)
+	}
+	if cached, exists := cache.Get(cacheKey); exists {
+		if keyPath == "" {
+			return cached, nil
+		}
+		return extractJSONKey(cached, keyPath)
+	}
+
+	client, err := b.getAppConfigClient(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	var options *azappconfig.GetSettingOptions
+	if label != "" {
+		options = &azappconfig.GetSettingOptions{Label: &label}
+	}
+	response, err := client.GetSetting(ctx, key, options)
+	if err != nil {
+		return "", azureError(fmt.Errorf("failed to retrieve key '%s' from Azure App Configuration '%s': %w", key, store, err))
+	}
+	if response.Value == nil {
+		return "", fmt.Errorf("no value found for key '%s' in App Configuration '%s'", key, store)
+	}
+
+	value := *response.Value
+	if response.ContentType != nil && strings.HasPrefix(*response.ContentType, keyVaultRefContentType) {
+		kvResource, err := parseKeyVaultReference(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid Key Vault reference in key '%s' of App Configuration '%s': %w", key, store, err)
+		}
+		debugLog("Azure App Configuration: key %s references Key Vault secret %s", key, kvResource)
+		cache.SetWithTTL(refCacheKey, kvResource, BackendTTL("azure"))
+		return b.retrieveFromKeyVault(ctx, kvResource, keyPath)
+	}
+
+	cache.SetWithTTL(cacheKey, value, BackendTTL("azure"))
+	if keyPath == "" {
+		return value, nil
+	}
+	return extractJSONKey(value, keyPath)
+}
+
+// parseAppConfigResource parses "store/key[/label]", where store is an App Configuration store name
+// (https://store.azconfig.io) or an https endpoint URL. A "/" inside a key must be escaped as %2F.
+func parseAppConfigResource(resource string) (endpoint, store, key, label string, err error) {
+	var rest string
+	if strings.HasPrefix(resource, "https://") {
+		store, rest, _ = strings.Cut(strings.TrimPrefix(resource, "https://"), "/")
+		endpoint = "https://" + store
+	} else {
+		store, rest, _ = strings.Cut(resource, "/")
+		endpoint = fmt.Sprintf("https://%s.azconfig.io", store)
+	}
+
+	parts := strings.Split(rest, "/")
+	if store == "" || parts[0] == "" || len(parts) > 2 {
+		return "", "", "", "", fmt.Errorf("invalid App Configuration resource '%s': expected 'store/key' or 'store/key/label'", resource)
+	}
+	key, err = neturl.PathUnescape(parts[0])
+	if err != nil {
+		return "", "", "", "", fmt.Errorf("invalid App Configuration key in '%s': %w", resource, err)
+	}
+	if len(parts) == 2 {
+		label = parts[1]
+	}
+	return endpoint, store, key, label, nil
+}
+
+// parseKeyVaultReference turns a Key Vault reference, {"uri":"https://VAULT.vault.azure.net/secrets/NAME[/VERSION]"},
+// into the "vault/name[/version]" resource retrieveFromKeyVault expects
+func parseKeyVaultReference(value string) (string, error) {
+	var ref struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(value), &ref); err != nil {
+		return "", fmt.Errorf("failed to parse reference JSON: %w", err)
+	}
+	u, err := neturl.Parse(ref.URI)
+	if err != nil || u.Scheme != "https" {
+		return "", fmt.Errorf("expected an https Key Vault secret URI, got '%s'", ref.URI)
+	}
+	vaultName, domain, _ := strings.Cut(u.Hostname(), ".")
+	if domain != "vault.azure.net" {
+		return "", fmt.Errorf("secret URI '%s' is not in a *.vault.azure.net Key Vault", ref.URI)
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if parts[0] != "secrets" || len(parts) < 2 || len(parts) > 3 || parts[1] == "" {
+		return "", fmt.Errorf("expected a secret URI like https://VAULT.vault.azure.net/secrets/NAME, got '%s'", ref.URI)
+	}
+	return vaultName + "/" + strings.Join(parts[1:], "/"), nil
+}
+
+// publicKeyPEM encodes the public part of an RSA or EC JSON web key as a PKIX PEM block
+func publicKeyPEM(key *azkeys.JSONWebKey) (string, error) {
+	if key.Kty == nil {
+		return "", fmt.Errorf("key has no type")
+	}
+
+	var publicKey crypto.PublicKey
+	switch *key.Kty {
+	case azkeys.KeyTypeRSA, azkeys.KeyTypeRSAHSM:
+		if len(key.N) == 0 || len(key.E) == 0 {
+			return "", fmt.Errorf("RSA key is missing its modulus or exponent")
+		}
+		publicKey = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(key.N),
+			E: int(new(big.Int).SetBytes(key.E).Int64()),
+		}
+	case azkeys.KeyTypeEC, azkeys.KeyTypeECHSM:
+		if key.Crv == nil {
+			return "", fmt.Errorf("EC key has no curve")
+		}
+		var curve elliptic.Curve
+		switch *key.Crv {
+		case azkeys.CurveNameP256:
+			curve = elliptic.P256()
+		case azkeys.CurveNameP384:
+			curve = elliptic.P384()
+		case azkeys.CurveNameP521:
+			curve = elliptic.P521()
+		default:
+			return "", fmt.Errorf("unsupported EC curve '%s'", *key.Crv)
+		}
+		publicKey = &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.X),
+			Y:     new(big.Int).SetBytes(key.Y),
+		}
+	default:
+		return "", fmt.Errorf("unsupported key type '%s' (supported: RSA, EC)", *key.Kty)
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// certificatePrivateKeyPEM extracts the private key from a certificate's backing secret, which is
+// either a PEM bundle ("application/x-pem-file") or a base64 PFX ("application/x-pkcs12")
+func certificatePrivateKeyPEM(value, contentType string) (string, error) {
+	var blocks []*pem.Block
+	if contentType == "application/x-pem-file" || strings.Contains(value, "-----BEGIN") {
+		rest := []byte(value)
+		for {
+			var block *pem.Block
+			block, rest = pem.Decode(rest)
+			if block == nil {
+				break
+			}
+			blocks = append(blocks, block)
+		}
+	} else {
+		pfx, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid PFX: expected base64: %w", err)
+		}
+		blocks, err = pkcs12.ToPEM(pfx, "")
+		if err != nil {
+			return "", fmt.Errorf("invalid PFX: %w", err)
+		}
+	}
+
+	for _, block := range blocks {
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		key, err := parsePrivateKey(block.Bytes)
+		if err != nil {
+			return "", err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode private key: %w", err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})), nil
+	}
+	return "", fmt.Errorf("no private key in certificate secret")
+}
+
+// parsePrivateKey parses a PKCS#8, PKCS#1 (RSA) or SEC 1 (EC) private key
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported private key format")
+}
+
+// azureError marks Key Vault 404 responses as ErrSecretNotFound
+func azureError(err error) error {
+	var responseErr *azcore.ResponseError
+	if errors.As(err, &responseErr) && responseErr.StatusCode == http.StatusNotFound {
+		return notFound(err)
+	}
+	return err
+}
+
 // parseKeyVaultResource parses the resource string into vault name, secret name, and optional version.
 // Supports formats:
 // - "vault-name/secret-name" (latest version)
@@ -118,37 +521,103 @@ func (b *AzureBackend) parseKeyVaultResource(resource string) (vaultName, secret
 	}
 }
 
-// getKeyVaultClient gets or creates a Key Vault client for the specified vault.
-func (b *AzureBackend) getKeyVaultClient(vaultName string) (*azsecrets.Client, error) {
-	// Check if we already have a client for this vault
-	if client, exists := b.keyVaultClients[vaultName]; exists {
+// getKeyVaultClient gets or creates a Key Vault secrets client for the specified vault.
+func (b *AzureBackend) getKeyVaultClient(vaultName string) (azureSecretClient, error) {
+	client, err := b.getClient("kv", vaultName, func(vaultURL string, cred azcore.TokenCredential) (interface{}, error) {
+		return azsecrets.NewClient(vaultURL, cred, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(azureSecretClient), nil
+}
+
+// getCertificateClient gets or creates a Key Vault certificates client for the specified vault.
+func (b *AzureBackend) getCertificateClient(vaultName string) (*azcertificates.Client, error) {
+	client, err := b.getClient("cert", vaultName, func(vaultURL string, cred azcore.TokenCredential) (interface{}, error) {
+		return azcertificates.NewClient(vaultURL, cred, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(*azcertificates.Client), nil
+}
+
+// getKeysClient gets or creates a Key Vault keys client for the specified vault.
+func (b *AzureBackend) getKeysClient(vaultName string) (azureKeyClient, error) {
+	client, err := b.getClient("key", vaultName, func(vaultURL string, cred azcore.TokenCredential) (interface{}, error) {
+		return azkeys.NewClient(vaultURL, cred, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return client.(azureKeyClient), nil
+}
+
+// getClient gets or creates the client for a service and vault, caching it by both.
+func (b *AzureBackend) getClient(service, vaultName string, newClient func(vaultURL string, cred azcore.TokenCredential) (interface{}, error)) (interface{}, error) {
+	// Check if we already have a client for this service and vault
+	clientKey := service + ":" + vaultName
+	if client, exists := b.keyVaultClients[clientKey]; exists {
 		return client, nil
 	}
 
-	// Create credential using default credential chain
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	cred, err := b.credential()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Azure credentials: %w", err)
+		return nil, err
 	}
 
 	// Construct the Key Vault URL
 	vaultURL := fmt.Sprintf("https://%s.vault.azure.net/", vaultName)
 
 	// Create the Key Vault client
-	client, err := azsecrets.NewClient(vaultURL, cred, nil)
+	client, err := newClient(vaultURL, cred)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Key Vault client for vault '%s': %w", vaultName, err)
 	}
 
 	// Cache the client for future use
-	b.keyVaultClients[vaultName] = client
+	b.keyVaultClients[clientKey] = client
 
 	return client, nil
 }
 
+// getAppConfigClient gets or creates an App Configuration client for the store endpoint.
+func (b *AzureBackend) getAppConfigClient(endpoint string) (azureAppConfigClient, error) {
+	clientKey := "appconfig:" + endpoint
+	if client, exists := b.keyVaultClients[clientKey]; exists {
+		return client.(azureAppConfigClient), nil
+	}
+
+	cred, err := b.credential()
+	if err != nil {
+		return nil, err
+	}
+	client, err := azappconfig.NewClient(endpoint, cred, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create App Configuration client for '%s': %w", endpoint, err)
+	}
+
+	b.keyVaultClients[clientKey] = client
+	return client, nil
+}
+
+// credential creates an Azure credential from the default credential chain
+func (b *AzureBackend) credential() (azcore.TokenCredential, error) {
+	var credOptions *azidentity.DefaultAzureCredentialOptions
+	if b.tenantID != "" {
+		credOptions = &azidentity.DefaultAzureCredentialOptions{TenantID: b.tenantID}
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(credOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure credentials: %w", err)
+	}
+	return cred, nil
+}
+
 // Close performs cleanup for the Azure backend.
 func (b *AzureBackend) Close() error {
 	// Azure SDK clients don't require explicit cleanup, but we can clear the cache
-	b.keyVaultClients = make(map[string]*azsecrets.Client)
+	b.keyVaultClients = make(map[string]interface{})
 	return nil
 }
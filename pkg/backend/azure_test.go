@@ -0,0 +1,345 @@
+package backend
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azkeys"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+)
+
+func TestNewAzureBackend_TenantOverride(t *testing.T) {
+	t.Setenv("AZURE_TENANT_ID", "ambient-tenant")
+	t.Setenv("SECRETINIT_AZURE_TENANT", "secretinit-tenant")
+
+	b, err := NewAzureBackend()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if b.tenantID != "secretinit-tenant" {
+		t.Errorf("Expected SECRETINIT_AZURE_TENANT to win, got tenant '%s'", b.tenantID)
+	}
+
+	t.Setenv("SECRETINIT_AZURE_TENANT", "")
+	b, _ = NewAzureBackend()
+	if b.tenantID != "" {
+		t.Errorf("Expected SDK default tenant handling when unset, got '%s'", b.tenantID)
+	}
+}
+
+func TestCertificatePrivateKeyPEM(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte("not parsed")})
+
+	// Key Vault PEM bundles hold the key and the certificate chain
+	got, err := certificatePrivateKeyPEM(string(certPEM)+string(keyPEM), "application/x-pem-file")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	block, _ := pem.Decode([]byte(got))
+	if block == nil || block.Type != "PRIVATE KEY" {
+		t.Fatalf("Expected a PKCS#8 PRIVATE KEY block, got %q", got)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("Failed to parse returned key: %v", err)
+	}
+	if !key.Equal(parsed) {
+		t.Error("Returned key differs from the bundled key")
+	}
+
+	if _, err := certificatePrivateKeyPEM(string(certPEM), "application/x-pem-file"); err == nil {
+		t.Error("Expected an error for a bundle without a private key")
+	}
+	if _, err := certificatePrivateKeyPEM("not base64!", "application/x-pkcs12"); err == nil {
+		t.Error("Expected an error for an invalid PFX")
+	}
+}
+
+func TestAzureBackend_RetrieveCertificate(t *testing.T) {
+	b, _ := NewAzureBackend()
+	cache := GetGlobalCache()
+	cache.Clear()
+	defer cache.Clear()
+
+	// Certificate material is cached per vault, certificate, version and keyPath
+	cache.Set("azure:cert:my-vault/ssl-cert:certificate", "CERT PEM")
+	cache.Set("azure:cert:my-vault/ssl-cert/v2:private_key", "KEY PEM")
+
+	if got, err := b.RetrieveSecret("cert", "my-vault/ssl-cert", ""); err != nil || got != "CERT PEM" {
+		t.Errorf("Expected the cached certificate by default, got %q, %v", got, err)
+	}
+	if got, err := b.RetrieveSecret("cert", "my-vault/ssl-cert/v2", "private_key"); err != nil || got != "KEY PEM" {
+		t.Errorf("Expected the cached private key, got %q, %v", got, err)
+	}
+	if _, err := b.RetrieveSecret("cert", "my-vault/ssl-cert", "password"); err == nil {
+		t.Error("Expected an error for an unsupported certificate keyPath")
+	}
+	if _, err := b.RetrieveSecret("cert", "ssl-cert", ""); err == nil {
+		t.Error("Expected an error for a resource without a vault")
+	}
+}
+
+// fakeKeyClient returns a fixed key and counts GetKey calls
+type fakeKeyClient struct {
+	key   *azkeys.JSONWebKey
+	calls int
+}
+
+func (c *fakeKeyClient) GetKey(ctx context.Context, name string, version string, options *azkeys.GetKeyOptions) (azkeys.GetKeyResponse, error) {
+	c.calls++
+	return azkeys.GetKeyResponse{KeyBundle: azkeys.KeyBundle{Key: c.key}}, nil
+}
+
+func TestAzureBackend_RetrieveFromKey(t *testing.T) {
+	cache := GetGlobalCache()
+	cache.Clear()
+	defer cache.Clear()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	kty := azkeys.KeyTypeRSAHSM
+	client := &fakeKeyClient{key: &azkeys.JSONWebKey{
+		Kty: &kty,
+		N:   rsaKey.N.Bytes(),
+		E:   big.NewInt(int64(rsaKey.E)).Bytes(),
+	}}
+
+	b, _ := NewAzureBackend()
+	b.keyVaultClients["key:my-vault"] = client
+
+	got, err := b.RetrieveSecret("key", "my-vault/signing-key", "public_pem")
+	if err != nil {
+		t.Fatalf("RetrieveSecret() error = %v", err)
+	}
+	block, _ := pem.Decode([]byte(got))
+	if block == nil || block.Type != "PUBLIC KEY" {
+		t.Fatalf("Expected a PUBLIC KEY PEM block, got %q", got)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse public key: %v", err)
+	}
+	if !rsaKey.PublicKey.Equal(parsed) {
+		t.Error("Public key does not match the Key Vault key")
+	}
+
+	// The default keyPath is public_pem, served from the cache
+	if again, err := b.RetrieveSecret("key", "my-vault/signing-key", ""); err != nil || again != got {
+		t.Errorf("Expected the cached public key, got %q, %v", again, err)
+	}
+	if client.calls != 1 {
+		t.Errorf("Expected 1 GetKey call, got %d", client.calls)
+	}
+	if _, exists := cache.Get("azure:key:my-vault/signing-key"); !exists {
+		t.Error("Expected the public key cached under azure:key:my-vault/signing-key")
+	}
+
+	if _, err := b.RetrieveSecret("key", "my-vault/signing-key", "private_pem"); err == nil {
+		t.Error("Expected an error for an unsupported key keyPath")
+	}
+}
+
+func TestPublicKeyPEM_EC(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	kty, crv := azkeys.KeyTypeEC, azkeys.CurveNameP256
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	got, err := publicKeyPEM(&azkeys.JSONWebKey{Kty: &kty, Crv: &crv, X: ecKey.X.Bytes(), Y: ecKey.Y.Bytes()})
+	if err != nil {
+		t.Fatalf("publicKeyPEM() error = %v", err)
+	}
+	if expected := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})); got != expected {
+		t.Errorf("publicKeyPEM() = %q, want %q", got, expected)
+	}
+
+	oct := azkeys.KeyTypeOct
+	if _, err := publicKeyPEM(&azkeys.JSONWebKey{Kty: &oct}); err == nil {
+		t.Error("Expected an error for a symmetric key")
+	}
+}
+
+// fakeAppConfigClient serves settings keyed by "key/label" and counts GetSetting calls
+type fakeAppConfigClient struct {
+	settings map[string]azappconfig.Setting
+	calls    int
+}
+
+func (c *fakeAppConfigClient) GetSetting(ctx context.Context, key string, options *azappconfig.GetSettingOptions) (azappconfig.GetSettingResponse, error) {
+	c.calls++
+	label := ""
+	if options != nil && options.Label != nil {
+		label = *options.Label
+	}
+	setting, ok := c.settings[key+"/"+label]
+	if !ok {
+		return azappconfig.GetSettingResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return azappconfig.GetSettingResponse{Setting: setting}, nil
+}
+
+// fakeSecretClient serves Key Vault secrets keyed by "name" or "name/version"
+type fakeSecretClient struct {
+	secrets map[string]string
+	calls   int
+}
+
+func (c *fakeSecretClient) GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	c.calls++
+	if version != "" {
+		name += "/" + version
+	}
+	value, ok := c.secrets[name]
+	if !ok {
+		return azsecrets.GetSecretResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound}
+	}
+	return azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: &value}}, nil
+}
+
+func TestAzureBackend_RetrieveFromAppConfig(t *testing.T) {
+	cache := GetGlobalCache()
+	cache.Clear()
+	defer cache.Clear()
+
+	str := func(s string) *string { return &s }
+	client := &fakeAppConfigClient{settings: map[string]azappconfig.Setting{
+		"App:Color/":      {Value: str("blue")},
+		"App:Color/prod":  {Value: str("red")},
+		"App:Db/":         {Value: str(`{"host":"db.internal","port":5432}`), ContentType: str("application/json")},
+		"app/feature/":    {Value: str("on")},
+		"App:DbPassword/": {Value: str(`{"uri":"https://my-vault.vault.azure.net/secrets/db-creds"}`), ContentType: str("application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8")},
+		"App:Pinned/":     {Value: str(`{"uri":"https://my-vault.vault.azure.net/secrets/db-creds/v1"}`), ContentType: str("application/vnd.microsoft.appconfig.keyvaultref+json;charset=utf-8")},
+	}}
+	secrets := &fakeSecretClient{secrets: map[string]string{
+		"db-creds":    `{"username":"app","password":"s3cret"}`,
+		"db-creds/v1": `{"username":"app","password":"old"}`,
+	}}
+
+	b, _ := NewAzureBackend()
+	b.keyVaultClients["appconfig:https://my-store.azconfig.io"] = client
+	b.keyVaultClients["kv:my-vault"] = secrets
+
+	tests := []struct {
+		name     string
+		resource string
+		keyPath  string
+		want     string
+	}{
+		{name: "plain value", resource: "my-store/App:Color", want: "blue"},
+		{name: "label", resource: "my-store/App:Color/prod", want: "red"},
+		{name: "endpoint URL", resource: "https://my-store.azconfig.io/App:Color", want: "blue"},
+		{name: "escaped slash in key", resource: "my-store/app%2Ffeature", want: "on"},
+		{name: "JSON keyPath", resource: "my-store/App:Db", keyPath: "host", want: "db.internal"},
+		{name: "Key Vault reference", resource: "my-store/App:DbPassword", keyPath: "password", want: "s3cret"},
+		{name: "Key Vault reference with version", resource: "my-store/App:Pinned", keyPath: "password", want: "old"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.RetrieveSecret("appconfig", tt.resource, tt.keyPath)
+			if err != nil {
+				t.Fatalf("RetrieveSecret() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RetrieveSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Cached settings and references are not fetched again
+	settingCalls, secretCalls := client.calls, secrets.calls
+	if got, err := b.RetrieveSecret("appconfig", "my-store/App:DbPassword", "username"); err != nil || got != "app" {
+		t.Errorf("Expected the cached reference to resolve, got %q, %v", got, err)
+	}
+	if got, err := b.RetrieveSecret("appconfig", "my-store/App:Db", "port"); err != nil || got != "5432" {
+		t.Errorf("Expected the cached setting, got %q, %v", got, err)
+	}
+	if client.calls != settingCalls || secrets.calls != secretCalls {
+		t.Errorf("Expected no further calls, got %d GetSetting and %d GetSecret", client.calls-settingCalls, secrets.calls-secretCalls)
+	}
+	if cached, _ := cache.Get("azure:appconfig-ref:my-store/App:DbPassword"); cached != "my-vault/db-creds" {
+		t.Errorf("Expected the reference cached as its Key Vault resource, got %q", cached)
+	}
+
+	_, err := b.RetrieveSecret("appconfig", "my-store/App:Missing", "")
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("Expected ErrSecretNotFound for a missing key, got %v", err)
+	}
+}
+
+func TestParseAppConfigResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		endpoint string
+		key      string
+		label    string
+		wantErr  bool
+	}{
+		{resource: "my-store/App:Color", endpoint: "https://my-store.azconfig.io", key: "App:Color"},
+		{resource: "my-store/App:Color/prod", endpoint: "https://my-store.azconfig.io", key: "App:Color", label: "prod"},
+		{resource: "https://cfg.example.azconfig.io/App:Color/prod", endpoint: "https://cfg.example.azconfig.io", key: "App:Color", label: "prod"},
+		{resource: "my-store/a%2Fb", endpoint: "https://my-store.azconfig.io", key: "a/b"},
+		{resource: "my-store", wantErr: true},
+		{resource: "/App:Color", wantErr: true},
+		{resource: "my-store/a/b/c", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			endpoint, _, key, label, err := parseAppConfigResource(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAppConfigResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if endpoint != tt.endpoint || key != tt.key || label != tt.label {
+				t.Errorf("parseAppConfigResource() = %q, %q, %q, want %q, %q, %q", endpoint, key, label, tt.endpoint, tt.key, tt.label)
+			}
+		})
+	}
+}
+
+func TestParseKeyVaultReference(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: `{"uri":"https://my-vault.vault.azure.net/secrets/db-creds"}`, want: "my-vault/db-creds"},
+		{value: `{"uri":"https://my-vault.vault.azure.net/secrets/db-creds/abc123"}`, want: "my-vault/db-creds/abc123"},
+		{value: `{"uri":"https://my-vault.vault.azure.net/keys/signing-key"}`, wantErr: true},
+		{value: `{"uri":"https://my-vault.vault.azure.cn/secrets/db-creds"}`, wantErr: true},
+		{value: `{"uri":"http://my-vault.vault.azure.net/secrets/db-creds"}`, wantErr: true},
+		{value: `not json`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseKeyVaultReference(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseKeyVaultReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseKeyVaultReference() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,46 @@
 package backend
 
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+)
+
+// ErrSecretNotFound is matched (via errors.Is) by backend errors for a secret, or a key within it,
+// that does not exist, as opposed to access or connection failures
+var ErrSecretNotFound = errors.New("secret not found")
+
 // Backend defines the interface for retrieving secrets from a specific backend.
 type Backend interface {
 	RetrieveSecret(service, resource, keyPath string) (string, error)
 }
+
+// ContextBackend is implemented by backends whose calls can be bounded by a context.
+// RetrieveSecretCtx must give up (killing any helper process) once ctx is done.
+type ContextBackend interface {
+	RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error)
+}
+
+// ClientCertBackend is implemented by backends that can use a TLS client certificate (mTLS)
+// for their own API calls. It returns a copy of the backend configured with the certificate.
+type ClientCertBackend interface {
+	WithClientCertificate(cert tls.Certificate) (Backend, error)
+}
+
+// notFoundError marks a backend error as ErrSecretNotFound while keeping its message
+type notFoundError struct {
+	err error
+}
+
+func (e *notFoundError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notFoundError) Unwrap() []error {
+	return []error{e.err, ErrSecretNotFound}
+}
+
+// notFound wraps err so that errors.Is(err, ErrSecretNotFound) reports true
+func notFound(err error) error {
+	return &notFoundError{err: err}
+}
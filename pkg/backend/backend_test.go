@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestErrSecretNotFound(t *testing.T) {
+	_, missingKeyErr := extractJSONKey(`{"enabled":"true"}`, "missing")
+	_, missingGitFieldErr := parseGitCredential("protocol=https\nhost=example.com\n", "password")
+	_, invalidJSONErr := extractJSONKey(`not json`, "enabled")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "missing JSON key", err: missingKeyErr, expected: true},
+		{name: "missing git credential field", err: missingGitFieldErr, expected: true},
+		{name: "invalid JSON", err: invalidJSONErr, expected: false},
+		{name: "plain error", err: errors.New("access denied"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.err == nil {
+				t.Fatal("expected an error")
+			}
+			if got := errors.Is(tt.err, ErrSecretNotFound); got != tt.expected {
+				t.Errorf("errors.Is(%v, ErrSecretNotFound) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+
+	// The wrapper keeps the original message
+	if wrapped := notFound(errors.New("credential not found")); wrapped.Error() != "credential not found" {
+		t.Errorf("notFound changed the message to %q", wrapped.Error())
+	}
+}
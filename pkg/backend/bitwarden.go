@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// BitwardenBackend implements the Backend interface using the Bitwarden CLI ("bw").
+// The CLI must be logged in and unlocked, with the session key exported as BW_SESSION.
+type BitwardenBackend struct {
+	Command string // bw executable (default "bw", override with SECRETINIT_BW_COMMAND)
+}
+
+// NewBitwardenBackend creates a new BitwardenBackend using the bw CLI found in PATH.
+func NewBitwardenBackend() (*BitwardenBackend, error) {
+	b := &BitwardenBackend{Command: "bw"}
+	if command := os.Getenv("SECRETINIT_BW_COMMAND"); command != "" {
+		b.Command = command
+	}
+	return b, nil
+}
+
+// bitwardenKeyPathAliases maps short keyPaths to their location in the item JSON
+var bitwardenKeyPathAliases = map[string]string{
+	"username": "login.username",
+	"password": "login.password",
+	"totp":     "login.totp",
+}
+
+// RetrieveSecret retrieves a Bitwarden item with "bw get item".
+// The service parameter must be "item".
+// The resource is an item ID or a name that matches exactly one item.
+// The keyPath selects a field of the item JSON (e.g. "login.password" or "notes");
+// "username", "password" and "totp" are shorthands for the login fields.
+func (b *BitwardenBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context; the bw CLI is killed when it expires
+func (b *BitwardenBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	if service != "item" {
+		return "", fmt.Errorf("unsupported Bitwarden service '%s'. Supported services: 'item'", service)
+	}
+
+	cache := GetGlobalCache()
+	// Cache the full item JSON, keyPath is only field selection
+	cacheKey := fmt.Sprintf("bitwarden:item:%s", resource)
+
+	var item string
+	if cached, exists := cache.Get(cacheKey); exists {
+		item = cached
+	} else {
+		var err error
+		item, err = b.getItem(ctx, resource)
+		if err != nil {
+			return "", err
+		}
+		cache.SetWithTTL(cacheKey, item, BackendTTL("bitwarden"))
+	}
+
+	if keyPath == "" {
+		return item, nil
+	}
+	if alias, exists := bitwardenKeyPathAliases[keyPath]; exists {
+		keyPath = alias
+	}
+	return extractJSONKey(item, keyPath)
+}
+
+// getItem runs "bw get item" and returns the item JSON
+func (b *BitwardenBackend) getItem(ctx context.Context, resource string) (string, error) {
+	debugLog("Bitwarden backend: running %s get item %s", b.Command, resource)
+
+	cmd := exec.CommandContext(ctx, b.Command, "get", "item", resource, "--nointeraction")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("bitwarden CLI interrupted: %w", ctx.Err())
+	}
+	if err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("bitwarden CLI '%s' not found: install it (https://bitwarden.com/help/cli/) or set SECRETINIT_BW_COMMAND", b.Command)
+		}
+		return "", bitwardenError(resource, strings.TrimSpace(stderr.String()), err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// bitwardenError turns a bw CLI failure into an actionable error
+func bitwardenError(resource, message string, err error) error {
+	lower := strings.ToLower(message)
+	switch {
+	case strings.Contains(lower, "locked"):
+		return fmt.Errorf("bitwarden vault is locked: run 'bw unlock' and export the session key as BW_SESSION (%s)", message)
+	case strings.Contains(lower, "not logged in"):
+		return fmt.Errorf("bitwarden CLI is not logged in: run 'bw login', then 'bw unlock' and export BW_SESSION (%s)", message)
+	case strings.Contains(lower, "not found"):
+		return notFound(fmt.Errorf("bitwarden item '%s' not found", resource))
+	case strings.Contains(lower, "more than one result"):
+		return fmt.Errorf("bitwarden item name '%s' matches more than one item, use the item ID instead", resource)
+	case message != "":
+		return fmt.Errorf("bitwarden CLI failed for item '%s': %w: %s", resource, err, message)
+	default:
+		return fmt.Errorf("bitwarden CLI failed for item '%s': %w", resource, err)
+	}
+}
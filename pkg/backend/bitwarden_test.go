@@ -0,0 +1,94 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const bitwardenItem = `{"id":"2f1c","name":"GitHub Deploy","login":{"username":"deploy","password":"s3cret","totp":null},"notes":"rotate monthly"}`
+
+func TestBitwardenBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	argsFile := filepath.Join(t.TempDir(), "args")
+	fakeBW := writeFakeSSH(t, `echo "$@" >> `+argsFile+`
+echo '`+bitwardenItem+`'
+`)
+	b := &BitwardenBackend{Command: fakeBW}
+
+	tests := []struct {
+		keyPath  string
+		expected string
+	}{
+		{"password", "s3cret"},
+		{"login.password", "s3cret"},
+		{"username", "deploy"},
+		{"notes", "rotate monthly"},
+		{"", bitwardenItem},
+	}
+	for _, tt := range tests {
+		value, err := b.RetrieveSecret("item", "GitHub Deploy", tt.keyPath)
+		if err != nil {
+			t.Fatalf("keyPath %q: unexpected error: %v", tt.keyPath, err)
+		}
+		if value != tt.expected {
+			t.Errorf("keyPath %q: expected %q, got %q", tt.keyPath, tt.expected, value)
+		}
+	}
+
+	// The item JSON is fetched once and cached for every field
+	args, _ := os.ReadFile(argsFile)
+	if string(args) != "get item GitHub Deploy --nointeraction\n" {
+		t.Errorf("Expected a single bw call, got %q", string(args))
+	}
+
+	if _, err := b.RetrieveSecret("item", "GitHub Deploy", "login.totp"); err == nil {
+		t.Error("Expected an error for a null field")
+	}
+	if _, err := b.RetrieveSecret("folder", "GitHub Deploy", "password"); err == nil {
+		t.Error("Expected an error for an unsupported service")
+	}
+}
+
+func TestBitwardenBackend_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		contains string
+		notFound bool
+	}{
+		{name: "locked vault", stderr: "Vault is locked.", contains: "bw unlock"},
+		{name: "logged out", stderr: "You are not logged in.", contains: "bw login"},
+		{name: "missing item", stderr: "Not found.", contains: "not found", notFound: true},
+		{name: "ambiguous name", stderr: "More than one result was found.", contains: "use the item ID"},
+		{name: "other failure", stderr: "boom", contains: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ClearGlobalCache()
+			defer ClearGlobalCache()
+
+			b := &BitwardenBackend{Command: writeFakeSSH(t, "echo '"+tt.stderr+"' >&2\nexit 1\n")}
+			_, err := b.RetrieveSecret("item", "my-item", "password")
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Fatalf("Expected error containing %q, got %v", tt.contains, err)
+			}
+			if errors.Is(err, ErrSecretNotFound) != tt.notFound {
+				t.Errorf("Expected errors.Is(ErrSecretNotFound) = %v, got %v", tt.notFound, err)
+			}
+		})
+	}
+}
+
+func TestBitwardenBackend_MissingCLI(t *testing.T) {
+	b := &BitwardenBackend{Command: "secretinit-no-such-bw"}
+	_, err := b.RetrieveSecret("item", "my-item", "password")
+	if err == nil || !strings.Contains(err.Error(), "not found: install it") {
+		t.Errorf("Expected an actionable missing CLI error, got %v", err)
+	}
+}
@@ -1,71 +1,222 @@
 package backend
 
 import (
+	"container/list"
 	"crypto/sha256"
 	"fmt"
+	"maps"
 	"os"
+	"slices"
+	"strings"
 	"sync"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/logging"
 )
 
 var debugEnabled = os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG"
 
-// debugLog prints debug messages to stderr if debugEnabled is true.
+// debugLog prints debug messages to stderr (see package logging) if debugEnabled is true.
 func debugLog(format string, args ...interface{}) {
 	if debugEnabled {
-		fmt.Fprintf(os.Stderr, "[DEBUG] "+format+"\n", args...)
+		logging.Printf(logging.LevelDebug, format, args...)
 	}
 }
 
+// cacheEntry is a cached value with an optional expiry (zero means it never expires)
+type cacheEntry struct {
+	value     string
+	expiresAt time.Time
+	element   *list.Element // Position in the LRU order, nil when the cache is unbounded
+}
+
 // Cache provides a thread-safe in-memory cache for backend data
 type Cache struct {
-	data  map[string]string
-	mutex sync.RWMutex
+	data     map[string]cacheEntry
+	mutex    sync.RWMutex
+	ttl      time.Duration    // Default lifetime of entries, zero means they never expire
+	capacity int              // Maximum number of entries, zero means unbounded
+	lru      *list.List       // Keys from most to least recently used, only kept with a capacity
+	now      func() time.Time // Clock used for expiry, replaceable in tests
+	persist  *cacheFile       // Encrypted file every change is written to, nil when in memory only (see PersistTo)
 }
 
-// NewCache creates a new cache instance
+// NewCache creates a new cache instance whose entries never expire
 func NewCache() *Cache {
-	return &Cache{
-		data: make(map[string]string),
+	return NewCacheWithLimits(0, 0)
+}
+
+// NewCacheWithTTL creates a new cache instance whose entries expire after ttl by default.
+// A ttl of zero or less means entries never expire.
+func NewCacheWithTTL(ttl time.Duration) *Cache {
+	return NewCacheWithLimits(ttl, 0)
+}
+
+// NewCacheWithCapacity creates a new cache instance holding at most n entries, evicting the
+// least recently used entry when a new key is stored. An n of zero or less means unbounded.
+func NewCacheWithCapacity(n int) *Cache {
+	return NewCacheWithLimits(0, n)
+}
+
+// NewCacheWithLimits creates a new cache instance with both a default TTL (see NewCacheWithTTL)
+// and a maximum number of entries (see NewCacheWithCapacity)
+func NewCacheWithLimits(ttl time.Duration, capacity int) *Cache {
+	c := &Cache{
+		data: make(map[string]cacheEntry),
+		ttl:  ttl,
+		now:  time.Now,
 	}
+	if capacity > 0 {
+		c.capacity = capacity
+		c.lru = list.New()
+	}
+	return c
+}
+
+// expired reports whether an entry is past its expiry
+func (c *Cache) expired(entry cacheEntry) bool {
+	return !entry.expiresAt.IsZero() && !c.now().Before(entry.expiresAt)
 }
 
-// Get retrieves a value from the cache
+// Get retrieves a value from the cache. Expired entries are reported as a miss.
+// With a capacity, a hit makes the entry the most recently used one.
 func (c *Cache) Get(key string) (string, bool) {
-	c.mutex.RLock()
-	defer c.mutex.RUnlock()
+	if c.lru != nil {
+		// Reordering the LRU list writes, so bounded caches can't share the read lock
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+	} else {
+		c.mutex.RLock()
+		defer c.mutex.RUnlock()
+	}
 
-	value, exists := c.data[key]
+	entry, exists := c.data[key]
+	if exists && c.expired(entry) {
+		debugLog("Cache entry expired for key: %s", hashKey(key))
+		exists = false
+	}
 	if exists {
 		debugLog("Cache hit for key: %s", hashKey(key))
+		if entry.element != nil {
+			c.lru.MoveToFront(entry.element)
+		}
 	} else {
 		debugLog("Cache miss for key: %s", hashKey(key))
 	}
-	return value, exists
+	return entry.value, exists
 }
 
-// Set stores a value in the cache
+// Set stores a value in the cache with the cache's default TTL
 func (c *Cache) Set(key, value string) {
+	c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL stores a value in the cache that expires after ttl.
+// A ttl of zero or less uses the cache's default TTL (which may be "never expires").
+// When a new key exceeds the capacity, the least recently used entry is evicted.
+func (c *Cache) SetWithTTL(key, value string, ttl time.Duration) {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
 
-	c.data[key] = value
-	debugLog("Cached value for key: %s", hashKey(key))
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	entry := cacheEntry{value: value}
+	if ttl > 0 {
+		entry.expiresAt = c.now().Add(ttl)
+	}
+	if c.lru != nil {
+		if existing, exists := c.data[key]; exists {
+			entry.element = existing.element
+			c.lru.MoveToFront(entry.element)
+		} else {
+			entry.element = c.lru.PushFront(key)
+			c.evict()
+		}
+	}
+	c.data[key] = entry
+	file := c.persist
+	c.mutex.Unlock()
+
+	debugLog("Cached value for key: %s (ttl: %v)", hashKey(key), ttl)
+	c.save(file, map[string]cacheEntry{key: entry}, nil) // Logged by save, the value is cached in memory either way
 }
 
-// Clear removes all entries from the cache
+// evict removes least recently used entries until the cache is within its capacity.
+// The caller must hold the write lock.
+func (c *Cache) evict() {
+	for c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		key := c.lru.Remove(oldest).(string)
+		delete(c.data, key)
+		debugLog("Evicted least recently used key: %s", hashKey(key))
+	}
+}
+
+// Clear removes all entries from the cache. A persisted cache also drops its entries from the
+// cache file; entries only other processes cached there are kept.
 func (c *Cache) Clear() {
 	c.mutex.Lock()
-	defer c.mutex.Unlock()
+	removed := slices.Collect(maps.Keys(c.data))
+	c.data = make(map[string]cacheEntry)
+	if c.lru != nil {
+		c.lru.Init()
+	}
+	file := c.persist
+	c.mutex.Unlock()
 
-	c.data = make(map[string]string)
+	c.save(file, nil, removed)
 	debugLog("Cache cleared")
 }
 
-// Size returns the number of cached entries
+// Size returns the number of cached entries that have not expired
 func (c *Cache) Size() int {
 	c.mutex.RLock()
 	defer c.mutex.RUnlock()
-	return len(c.data)
+
+	size := 0
+	for _, entry := range c.data {
+		if !c.expired(entry) {
+			size++
+		}
+	}
+	return size
+}
+
+// SizeByPrefix returns the number of unexpired entries whose key starts with prefix.
+// Backends prefix their keys with their name (e.g. "aws:"), so this counts one backend's entries.
+func (c *Cache) SizeByPrefix(prefix string) int {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	size := 0
+	for key, entry := range c.data {
+		if strings.HasPrefix(key, prefix) && !c.expired(entry) {
+			size++
+		}
+	}
+	return size
+}
+
+// BackendTTL returns the cache lifetime for a backend from SECRETINIT_TTL_<BACKEND> (e.g. SECRETINIT_TTL_GIT=1h).
+// Returns zero (the cache's default TTL) when the variable is unset or not a valid duration.
+func BackendTTL(backendName string) time.Duration {
+	envName := "SECRETINIT_TTL_" + strings.ToUpper(backendName)
+	raw := os.Getenv(envName)
+	if raw == "" {
+		return 0
+	}
+	ttl, err := time.ParseDuration(raw)
+	if err != nil || ttl < 0 {
+		debugLog("Ignoring invalid %s=%q, expected a duration like 5m or 1h", envName, raw)
+		return 0
+	}
+	return ttl
+}
+
+// ShortHash returns the short hash used for cache keys in debug logs, for reports that
+// must tell values apart without exposing them (e.g. --resolve-map)
+func ShortHash(value string) string {
+	return hashKey(value)
 }
 
 // hashKey returns a hash of the key for debug logging (to avoid exposing sensitive data)
@@ -82,6 +233,12 @@ func GetGlobalCache() *Cache {
 	return globalCache
 }
 
+// SetGlobalCache replaces the global cache instance (e.g. with NewCacheWithTTL).
+// It should be called before any backend is used.
+func SetGlobalCache(cache *Cache) {
+	globalCache = cache
+}
+
 // ClearGlobalCache clears the global cache
 func ClearGlobalCache() {
 	globalCache.Clear()
@@ -91,3 +248,8 @@ func ClearGlobalCache() {
 func GetGlobalCacheSize() int {
 	return globalCache.Size()
 }
+
+// GetGlobalCacheSizeByPrefix returns the number of global cache entries whose key starts with prefix
+func GetGlobalCacheSizeByPrefix(prefix string) int {
+	return globalCache.SizeByPrefix(prefix)
+}
@@ -1,7 +1,10 @@
 package backend
 
 import (
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 )
 
 // MockBackend for testing caching behavior without external dependencies
@@ -323,3 +326,205 @@ func TestJSONKeyExtraction_CachedValues(t *testing.T) {
 		})
 	}
 }
+
+func TestBackendTTL(t *testing.T) {
+	t.Setenv("SECRETINIT_TTL_GIT", "1h")
+	t.Setenv("SECRETINIT_TTL_VAULT", "5m")
+	t.Setenv("SECRETINIT_TTL_AWS", "not-a-duration")
+	t.Setenv("SECRETINIT_TTL_GCP", "-1m")
+
+	tests := map[string]time.Duration{
+		"git":   time.Hour,
+		"vault": 5 * time.Minute,
+		"aws":   0,
+		"gcp":   0,
+		"azure": 0,
+	}
+	for backendName, expected := range tests {
+		if got := BackendTTL(backendName); got != expected {
+			t.Errorf("BackendTTL(%q) = %v, want %v", backendName, got, expected)
+		}
+	}
+}
+
+func TestCache_PerBackendTTL(t *testing.T) {
+	t.Setenv("SECRETINIT_TTL_GIT", "1h")
+	t.Setenv("SECRETINIT_TTL_VAULT", "5m")
+
+	cache := NewCache()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.SetWithTTL("git::https://example.com", "git-value", BackendTTL("git"))
+	cache.SetWithTTL("vault:kv:app/db", "vault-value", BackendTTL("vault"))
+	cache.SetWithTTL("aws:sm:app/key", "aws-value", BackendTTL("aws"))
+
+	// Before any TTL elapses, everything is cached
+	if cache.Size() != 3 {
+		t.Fatalf("Expected 3 cached entries, got %d", cache.Size())
+	}
+
+	// After 10 minutes the vault entry has expired but git has not
+	now = now.Add(10 * time.Minute)
+	if _, exists := cache.Get("vault:kv:app/db"); exists {
+		t.Error("Expected vault entry to expire after 5m")
+	}
+	if value, exists := cache.Get("git::https://example.com"); !exists || value != "git-value" {
+		t.Errorf("Expected git entry to still be cached, got exists=%v, value='%s'", exists, value)
+	}
+
+	// After 2 hours the git entry has expired too, while the entry without TTL never expires
+	now = now.Add(2 * time.Hour)
+	if _, exists := cache.Get("git::https://example.com"); exists {
+		t.Error("Expected git entry to expire after 1h")
+	}
+	if value, exists := cache.Get("aws:sm:app/key"); !exists || value != "aws-value" {
+		t.Errorf("Expected entry without TTL to stay cached, got exists=%v, value='%s'", exists, value)
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected 1 unexpired entry, got %d", cache.Size())
+	}
+
+	// Re-setting an expired key caches it again with a fresh TTL
+	cache.SetWithTTL("vault:kv:app/db", "vault-value-2", BackendTTL("vault"))
+	if value, exists := cache.Get("vault:kv:app/db"); !exists || value != "vault-value-2" {
+		t.Errorf("Expected refreshed vault entry, got exists=%v, value='%s'", exists, value)
+	}
+}
+
+func TestNewCacheWithTTL(t *testing.T) {
+	cache := NewCacheWithTTL(time.Minute)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("default", "value")
+	cache.SetWithTTL("longer", "value", time.Hour)
+
+	now = now.Add(59 * time.Second)
+	if _, exists := cache.Get("default"); !exists {
+		t.Error("Expected entry to be cached before the default TTL elapsed")
+	}
+
+	now = now.Add(time.Second)
+	if _, exists := cache.Get("default"); exists {
+		t.Error("Expected entry to expire after the default TTL")
+	}
+	if _, exists := cache.Get("longer"); !exists {
+		t.Error("Expected entry with its own TTL to outlive the default")
+	}
+	if cache.Size() != 1 {
+		t.Errorf("Expected Size() to count only unexpired entries, got %d", cache.Size())
+	}
+}
+
+func TestNewCacheWithTTL_ZeroNeverExpires(t *testing.T) {
+	cache := NewCacheWithTTL(0)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("key", "value")
+	now = now.Add(24 * 365 * time.Hour)
+	if value, exists := cache.Get("key"); !exists || value != "value" {
+		t.Errorf("Expected entry without TTL to never expire, got exists=%v, value='%s'", exists, value)
+	}
+}
+
+func TestCache_SizeByPrefix(t *testing.T) {
+	cache := NewCache()
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	cache.Set("aws:sm:app/key", "value")
+	cache.Set("aws:ps:/app/db", "value")
+	cache.SetWithTTL("aws:kms:alias/app:abc", "value", time.Minute)
+	cache.Set("azure:kv:vault/secret", "value")
+	cache.Set("git::https://example.com", "value")
+
+	if got := cache.SizeByPrefix("aws:"); got != 3 {
+		t.Errorf("SizeByPrefix(aws:) = %d, want 3", got)
+	}
+	if got := cache.SizeByPrefix("azure:"); got != 1 {
+		t.Errorf("SizeByPrefix(azure:) = %d, want 1", got)
+	}
+	if got := cache.SizeByPrefix("gcp:"); got != 0 {
+		t.Errorf("SizeByPrefix(gcp:) = %d, want 0", got)
+	}
+
+	// Expired entries are not counted
+	now = now.Add(time.Hour)
+	if got := cache.SizeByPrefix("aws:"); got != 2 {
+		t.Errorf("SizeByPrefix(aws:) after expiry = %d, want 2", got)
+	}
+}
+
+func TestNewCacheWithCapacity_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := NewCacheWithCapacity(2)
+
+	cache.Set("aws:sm:a", "1")
+	cache.Set("aws:sm:b", "2")
+	// Reading a makes b the least recently used entry
+	if _, exists := cache.Get("aws:sm:a"); !exists {
+		t.Fatal("Expected a to be cached")
+	}
+	cache.Set("aws:sm:c", "3")
+
+	if _, exists := cache.Get("aws:sm:b"); exists {
+		t.Error("Expected the least recently used entry b to be evicted")
+	}
+	for _, key := range []string{"aws:sm:a", "aws:sm:c"} {
+		if _, exists := cache.Get(key); !exists {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+	if cache.Size() != 2 {
+		t.Errorf("Expected 2 entries, got %d", cache.Size())
+	}
+
+	// Overwriting a key refreshes it without evicting anything
+	cache.Set("aws:sm:a", "1b")
+	if value, _ := cache.Get("aws:sm:a"); value != "1b" || cache.Size() != 2 {
+		t.Errorf("Expected overwritten value with 2 entries, got '%s' with %d", value, cache.Size())
+	}
+	cache.Set("aws:sm:d", "4")
+	if _, exists := cache.Get("aws:sm:c"); exists {
+		t.Error("Expected c to be evicted after a was refreshed")
+	}
+
+	cache.Clear()
+	cache.Set("aws:sm:e", "5")
+	cache.Set("aws:sm:f", "6")
+	if cache.Size() != 2 {
+		t.Errorf("Expected a cleared cache to hold its full capacity again, got %d", cache.Size())
+	}
+}
+
+func TestNewCacheWithCapacity_ZeroIsUnbounded(t *testing.T) {
+	cache := NewCacheWithCapacity(0)
+	for i := 0; i < 100; i++ {
+		cache.Set(fmt.Sprintf("key-%d", i), "value")
+	}
+	if cache.Size() != 100 {
+		t.Errorf("Expected 100 entries, got %d", cache.Size())
+	}
+}
+
+func TestNewCacheWithCapacity_Concurrent(t *testing.T) {
+	cache := NewCacheWithCapacity(10)
+	var wg sync.WaitGroup
+	for worker := 0; worker < 8; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				key := fmt.Sprintf("key-%d", (worker*7+i)%25)
+				cache.Set(key, "value")
+				cache.Get(key)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if size := cache.Size(); size > 10 {
+		t.Errorf("Expected at most 10 entries, got %d", size)
+	}
+}
@@ -0,0 +1,260 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/logging"
+)
+
+// cacheFileIterations is the PBKDF2-SHA256 work factor for the cache file key (OWASP recommendation).
+// The key is derived once per run, when the file is opened.
+const cacheFileIterations = 600_000
+
+// cacheFileVersion is the format version of the persisted cache
+const cacheFileVersion = 1
+
+// cacheFileEnvelope is the on-disk format: the entries as JSON, encrypted with AES-256-GCM
+// under a key derived from a passphrase and the salt
+type cacheFileEnvelope struct {
+	Version int    `json:"version"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// persistedEntry is a cache entry as stored in the encrypted payload
+type persistedEntry struct {
+	Value     string    `json:"value"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// cacheFile writes a cache's entries to an encrypted file
+type cacheFile struct {
+	path   string
+	salt   []byte
+	aead   cipher.AEAD
+	maxAge time.Duration // Lifetime given to entries that would otherwise never expire
+}
+
+// ErrCacheFileDecrypt is returned by PersistTo when the cache file can't be decrypted, e.g. after
+// the passphrase changed. The cache then stays in memory only and leaves the file untouched.
+var ErrCacheFileDecrypt = errors.New("cache file could not be decrypted")
+
+// ErrCacheFileLocked is returned by PersistTo when another process held the cache file's lock for
+// longer than cacheLockTimeout. The cache then stays in memory only.
+var ErrCacheFileLocked = errors.New("cache file is locked by another process")
+
+// cacheLockTimeout is how long reading or writing the cache file waits for another process holding
+// its lock. After that the cache stops persisting and keeps working in memory only.
+var cacheLockTimeout = 5 * time.Second
+
+// PersistTo loads the unexpired entries of the encrypted cache file at path and writes every later
+// change back to it, so repeated runs don't hit the backends again. The file is encrypted with a
+// key derived from passphrase and is only ever readable by its owner (0600). Reads and writes hold
+// the file's lock (see updateFileLocked) and writes merge with the entries on disk, so concurrent
+// runs sharing the file keep each other's entries. A lock held for longer than cacheLockTimeout
+// turns persisting off rather than blocking the run.
+//
+// Entries keep their TTL on disk; entries that would never expire are persisted for maxAge, so a
+// stale secret is never served from disk indefinitely. A maxAge of zero or less is an error.
+func (c *Cache) PersistTo(path, passphrase string, maxAge time.Duration) error {
+	if passphrase == "" {
+		return errors.New("an encrypted cache file needs a passphrase")
+	}
+	if maxAge <= 0 {
+		return errors.New("an encrypted cache file needs a maximum entry age")
+	}
+
+	// A missing file is created with its salt under the lock, so concurrent first runs derive the
+	// same key instead of replacing each other's file
+	var raw []byte
+	err := updateFileLocked(path, 0600, cacheLockTimeout, func(existing []byte) ([]byte, error) {
+		if existing != nil {
+			raw = existing
+			return nil, nil
+		}
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, fmt.Errorf("failed to generate cache file salt: %w", err)
+		}
+		var err error
+		raw, err = json.Marshal(cacheFileEnvelope{Version: cacheFileVersion, Salt: salt})
+		return raw, err
+	})
+	if errors.Is(err, errLockBusy) {
+		return fmt.Errorf("%w: %v", ErrCacheFileLocked, err)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cache file %s: %w", path, err)
+	}
+	var envelope cacheFileEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil || envelope.Version != cacheFileVersion || len(envelope.Salt) == 0 {
+		return fmt.Errorf("%w: %s is not a secretinit cache file", ErrCacheFileDecrypt, path)
+	}
+
+	aead, err := cacheFileCipher(passphrase, envelope.Salt)
+	if err != nil {
+		return err
+	}
+	file := &cacheFile{path: path, salt: envelope.Salt, aead: aead, maxAge: maxAge}
+
+	entries, err := file.open(envelope)
+	if err != nil {
+		return fmt.Errorf("%w: %s (%v)", ErrCacheFileDecrypt, path, err)
+	}
+
+	c.mutex.Lock()
+	loaded := 0
+	for key, persisted := range entries {
+		entry := cacheEntry{value: persisted.Value, expiresAt: persisted.ExpiresAt}
+		if entry.expiresAt.IsZero() || c.expired(entry) {
+			continue
+		}
+		if _, exists := c.data[key]; exists {
+			continue // Values set in this run are newer
+		}
+		if c.lru != nil {
+			entry.element = c.lru.PushBack(key)
+		}
+		c.data[key] = entry
+		loaded++
+	}
+	if c.lru != nil {
+		c.evict()
+	}
+	c.persist = file
+	snapshot := make(map[string]cacheEntry, len(c.data))
+	maps.Copy(snapshot, c.data)
+	c.mutex.Unlock()
+
+	debugLog("Loaded %d cache entries from %s", loaded, path)
+	c.save(file, snapshot, nil)
+	return nil
+}
+
+// cacheFileCipher derives the AES-256-GCM cipher for a cache file from passphrase and salt
+func cacheFileCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, cacheFileIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive cache file key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// save writes entries to file and drops the removed keys from it, keeping the entries other runs
+// wrote. The caller must not hold the cache's lock, so Get and Set don't wait for other processes.
+// Failures are logged: the in-memory cache still works. When another process holds the file's lock
+// for longer than cacheLockTimeout, the cache stops persisting for the rest of the run.
+func (c *Cache) save(file *cacheFile, entries map[string]cacheEntry, removed []string) {
+	if file == nil {
+		return
+	}
+	err := file.write(entries, removed, c.now())
+	if errors.Is(err, errLockBusy) {
+		logging.Printf(logging.LevelWarn, "Warning: %v; caching in memory only", err)
+		c.mutex.Lock()
+		if c.persist == file {
+			c.persist = nil
+		}
+		c.mutex.Unlock()
+		return
+	}
+	if err != nil {
+		debugLog("Failed to write cache file %s: %v", file.path, err)
+	}
+}
+
+// open decrypts the entries of an envelope sealed with this file's key
+func (f *cacheFile) open(envelope cacheFileEnvelope) (map[string]persistedEntry, error) {
+	var entries map[string]persistedEntry
+	if envelope.Data == nil {
+		return entries, nil
+	}
+	plaintext, err := f.aead.Open(nil, envelope.Nonce, envelope.Data, nil)
+	if err != nil {
+		return nil, errors.New("wrong passphrase?")
+	}
+	if err := json.Unmarshal(plaintext, &entries); err != nil {
+		return nil, errors.New("invalid payload")
+	}
+	return entries, nil
+}
+
+// write merges data into the cache file while holding its lock: the unexpired entries already in
+// the file are kept unless data has the same key or it is in removed.
+func (f *cacheFile) write(data map[string]cacheEntry, removed []string, now time.Time) error {
+	return updateFileLocked(f.path, 0600, cacheLockTimeout, func(existing []byte) ([]byte, error) {
+		entries := make(map[string]persistedEntry, len(data))
+		if existing != nil {
+			for key, persisted := range f.readEntries(existing) {
+				if now.Before(persisted.ExpiresAt) {
+					entries[key] = persisted
+				}
+			}
+		}
+		for _, key := range removed {
+			delete(entries, key)
+		}
+		for key, entry := range data {
+			expiresAt := entry.expiresAt
+			if expiresAt.IsZero() {
+				expiresAt = now.Add(f.maxAge)
+			}
+			if now.Before(expiresAt) {
+				entries[key] = persistedEntry{Value: entry.value, ExpiresAt: expiresAt}
+			} else {
+				delete(entries, key)
+			}
+		}
+		return f.seal(entries)
+	})
+}
+
+// readEntries returns the entries of the cache file content raw. A file sealed under another
+// salt or passphrase, e.g. one a concurrent first run created, has no entries to keep.
+func (f *cacheFile) readEntries(raw []byte) map[string]persistedEntry {
+	var envelope cacheFileEnvelope
+	if err := json.Unmarshal(raw, &envelope); err != nil || envelope.Version != cacheFileVersion || !bytes.Equal(envelope.Salt, f.salt) {
+		debugLog("Replacing cache file %s written with another key", f.path)
+		return nil
+	}
+	entries, err := f.open(envelope)
+	if err != nil {
+		debugLog("Replacing unreadable cache file %s: %v", f.path, err)
+		return nil
+	}
+	return entries
+}
+
+// seal encrypts entries into the cache file format
+func (f *cacheFile) seal(entries map[string]persistedEntry) ([]byte, error) {
+	plaintext, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, f.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(cacheFileEnvelope{
+		Version: cacheFileVersion,
+		Salt:    f.salt,
+		Nonce:   nonce,
+		Data:    f.aead.Seal(nil, nonce, plaintext, nil),
+	})
+}
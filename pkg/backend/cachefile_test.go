@@ -0,0 +1,259 @@
+package backend
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCache_PersistTo_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+
+	first := NewCache()
+	if err := first.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatalf("PersistTo() error = %v", err)
+	}
+	first.Set("aws:sm:myapp/db", "s3cret-value")
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cache file not written: %v", err)
+	}
+	if bytes.Contains(raw, []byte("s3cret-value")) || bytes.Contains(raw, []byte("myapp/db")) {
+		t.Error("cache file holds plaintext")
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+		}
+	}
+
+	second := NewCache()
+	if err := second.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatalf("PersistTo() error = %v", err)
+	}
+	if value, ok := second.Get("aws:sm:myapp/db"); !ok || value != "s3cret-value" {
+		t.Errorf("Get() = %q, %v, want the persisted value", value, ok)
+	}
+}
+
+func TestCache_PersistTo_WrongPassphrase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	first := NewCache()
+	if err := first.PersistTo(path, "right", time.Hour); err != nil {
+		t.Fatalf("PersistTo() error = %v", err)
+	}
+	first.Set("git:https://example.com", "password=x")
+	before, _ := os.ReadFile(path)
+
+	second := NewCache()
+	err := second.PersistTo(path, "wrong", time.Hour)
+	if !errors.Is(err, ErrCacheFileDecrypt) {
+		t.Fatalf("PersistTo() error = %v, want ErrCacheFileDecrypt", err)
+	}
+
+	// The cache keeps working in memory and leaves the file alone
+	second.Set("git:https://other.example.com", "password=y")
+	if second.Size() != 1 {
+		t.Errorf("expected only the in-memory entry, got %d entries", second.Size())
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(before, after) {
+		t.Error("cache file was rewritten after a failed decryption")
+	}
+}
+
+func TestCache_PersistTo_Expiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	now := time.Now()
+
+	first := NewCache()
+	first.now = func() time.Time { return now }
+	if err := first.PersistTo(path, "passphrase", 10*time.Minute); err != nil {
+		t.Fatalf("PersistTo() error = %v", err)
+	}
+	first.Set("no-ttl", "a")                        // Persisted for the maximum age
+	first.SetWithTTL("short-ttl", "b", time.Minute) // Keeps its own TTL on disk
+
+	tests := []struct {
+		after time.Duration
+		want  []string
+	}{
+		{30 * time.Second, []string{"no-ttl", "short-ttl"}},
+		{5 * time.Minute, []string{"no-ttl"}},
+		{11 * time.Minute, nil},
+	}
+	for _, tt := range tests {
+		// Load a copy, since loading rewrites the file without the expired entries
+		later := NewCache()
+		later.now = func() time.Time { return now.Add(tt.after) }
+		raw, _ := os.ReadFile(path)
+		copyPath := filepath.Join(t.TempDir(), "cache")
+		if err := os.WriteFile(copyPath, raw, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := later.PersistTo(copyPath, "passphrase", 10*time.Minute); err != nil {
+			t.Fatalf("PersistTo() error = %v", err)
+		}
+		if later.Size() != len(tt.want) {
+			t.Errorf("after %v: got %d entries, want %v", tt.after, later.Size(), tt.want)
+		}
+		for _, key := range tt.want {
+			if _, ok := later.Get(key); !ok {
+				t.Errorf("after %v: %s was not loaded", tt.after, key)
+			}
+		}
+	}
+}
+
+func TestCache_PersistTo_Clear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	first := NewCache()
+	if err := first.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	first.Set("aws:sm:myapp/db", "value")
+	first.Clear()
+
+	second := NewCache()
+	if err := second.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if second.Size() != 0 {
+		t.Errorf("Clear() should forget persisted entries, got %d", second.Size())
+	}
+}
+
+func TestCache_PersistTo_ClearKeepsOtherEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	first, second := NewCache(), NewCache()
+	for _, cache := range []*Cache{first, second} {
+		if err := cache.PersistTo(path, "passphrase", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	first.Set("aws:sm:other/db", "other")
+	second.Set("aws:sm:myapp/db", "value")
+	second.Clear() // e.g. a reload
+
+	loaded := NewCache()
+	if err := loaded.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loaded.Get("aws:sm:myapp/db"); ok {
+		t.Error("Clear() should drop its own entries from the file")
+	}
+	if value, ok := loaded.Get("aws:sm:other/db"); !ok || value != "other" {
+		t.Errorf("Clear() should keep the other run's entry, got %q, %v", value, ok)
+	}
+}
+
+func TestCache_PersistTo_LockTimeout(t *testing.T) {
+	oldTimeout := cacheLockTimeout
+	cacheLockTimeout = 200 * time.Millisecond
+	defer func() { cacheLockTimeout = oldTimeout }()
+
+	path := filepath.Join(t.TempDir(), "cache")
+	cache := NewCache()
+	if err := cache.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	cache.Set("aws:sm:cached", "cached")
+
+	lock, err := lockFile(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		cache.Set("aws:sm:myapp/db", "value")
+		close(done)
+	}()
+
+	// Waiting for the file lock must not block other lookups
+	time.Sleep(50 * time.Millisecond)
+	start := time.Now()
+	if value, ok := cache.Get("aws:sm:cached"); !ok || value != "cached" {
+		t.Errorf("Get() = %q, %v, want the cached value", value, ok)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("Get() took %v while Set() waited for the file lock", elapsed)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Set() did not give up on the file lock")
+	}
+	if value, ok := cache.Get("aws:sm:myapp/db"); !ok || value != "value" {
+		t.Errorf("Get() = %q, %v, want the value cached in memory", value, ok)
+	}
+	cache.mutex.RLock()
+	persist := cache.persist
+	cache.mutex.RUnlock()
+	if persist != nil {
+		t.Error("Expected the cache to stop persisting after the lock timeout")
+	}
+
+	// A new run waiting on the same lock starts with an in-memory cache
+	if err := NewCache().PersistTo(path, "passphrase", time.Hour); !errors.Is(err, ErrCacheFileLocked) {
+		t.Errorf("PersistTo() error = %v, want ErrCacheFileLocked", err)
+	}
+}
+
+func TestCache_PersistTo_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	if err := NewCache().PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every run loads the file before any of them writes, so none has the others' entries in memory
+	const writers = 4
+	caches := make([]*Cache, writers)
+	for i := range caches {
+		caches[i] = NewCache()
+		if err := caches[i].PersistTo(path, "passphrase", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, cache := range caches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				cache.Set(fmt.Sprintf("writer%d:key%d", i, j), "value")
+			}
+		}()
+	}
+	wg.Wait()
+
+	loaded := NewCache()
+	if err := loaded.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Size() != writers*5 {
+		t.Errorf("expected %d persisted entries, got %d", writers*5, loaded.Size())
+	}
+}
+
+func TestCache_PersistTo_RequiresPassphraseAndMaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	if err := NewCache().PersistTo(path, "", time.Hour); err == nil {
+		t.Error("expected an error without a passphrase")
+	}
+	if err := NewCache().PersistTo(path, "passphrase", 0); err == nil {
+		t.Error("expected an error without a maximum age")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("no cache file should be written without encryption settings")
+	}
+}
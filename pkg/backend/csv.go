@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CSVBackend implements the Backend interface for flat credential tables in CSV or TSV files.
+//
+// The first line of the file is a header naming the columns, and the first column is the row key.
+// Files ending in .tsv are tab separated, all others comma separated.
+type CSVBackend struct{}
+
+// NewCSVBackend creates a new CSVBackend.
+func NewCSVBackend() (*CSVBackend, error) {
+	return &CSVBackend{}, nil
+}
+
+// RetrieveSecret looks up a row of a CSV file by its key.
+// The service parameter is empty for csv.
+// The resource has the format "/path/to/file.csv:ROWKEY", split at the last colon.
+// The keyPath names the column to return; without one the whole row is returned as a JSON object.
+func (b *CSVBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	path, rowKey, err := parseCSVResource(resource)
+	if err != nil {
+		return "", err
+	}
+
+	// Cache the whole file by path, so every row and column reads it once
+	cache := GetGlobalCache()
+	cacheKey := fmt.Sprintf("csv:%s", path)
+	content, exists := cache.Get(cacheKey)
+	if !exists {
+		debugLog("CSV backend: reading %s", path)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to read CSV file: %w", err)
+		}
+		content = string(data)
+		cache.SetWithTTL(cacheKey, content, BackendTTL("csv"))
+	}
+
+	header, row, err := findCSVRow(strings.NewReader(content), csvSeparator(path), rowKey)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", path, err)
+	}
+
+	if keyPath == "" {
+		fields := make(map[string]string, len(header))
+		for i, column := range header {
+			fields[column] = row[i]
+		}
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode CSV row as JSON: %w", err)
+		}
+		return string(data), nil
+	}
+
+	for i, column := range header {
+		if column == keyPath {
+			return row[i], nil
+		}
+	}
+	return "", notFound(fmt.Errorf("column '%s' not found in %s (columns: %s)", keyPath, path, strings.Join(header, ", ")))
+}
+
+// parseCSVResource splits "PATH:ROWKEY" at the last colon, so Windows drive letters stay in the path
+func parseCSVResource(resource string) (path, rowKey string, err error) {
+	idx := strings.LastIndex(resource, ":")
+	if idx <= 0 || idx == len(resource)-1 {
+		return "", "", fmt.Errorf("invalid csv resource '%s': expected '/path/to/file.csv:ROWKEY'", resource)
+	}
+	return resource[:idx], resource[idx+1:], nil
+}
+
+// csvSeparator returns the field separator for path: tabs for .tsv files, commas otherwise
+func csvSeparator(path string) rune {
+	if strings.EqualFold(filepath.Ext(path), ".tsv") {
+		return '\t'
+	}
+	return ','
+}
+
+// findCSVRow reads the header and returns it with the first row whose first field is rowKey
+func findCSVRow(r io.Reader, separator rune, rowKey string) ([]string, []string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = separator
+	reader.TrimLeadingSpace = separator != '\t' // Tabs would swallow empty TSV fields
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("file is empty, expected a header line")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse CSV header: %w", err)
+	}
+
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return nil, nil, notFound(fmt.Errorf("row '%s' not found", rowKey))
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if row[0] == rowKey {
+			return header, row, nil
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const csvFixture = `name,username,password,notes
+prod-db,admin,"p,ss ""quoted""",primary
+staging-db, deploy,s3cret,"multi
+line"
+`
+
+func writeCSVFixture(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	return path
+}
+
+func TestCSVBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	csvPath := writeCSVFixture(t, "creds.csv", csvFixture)
+	tsvPath := writeCSVFixture(t, "creds.tsv", "name\tpassword\tnotes\napi\tt0ken\t\n")
+
+	tests := []struct {
+		name     string
+		resource string
+		keyPath  string
+		want     string
+	}{
+		{name: "quoted field", resource: csvPath + ":prod-db", keyPath: "password", want: `p,ss "quoted"`},
+		{name: "leading space trimmed", resource: csvPath + ":staging-db", keyPath: "username", want: "deploy"},
+		{name: "multiline field", resource: csvPath + ":staging-db", keyPath: "notes", want: "multi\nline"},
+		{name: "whole row as JSON", resource: csvPath + ":prod-db", want: `{"name":"prod-db","notes":"primary","password":"p,ss \"quoted\"","username":"admin"}`},
+		{name: "tsv", resource: tsvPath + ":api", keyPath: "password", want: "t0ken"},
+	}
+
+	b := &CSVBackend{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.RetrieveSecret("", tt.resource, tt.keyPath)
+			if err != nil {
+				t.Fatalf("RetrieveSecret() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RetrieveSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVBackend_RetrieveSecret_Errors(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	path := writeCSVFixture(t, "creds.csv", csvFixture)
+
+	tests := []struct {
+		name        string
+		resource    string
+		keyPath     string
+		errContains string
+		notFound    bool
+	}{
+		{name: "missing row", resource: path + ":dev-db", keyPath: "password", errContains: "row 'dev-db' not found", notFound: true},
+		{name: "missing column", resource: path + ":prod-db", keyPath: "token", errContains: "column 'token' not found", notFound: true},
+		{name: "missing file", resource: path + ".missing:prod-db", keyPath: "password", errContains: "failed to read CSV file"},
+		{name: "no row key", resource: path, keyPath: "password", errContains: "invalid csv resource"},
+	}
+
+	b := &CSVBackend{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := b.RetrieveSecret("", tt.resource, tt.keyPath)
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+			}
+			if errors.Is(err, ErrSecretNotFound) != tt.notFound {
+				t.Errorf("errors.Is(%v, ErrSecretNotFound) = %v, want %v", err, !tt.notFound, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestCSVBackend_CachesFileByPath(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	path := writeCSVFixture(t, "creds.csv", csvFixture)
+	b := &CSVBackend{}
+	if _, err := b.RetrieveSecret("", path+":prod-db", "password"); err != nil {
+		t.Fatalf("RetrieveSecret() error = %v", err)
+	}
+
+	// Later lookups in the same run read the cached file
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove fixture: %v", err)
+	}
+	got, err := b.RetrieveSecret("", path+":staging-db", "password")
+	if err != nil || got != "s3cret" {
+		t.Errorf("RetrieveSecret() after removing the file = %q, %v, want cached s3cret", got, err)
+	}
+}
@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// ExecBackend implements the Backend interface by running an external helper executable,
+// so secret stores without a built-in backend can be plugged in without recompiling.
+//
+// The helper is started as "HELPER NAME" with NAME also written to its stdin (followed by a newline),
+// so it can read whichever is more convenient. It must print the secret on stdout and exit 0;
+// a non-zero exit is reported with the helper's stderr.
+type ExecBackend struct{}
+
+// NewExecBackend creates a new ExecBackend.
+func NewExecBackend() (*ExecBackend, error) {
+	return &ExecBackend{}, nil
+}
+
+// RetrieveSecret retrieves a secret from an external helper.
+// The service parameter is empty for exec.
+// The resource has the format "/path/to/helper[ NAME]", e.g. "/usr/local/bin/vault-helper db/password".
+// The keyPath is optional and used for JSON key extraction from the helper's output.
+func (b *ExecBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context; the helper is killed when it expires
+func (b *ExecBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	cache := GetGlobalCache()
+
+	// Cache the helper output by helper and name, keyPath is only field selection
+	cacheKey := fmt.Sprintf("exec:%s", resource)
+
+	var output string
+	if cached, exists := cache.Get(cacheKey); exists {
+		output = cached
+	} else {
+		helper, name := parseExecResource(resource)
+		if helper == "" {
+			return "", fmt.Errorf("invalid exec resource '%s': expected '/path/to/helper[ NAME]'", resource)
+		}
+
+		var err error
+		output, err = runExecHelper(ctx, helper, name)
+		if err != nil {
+			return "", err
+		}
+		cache.SetWithTTL(cacheKey, output, BackendTTL("exec"))
+	}
+
+	if keyPath == "" {
+		return output, nil
+	}
+	return extractJSONKey(output, keyPath)
+}
+
+// parseExecResource splits "HELPER NAME" at the first space; the name may itself contain spaces
+func parseExecResource(resource string) (helper, name string) {
+	helper, name, _ = strings.Cut(strings.TrimSpace(resource), " ")
+	return helper, strings.TrimSpace(name)
+}
+
+// runExecHelper runs helper with name as its argument and on stdin, returning stdout without the trailing newline
+func runExecHelper(ctx context.Context, helper, name string) (string, error) {
+	debugLog("Exec backend: running helper %s", helper)
+
+	var args []string
+	if name != "" {
+		args = append(args, name)
+	}
+	cmd := exec.CommandContext(ctx, helper, args...)
+	cmd.Stdin = strings.NewReader(name + "\n")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("exec helper '%s' interrupted: %w", helper, ctx.Err())
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if message := strings.TrimSpace(stderr.String()); message != "" {
+				return "", fmt.Errorf("exec helper '%s' failed: %w: %s", helper, err, message)
+			}
+			return "", fmt.Errorf("exec helper '%s' failed: %w", helper, err)
+		}
+		return "", fmt.Errorf("failed to run exec helper '%s': %w", helper, err)
+	}
+
+	value := strings.TrimSuffix(string(output), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	return value, nil
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExecBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	helper := writeFakeSSH(t, `read name
+echo "arg=$1 stdin=$name" >> `+callsFile+`
+echo '{"username":"admin","password":"s3cret"}'
+`)
+	b, _ := NewExecBackend()
+
+	for _, keyPath := range []string{"password", "username", ""} {
+		value, err := b.RetrieveSecret("", helper+" db/creds", keyPath)
+		if err != nil {
+			t.Fatalf("keyPath %q: unexpected error: %v", keyPath, err)
+		}
+		expected := map[string]string{"password": "s3cret", "username": "admin", "": `{"username":"admin","password":"s3cret"}`}[keyPath]
+		if value != expected {
+			t.Errorf("keyPath %q: expected %q, got %q", keyPath, expected, value)
+		}
+	}
+
+	// The helper gets the name as its argument and on stdin, and runs once per resource
+	calls, err := os.ReadFile(callsFile)
+	if err != nil {
+		t.Fatalf("failed to read calls: %v", err)
+	}
+	if got := strings.TrimSpace(string(calls)); got != "arg=db/creds stdin=db/creds" {
+		t.Errorf("Expected one helper call with the name, got %q", got)
+	}
+}
+
+func TestExecBackend_HelperFailure(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	helper := writeFakeSSH(t, `echo "permission denied for $1" >&2
+exit 3
+`)
+	b, _ := NewExecBackend()
+
+	_, err := b.RetrieveSecret("", helper+" db/creds", "")
+	if err == nil {
+		t.Fatal("Expected an error for a failing helper")
+	}
+	if !strings.Contains(err.Error(), "permission denied for db/creds") || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Expected the exit status and stderr in the error, got %v", err)
+	}
+
+	if _, err := b.RetrieveSecret("", filepath.Join(t.TempDir(), "missing-helper"), ""); err == nil {
+		t.Error("Expected an error for a missing helper")
+	}
+}
@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileBackend implements the Backend interface for secrets mounted as files, such as
+// Kubernetes projected service account tokens and secret volumes.
+type FileBackend struct{}
+
+// NewFileBackend creates a new FileBackend.
+func NewFileBackend() (*FileBackend, error) {
+	return &FileBackend{}, nil
+}
+
+// RetrieveSecret reads a secret from a file.
+// The service parameter is empty for file.
+// The resource is the file path, e.g. "/var/run/secrets/kubernetes.io/serviceaccount/token".
+// The keyPath is optional and used for JSON key extraction from the file contents.
+// A single trailing newline is dropped, as files written by editors and echo usually end in one.
+func (b *FileBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	path, err := filepath.Abs(resource)
+	if err != nil {
+		return "", fmt.Errorf("invalid file path '%s': %w", resource, err)
+	}
+
+	// Cache the contents by absolute path, keyPath is only field selection
+	cache := GetGlobalCache()
+	cacheKey := fmt.Sprintf("file:%s", path)
+	content, exists := cache.Get(cacheKey)
+	if !exists {
+		debugLog("File backend: reading %s", path)
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return "", notFound(fmt.Errorf("secret file %s does not exist", path))
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read secret file: %w", err)
+		}
+		content = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+		cache.SetWithTTL(cacheKey, content, BackendTTL("file"))
+	}
+
+	if keyPath == "" {
+		return content, nil
+	}
+	return extractJSONKey(content, keyPath)
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	credsPath := filepath.Join(dir, "creds.json")
+	if err := os.WriteFile(tokenPath, []byte("eyJhbGciOi.token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(credsPath, []byte(`{"username":"app","password":"s3cret"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		resource string
+		keyPath  string
+		want     string
+	}{
+		{name: "whole file without trailing newline", resource: tokenPath, want: "eyJhbGciOi.token"},
+		{name: "JSON field", resource: credsPath, keyPath: "password", want: "s3cret"},
+	}
+
+	b := &FileBackend{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.RetrieveSecret("", tt.resource, tt.keyPath)
+			if err != nil {
+				t.Fatalf("RetrieveSecret() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("RetrieveSecret() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// The file is cached by absolute path, so a relative path shares the entry
+	if err := os.Remove(credsPath); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	if got, err := b.RetrieveSecret("", "creds.json", "username"); err != nil || got != "app" {
+		t.Errorf("RetrieveSecret(creds.json) = %q, %v, want cached app", got, err)
+	}
+}
+
+func TestFileBackend_RetrieveSecret_Missing(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	_, err := (&FileBackend{}).RetrieveSecret("", filepath.Join(t.TempDir(), "missing"), "")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+	if !errors.Is(err, ErrSecretNotFound) {
+		t.Errorf("expected ErrSecretNotFound, got %v", err)
+	}
+}
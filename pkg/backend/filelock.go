@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// errLockBusy is returned by tryLock implementations when another process holds the lock
+var errLockBusy = errors.New("lock is held by another process")
+
+// lockPollInterval is how often lockFile retries a busy lock
+const lockPollInterval = 20 * time.Millisecond
+
+// fileLock is an advisory, exclusive lock held on a ".lock" file next to the protected file.
+// A separate lock file is used so the protected file can be replaced atomically via rename.
+type fileLock struct {
+	file *os.File
+}
+
+// lockFile acquires an exclusive advisory lock for path, waiting up to timeout.
+// A zero timeout waits indefinitely.
+func lockFile(path string, timeout time.Duration) (*fileLock, error) {
+	lockPath := path + ".lock"
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file %s: %w", lockPath, err)
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		err := tryLock(file)
+		if err == nil {
+			return &fileLock{file: file}, nil
+		}
+		if !errors.Is(err, errLockBusy) {
+			file.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			file.Close()
+			return nil, fmt.Errorf("timed out after %s waiting for lock on %s: %w", timeout, lockPath, errLockBusy)
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
+
+// Unlock releases the lock. The lock file itself is left in place for other processes.
+func (l *fileLock) Unlock() error {
+	if err := unlock(l.file); err != nil {
+		l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
+
+// updateFileLocked performs a read-modify-write of path while holding its lock, so concurrent
+// processes neither corrupt the file nor lose each other's updates. The new content is written
+// to a temporary file and renamed into place with the given permissions; a nil result leaves the
+// file unchanged. The lock is waited for up to timeout (see lockFile).
+func updateFileLocked(path string, perm os.FileMode, timeout time.Duration, update func(data []byte) ([]byte, error)) error {
+	lock, err := lockFile(path, timeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	newData, err := update(data)
+	if err != nil || newData == nil {
+		return err
+	}
+
+	return writeFileAtomic(path, newData, perm)
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUpdateFileLocked_ConcurrentWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+	const writers = 25
+
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- updateFileLocked(path, 0600, 0, func(data []byte) ([]byte, error) {
+				entries := make(map[string]string)
+				if len(data) > 0 {
+					if err := json.Unmarshal(data, &entries); err != nil {
+						return nil, fmt.Errorf("file corrupted: %w", err)
+					}
+				}
+				entries[fmt.Sprintf("key-%d", i)] = strings.Repeat("x", 1024)
+				return json.Marshal(entries)
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Unexpected error from writer: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	entries := make(map[string]string)
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatalf("Expected valid JSON after concurrent writes: %v", err)
+	}
+	if len(entries) != writers {
+		t.Errorf("Expected %d entries (no lost updates), got %d", writers, len(entries))
+	}
+}
+
+func TestLockFile_Timeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.json")
+
+	lock, err := lockFile(path, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer lock.Unlock()
+
+	if _, err := lockFile(path, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error while lock is held, got: %v", err)
+	}
+}
+
+func TestUpdateFileLocked_NilLeavesFileUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state")
+	if err := updateFileLocked(path, 0600, 0, func(data []byte) ([]byte, error) { return nil, nil }); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Expected no file to be created, got: %v", err)
+	}
+}
+
+func TestCache_PersistTo_WaitsForLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	cache := NewCache()
+	if err := cache.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	lock, err := lockFile(path, time.Second)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	done := make(chan struct{})
+	go func() {
+		cache.Set("aws:sm:myapp/db", "value")
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Expected the cache file write to wait while the lock is held")
+	case <-time.After(100 * time.Millisecond):
+	}
+	lock.Unlock()
+	<-done
+
+	loaded := NewCache()
+	if err := loaded.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value, ok := loaded.Get("aws:sm:myapp/db"); !ok || value != "value" {
+		t.Errorf("Expected the entry written after the lock was released, got %q, %v", value, ok)
+	}
+}
+
+// TestCacheWriterHelper persists a cache to SECRETINIT_TEST_CACHE_FILE and sets a few entries.
+// It is started as a separate process by TestCache_PersistTo_ConcurrentProcesses.
+func TestCacheWriterHelper(t *testing.T) {
+	path := os.Getenv("SECRETINIT_TEST_CACHE_FILE")
+	if path == "" {
+		t.Skip("helper process for TestCache_PersistTo_ConcurrentProcesses")
+	}
+	cache := NewCache()
+	if err := cache.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	for j := 0; j < 5; j++ {
+		cache.Set(fmt.Sprintf("%s:key%d", os.Getenv("SECRETINIT_TEST_WRITER"), j), strings.Repeat("x", 1024))
+	}
+}
+
+func TestCache_PersistTo_ConcurrentProcesses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache")
+	const writers = 4
+
+	cmds := make([]*exec.Cmd, writers)
+	for i := range cmds {
+		cmds[i] = exec.Command(os.Args[0], "-test.run=^TestCacheWriterHelper$")
+		cmds[i].Env = append(os.Environ(), "SECRETINIT_TEST_CACHE_FILE="+path, fmt.Sprintf("SECRETINIT_TEST_WRITER=writer%d", i))
+		if err := cmds[i].Start(); err != nil {
+			t.Fatalf("failed to start writer: %v", err)
+		}
+	}
+	for _, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("writer failed: %v", err)
+		}
+	}
+
+	loaded := NewCache()
+	if err := loaded.PersistTo(path, "passphrase", time.Hour); err != nil {
+		t.Fatalf("Expected a valid cache file after concurrent writers: %v", err)
+	}
+	if loaded.Size() != writers*5 {
+		t.Errorf("Expected %d entries (no lost updates), got %d", writers*5, loaded.Size())
+	}
+}
@@ -2,12 +2,16 @@ package backend
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	secretmanager "cloud.google.com/go/secretmanager/apiv1"
 	"cloud.google.com/go/secretmanager/apiv1/secretmanagerpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // GCPBackend implements the Backend interface for Google Cloud Platform services.
@@ -35,19 +39,32 @@ func NewGCPBackend() (*GCPBackend, error) {
 // - For Secret Manager: "projects/PROJECT_ID/secrets/SECRET_NAME/versions/VERSION" or "PROJECT_ID/SECRET_NAME" or "SECRET_NAME" (uses default project)
 // The keyPath is optional and used for JSON key extraction from the secret value.
 func (b *GCPBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context that bounds the GCP API calls
+func (b *GCPBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
 	switch service {
 	case "sm":
-		return b.retrieveFromSecretManager(resource, keyPath)
+		return b.retrieveFromSecretManager(ctx, resource, keyPath)
 	default:
 		return "", fmt.Errorf("unsupported GCP service '%s'. Supported services: 'sm' (Secret Manager)", service)
 	}
 }
 
+// labelsKey is the pseudo-key that returns a secret's labels as a JSON object instead of its
+// payload (":::_labels"). A key after it ("_labels.team") picks a single label.
+const labelsKey = "_labels"
+
 // retrieveFromSecretManager retrieves a secret from GCP Secret Manager.
-func (b *GCPBackend) retrieveFromSecretManager(resource, keyPath string) (string, error) {
+func (b *GCPBackend) retrieveFromSecretManager(ctx context.Context, resource, keyPath string) (string, error) {
 	// Normalize the resource name to full path format
 	secretName := b.normalizeSecretName(resource)
 
+	if keyPath == labelsKey || strings.HasPrefix(keyPath, labelsKey+".") {
+		return b.retrieveSecretLabels(ctx, resource, secretName, strings.TrimPrefix(strings.TrimPrefix(keyPath, labelsKey), "."))
+	}
+
 	// Create cache key without keyPath
 	cacheKey := fmt.Sprintf("gcp:sm:%s", secretName)
 
@@ -62,15 +79,17 @@ func (b *GCPBackend) retrieveFromSecretManager(resource, keyPath string) (string
 	}
 
 	// Cache miss - retrieve from GCP Secret Manager
-	ctx := context.Background()
-
 	req := &secretmanagerpb.AccessSecretVersionRequest{
 		Name: secretName,
 	}
 
 	result, err := b.client.AccessSecretVersion(ctx, req)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve secret from GCP Secret Manager for resource '%s': %w", resource, err)
+		err = fmt.Errorf("failed to retrieve secret from GCP Secret Manager for resource '%s': %w", resource, err)
+		if status.Code(err) == codes.NotFound {
+			return "", notFound(err)
+		}
+		return "", err
 	}
 
 	if result.Payload == nil || result.Payload.Data == nil {
@@ -79,7 +98,7 @@ func (b *GCPBackend) retrieveFromSecretManager(resource, keyPath string) (string
 
 	// Store raw secret value in cache
 	secretValue := string(result.Payload.Data)
-	cache.Set(cacheKey, secretValue)
+	cache.SetWithTTL(cacheKey, secretValue, BackendTTL("gcp"))
 
 	// Parse keyPath from the raw secret value
 	if keyPath == "" {
@@ -89,24 +108,78 @@ func (b *GCPBackend) retrieveFromSecretManager(resource, keyPath string) (string
 	return extractJSONKey(secretValue, keyPath)
 }
 
+// retrieveSecretLabels returns the labels of a secret as a JSON object, or the label at keyPath.
+// Labels belong to the secret rather than a version, so they are read with GetSecret and cached
+// apart from the payload.
+func (b *GCPBackend) retrieveSecretLabels(ctx context.Context, resource, secretName, keyPath string) (string, error) {
+	name := secretMetadataName(secretName)
+	cacheKey := fmt.Sprintf("gcp:sm-labels:%s", name)
+
+	cache := GetGlobalCache()
+	labels, exists := cache.Get(cacheKey)
+	if !exists {
+		secret, err := b.client.GetSecret(ctx, &secretmanagerpb.GetSecretRequest{Name: name})
+		if err != nil {
+			err = fmt.Errorf("failed to retrieve labels from GCP Secret Manager for resource '%s': %w", resource, err)
+			if status.Code(err) == codes.NotFound {
+				return "", notFound(err)
+			}
+			return "", err
+		}
+		if labels, err = labelsJSON(secret.GetLabels()); err != nil {
+			return "", err
+		}
+		cache.SetWithTTL(cacheKey, labels, BackendTTL("gcp"))
+	}
+
+	if keyPath == "" {
+		return labels, nil
+	}
+	return extractJSONKey(labels, keyPath)
+}
+
+// labelsJSON encodes labels as a JSON object with sorted keys; a secret without labels is "{}"
+func labelsJSON(labels map[string]string) (string, error) {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret labels: %w", err)
+	}
+	return string(data), nil
+}
+
+// secretMetadataName returns the secret a version name belongs to
+// ("projects/P/secrets/S/versions/V" becomes "projects/P/secrets/S")
+func secretMetadataName(secretName string) string {
+	if idx := strings.Index(secretName, "/versions/"); idx >= 0 {
+		return secretName[:idx]
+	}
+	return secretName
+}
+
 // normalizeSecretName converts various resource formats to the full GCP Secret Manager resource name.
 // Supports:
 // - Full path: "projects/PROJECT_ID/secrets/SECRET_NAME/versions/VERSION"
 // - Project/secret: "PROJECT_ID/SECRET_NAME" (uses latest version)
 // - Secret only: "SECRET_NAME" (uses default project and latest version)
+// The short forms accept a ":VERSION" suffix (a number or "latest") to pin a version.
 func (b *GCPBackend) normalizeSecretName(resource string) string {
 	// If already a full path, return as-is
 	if strings.HasPrefix(resource, "projects/") {
 		return resource
 	}
 
+	resource, version := splitSecretVersion(resource)
+
 	// Handle PROJECT_ID/SECRET_NAME format
 	if strings.Contains(resource, "/") && !strings.Contains(resource, "projects/") {
 		parts := strings.SplitN(resource, "/", 2)
 		if len(parts) == 2 {
 			projectID := parts[0]
 			secretName := parts[1]
-			return fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, secretName)
+			return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", projectID, secretName, version)
 		}
 	}
 
@@ -117,11 +190,33 @@ func (b *GCPBackend) normalizeSecretName(resource string) string {
 		return resource
 	}
 
-	return fmt.Sprintf("projects/%s/secrets/%s/versions/latest", projectID, resource)
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", projectID, resource, version)
+}
+
+// splitSecretVersion splits a trailing ":N" or ":latest" version from a secret name.
+// Secret names can't contain colons, so any other suffix is left for GCP to reject.
+func splitSecretVersion(resource string) (string, string) {
+	idx := strings.LastIndex(resource, ":")
+	if idx == -1 {
+		return resource, "latest"
+	}
+	version := resource[idx+1:]
+	if version == "latest" {
+		return resource[:idx], version
+	}
+	if _, err := strconv.ParseUint(version, 10, 64); err == nil {
+		return resource[:idx], version
+	}
+	return resource, "latest"
 }
 
 // getGCPProjectID attempts to get the GCP project ID from environment variables or metadata.
+// SECRETINIT_GCP_PROJECT takes precedence over the GCP SDK's own variables.
 func getGCPProjectID() string {
+	if projectID := os.Getenv("SECRETINIT_GCP_PROJECT"); projectID != "" {
+		return projectID
+	}
+
 	// Try common environment variables
 	if projectID := os.Getenv("GOOGLE_CLOUD_PROJECT"); projectID != "" {
 		return projectID
@@ -0,0 +1,97 @@
+package backend
+
+import "testing"
+
+func TestGCPBackend_normalizeSecretName_ProjectOverride(t *testing.T) {
+	t.Setenv("GOOGLE_CLOUD_PROJECT", "ambient-project")
+	t.Setenv("SECRETINIT_GCP_PROJECT", "secretinit-project")
+
+	b := &GCPBackend{}
+	got := b.normalizeSecretName("api-key")
+	expected := "projects/secretinit-project/secrets/api-key/versions/latest"
+	if got != expected {
+		t.Errorf("Expected SECRETINIT_GCP_PROJECT to win, got %s", got)
+	}
+
+	t.Setenv("SECRETINIT_GCP_PROJECT", "")
+	got = b.normalizeSecretName("api-key")
+	expected = "projects/ambient-project/secrets/api-key/versions/latest"
+	if got != expected {
+		t.Errorf("Expected fallback to GOOGLE_CLOUD_PROJECT, got %s", got)
+	}
+}
+
+func TestGCPBackend_normalizeSecretName_Version(t *testing.T) {
+	t.Setenv("SECRETINIT_GCP_PROJECT", "default-project")
+
+	tests := []struct {
+		resource string
+		expected string
+	}{
+		{"my-project/api-key", "projects/my-project/secrets/api-key/versions/latest"},
+		{"my-project/api-key:3", "projects/my-project/secrets/api-key/versions/3"},
+		{"my-project/api-key:latest", "projects/my-project/secrets/api-key/versions/latest"},
+		{"api-key:12", "projects/default-project/secrets/api-key/versions/12"},
+		{"api-key:latest", "projects/default-project/secrets/api-key/versions/latest"},
+		{"projects/p/secrets/s/versions/5", "projects/p/secrets/s/versions/5"},
+	}
+
+	b := &GCPBackend{}
+	for _, tt := range tests {
+		if got := b.normalizeSecretName(tt.resource); got != tt.expected {
+			t.Errorf("normalizeSecretName(%q) = %s, want %s", tt.resource, got, tt.expected)
+		}
+	}
+}
+
+func TestSecretMetadataName(t *testing.T) {
+	tests := map[string]string{
+		"projects/p/secrets/s/versions/latest": "projects/p/secrets/s",
+		"projects/p/secrets/s/versions/3":      "projects/p/secrets/s",
+		"api-key":                              "api-key",
+	}
+	for secretName, expected := range tests {
+		if got := secretMetadataName(secretName); got != expected {
+			t.Errorf("secretMetadataName(%q) = %s, want %s", secretName, got, expected)
+		}
+	}
+}
+
+func TestLabelsJSON(t *testing.T) {
+	got, err := labelsJSON(map[string]string{"team": "payments", "env": "prod"})
+	if err != nil || got != `{"env":"prod","team":"payments"}` {
+		t.Errorf("labelsJSON() = %s, %v", got, err)
+	}
+	if got, _ := labelsJSON(nil); got != "{}" {
+		t.Errorf("labelsJSON(nil) = %s, want {}", got)
+	}
+}
+
+func TestGCPBackend_Labels_CachedApartFromPayload(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+	cache := GetGlobalCache()
+	cache.Set("gcp:sm:projects/p/secrets/s/versions/latest", `{"team": "from-payload"}`)
+	cache.Set("gcp:sm-labels:projects/p/secrets/s", `{"env":"prod","team":"payments"}`)
+
+	// The cached values stand in for the API, so no client is needed
+	b := &GCPBackend{}
+	tests := []struct {
+		keyPath  string
+		expected string
+	}{
+		{"_labels", `{"env":"prod","team":"payments"}`},
+		{"_labels.team", "payments"},
+		{"team", "from-payload"},
+		{"", `{"team": "from-payload"}`},
+	}
+	for _, tt := range tests {
+		got, err := b.RetrieveSecret("sm", "p/s", tt.keyPath)
+		if err != nil {
+			t.Fatalf("RetrieveSecret(%q) error = %v", tt.keyPath, err)
+		}
+		if got != tt.expected {
+			t.Errorf("RetrieveSecret(%q) = %s, want %s", tt.keyPath, got, tt.expected)
+		}
+	}
+}
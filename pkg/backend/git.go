@@ -1,71 +1,135 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	neturl "net/url"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
+	executil "github.com/liifi/secretinit/pkg/exec"
 	"github.com/liifi/secretinit/pkg/parser"
 )
 
 // GitBackend implements the Backend interface for the Git credential manager.
-type GitBackend struct{}
+type GitBackend struct {
+	refreshed sync.Map // Cache keys already refreshed for SECRETINIT_GIT_REFRESH
+}
 
 // RetrieveSecret retrieves a secret from the Git credential manager.
 // The service parameter is empty for git (git doesn't have services).
 // The resource string may contain username (e.g., "https://user@example.com").
 // The keyPath should be "username" or "password".
 func (b *GitBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context; the git credential helper is killed when it expires.
+// With SECRETINIT_GIT_REFRESH=1 the stored credential is rejected before the fill, once per URL per run.
+func (b *GitBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
 	cache := GetGlobalCache()
 	// Create cache key for the credential (without keyPath since we cache the full credential)
 	cacheKey := fmt.Sprintf("git:%s:%s", service, resource)
 
-	if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Git backend: resource=%s, keyPath=%s\n", resource, keyPath)
+	debugLog("Git backend: resource=%s, keyPath=%s", resource, keyPath)
+
+	// A refresh skips the cache, including entries loaded from a persisted cache file,
+	// for the first lookup of each URL; later lookups use the refreshed credential
+	refresh := false
+	if os.Getenv("SECRETINIT_GIT_REFRESH") == "1" {
+		_, done := b.refreshed.LoadOrStore(cacheKey, true)
+		refresh = !done
 	}
 
 	// Check if we have cached the raw git credential response
 	var rawCredentialResponse string
 	var err error
-	if cached, exists := cache.Get(cacheKey); exists {
+	if cached, exists := cache.Get(cacheKey); exists && !refresh {
 		rawCredentialResponse = cached
-		if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Git credential cache hit\n")
-		}
+		debugLog("Git credential cache hit")
 	} else {
-		if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Git credential cache miss, calling git credential helper\n")
-		}
+		debugLog("Git credential cache miss, calling git credential helper")
 		// Cache miss - retrieve from git credential helper
+		if err := checkCredentialScheme(resource); err != nil {
+			return "", err
+		}
 		// For git, we need to extract username from resource if present
 		cleanURL, username := parser.ParseGitURL(resource)
-		if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Parsed URL: %s, username: %s\n", cleanURL, username)
+		debugLog("Parsed URL: %s, username: %s", cleanURL, username)
+		if refresh {
+			// Drop the stored credential so the helper re-prompts or re-fetches it
+			debugLog("SECRETINIT_GIT_REFRESH set, rejecting stored credential for %s", cleanURL)
+			_ = b.clearCredential(ctx, cleanURL, username) // Ignore errors - credential might not exist
 		}
-		rawCredentialResponse, err = getCredential(cleanURL, username)
+		// A refreshed credential is not approved: nothing here confirms it works, and approving a
+		// mistyped password would store it for every later run
+		rawCredentialResponse, err = getCredential(ctx, cleanURL, username)
 		if err != nil {
 			return "", fmt.Errorf("failed to retrieve git credential for %s: %w", cleanURL, err)
 		}
 
-		if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-			fmt.Fprintf(os.Stderr, "[DEBUG] Git credential retrieved successfully\n")
-		}
+		debugLog("Git credential retrieved successfully")
 		// Cache the raw git credential response directly
-		cache.Set(cacheKey, rawCredentialResponse)
+		cache.SetWithTTL(cacheKey, rawCredentialResponse, BackendTTL("git"))
 	}
 
 	// Apply keyPath parsing to the raw credential response (same pattern as AWS)
 	return parseGitCredential(rawCredentialResponse, keyPath)
 }
 
+// sshGitSchemes are URL schemes git authenticates with SSH keys, never asking a credential helper
+var sshGitSchemes = map[string]bool{"ssh": true, "git+ssh": true, "ssh+git": true}
+
+// checkCredentialScheme rejects SSH URLs with a pointer to what does work, instead of
+// letting git credential fill fail or answer for a URL git itself never looks up
+func checkCredentialScheme(rawURL string) error {
+	scheme, rest, found := strings.Cut(rawURL, "://")
+	if !found || !sshGitSchemes[strings.ToLower(scheme)] {
+		return nil
+	}
+	host := rest
+	if u, err := neturl.Parse(rawURL); err == nil && u.Hostname() != "" {
+		host = u.Hostname()
+	}
+	return fmt.Errorf("git credential helpers don't serve %s:// URLs, SSH authenticates with keys from ssh-agent or ~/.ssh. "+
+		"Store the token for the https URL (secretinit --store --url https://%s) and use git:https://%s, "+
+		"or read it with a helper using the exec backend (exec:HELPER)", scheme, host, host)
+}
+
+// gitKeyPathAliases maps friendly keyPath names to the git credential fields they try, in order
+var gitKeyPathAliases = map[string][]string{
+	"user":  {"username"},
+	"pass":  {"password"},
+	"token": {"password", "token"},
+}
+
 // parseGitCredential parses git credential response and returns the requested part
 // This is equivalent to extractJSONKey for AWS backend
+// Friendly aliases are supported: "user" and "pass" map to "username" and "password",
+// and "token" returns the password, falling back to an explicit "token" field.
 func parseGitCredential(credentialResponse, keyPath string) (string, error) {
-	if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Parsing git credential for keyPath: %s\n", keyPath)
+	fields, isAlias := gitKeyPathAliases[keyPath]
+	if !isAlias {
+		return parseGitCredentialField(credentialResponse, keyPath)
 	}
 
+	for _, field := range fields {
+		if value, err := parseGitCredentialField(credentialResponse, field); err == nil {
+			return value, nil
+		}
+	}
+	return "", notFound(fmt.Errorf("key '%s' not found in git credential response (tried %s)", keyPath, strings.Join(fields, ", ")))
+}
+
+// parseGitCredentialField returns a single field from a git credential response
+func parseGitCredentialField(credentialResponse, keyPath string) (string, error) {
+	debugLog("Parsing git credential for keyPath: %s", keyPath)
+
 	// Parse the git credential format: "key=value\n" lines
 	for _, line := range strings.Split(credentialResponse, "\n") {
 		line = strings.TrimSpace(line)
@@ -80,50 +144,98 @@ func parseGitCredential(credentialResponse, keyPath string) (string, error) {
 
 		key, value := parts[0], parts[1]
 		if key == keyPath {
-			if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-				fmt.Fprintf(os.Stderr, "[DEBUG] Found requested key '%s'\n", keyPath)
-			}
+			debugLog("Found requested key '%s'", keyPath)
 			return value, nil
 		}
 	}
 
-	if os.Getenv("SECRETINIT_LOG_LEVEL") == "DEBUG" {
-		fmt.Fprintf(os.Stderr, "[DEBUG] Key '%s' not found in git credential response\n", keyPath)
+	debugLog("Key '%s' not found in git credential response", keyPath)
+	return "", notFound(fmt.Errorf("key '%s' not found in git credential response", keyPath))
+}
+
+// gitCredentialCommand returns the command run for a git credential action ("fill", "reject" or
+// "approve"): SECRETINIT_GIT_CREDENTIAL_CMD split like a command line (e.g. a sandboxed CI wrapper
+// script), or "git credential ACTION" when it is not set. The configured command is run as is to
+// fill; other actions are passed as its last argument, replacing a trailing "fill", so the configured
+// command must dispatch on its last argument rather than treat every call as a fill.
+func gitCredentialCommand(action string) []string {
+	command := executil.ParseCommandLine(os.Getenv("SECRETINIT_GIT_CREDENTIAL_CMD"))
+	if len(command) == 0 {
+		return []string{"git", "credential", action}
 	}
-	return "", fmt.Errorf("key '%s' not found in git credential response", keyPath)
+	if action == "fill" {
+		return command
+	}
+	if command[len(command)-1] == "fill" {
+		command = command[:len(command)-1]
+	}
+	return append(command, action)
 }
 
-// getCredential retrieves raw credentials from git credential fill.
-func getCredential(url, user string) (string, error) {
+// getCredential retrieves raw credentials from git credential fill, or the SECRETINIT_GIT_CREDENTIAL_CMD helper.
+// Either one gets the url= and username= lines on stdin and must answer in git credential format.
+func getCredential(ctx context.Context, url, user string) (string, error) {
 	input := fmt.Sprintf("url=%s\n", url)
 	if user != "" {
 		input += fmt.Sprintf("username=%s\n", user)
 	}
 	input += "\n" // Important: git credential fill expects a blank line to terminate input
 
-	cmd := exec.Command("git", "credential", "fill")
+	command := gitCredentialCommand("fill")
+	name := strings.Join(command, " ")
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Stdin = strings.NewReader(input)
 	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("%s interrupted: %w", name, ctx.Err())
+	}
 	if err != nil {
-		return "", fmt.Errorf("git credential fill failed: %w", err)
+		return "", fmt.Errorf("%s failed: %w", name, err)
 	}
 
 	return string(output), nil
 }
 
+// StoreOptions customizes how StoreCredential asks for missing values
+type StoreOptions struct {
+	URLPrompt      string // Prompt for a missing URL (default "URL: ")
+	UsernamePrompt string // Prompt for a missing username (default "Username: ")
+	NoPrompt       bool   // Fail instead of prompting, including git's own password prompt
+}
+
 // StoreCredential stores credentials using git credential helper
 // url: the URL to store credentials for (can include user@ prefix, can be empty to prompt)
 // username: username (optional if already in URL)
 // Returns error if storage fails
 func (b *GitBackend) StoreCredential(url, username string) error {
+	return b.StoreCredentialWithOptions(url, username, StoreOptions{})
+}
+
+// StoreCredentialWithOptions is StoreCredential with custom prompts, or no prompts at all.
+// With NoPrompt a missing URL or username is an error instead of a blocking read from stdin.
+func (b *GitBackend) StoreCredentialWithOptions(url, username string, opts StoreOptions) error {
+	if opts.URLPrompt == "" {
+		opts.URLPrompt = "URL: "
+	}
+	if opts.UsernamePrompt == "" {
+		opts.UsernamePrompt = "Username: "
+	}
+
 	// Prompt for URL if not provided
 	if url == "" {
-		fmt.Print("URL: ")
+		if opts.NoPrompt {
+			return fmt.Errorf("no URL given and prompting is disabled (use --url)")
+		}
+		fmt.Print(opts.URLPrompt)
 		if _, err := fmt.Scanln(&url); err != nil {
 			return fmt.Errorf("error reading URL: %w", err)
 		}
 	}
 
+	if err := checkCredentialScheme(url); err != nil {
+		return err
+	}
+
 	// Parse the URL to extract user if present and get clean URL
 	cleanURL, userFromURL := parser.ParseGitURL(url)
 
@@ -134,56 +246,105 @@ func (b *GitBackend) StoreCredential(url, username string) error {
 
 	// If we still don't have a username, prompt for it
 	if username == "" {
-		fmt.Print("Username: ")
+		if opts.NoPrompt {
+			return fmt.Errorf("no username given for %s and prompting is disabled (use --user or user@ in the URL)", cleanURL)
+		}
+		fmt.Print(opts.UsernamePrompt)
 		if _, err := fmt.Scanln(&username); err != nil {
 			return fmt.Errorf("error reading username: %w", err)
 		}
 	}
 
-	// Clear any existing credentials first
-	if err := b.clearCredential(cleanURL, username); err != nil {
-		// Ignore errors - credential might not exist
+	// Serialize the reject/fill/approve sequence with other processes storing the same URL
+	lock, err := lockStore(cleanURL)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	// Clear any existing credentials first, so git prompts for a new password instead of returning them.
+	// Without prompts nothing could replace them, so they are only cleared once fill succeeded.
+	if !opts.NoPrompt {
+		b.clearCredential(context.Background(), cleanURL, username) // Ignore errors - credential might not exist
 	}
 
 	// Get credentials (this will prompt for password if needed)
-	credentials, err := b.promptForCredentials(cleanURL, username)
+	credentials, err := b.promptForCredentials(cleanURL, username, !opts.NoPrompt)
 	if err != nil {
 		return fmt.Errorf("failed to get credentials: %w", err)
 	}
+	if opts.NoPrompt {
+		b.clearCredential(context.Background(), cleanURL, username) // Ignore errors - credential might not exist
+	}
 
 	// Store the credentials
-	if err := b.approveCredentials(credentials); err != nil {
+	if err := b.approveCredentials(context.Background(), credentials); err != nil {
 		return fmt.Errorf("failed to store credentials: %w", err)
 	}
 
 	return nil
 }
 
-// clearCredential removes existing credentials
-func (b *GitBackend) clearCredential(url, username string) error {
+// storeLockTimeout is how long StoreCredential waits for another process storing the same URL
+var storeLockTimeout = 5 * time.Second
+
+// lockStore takes the advisory lock for storing credentials for url. The lock file lives in
+// $XDG_RUNTIME_DIR/secretinit (the user cache directory when unset) and is named by a hash of the URL.
+func lockStore(url string) (*fileLock, error) {
+	dir := os.Getenv("XDG_RUNTIME_DIR")
+	if dir != "" {
+		dir = filepath.Join(dir, "secretinit")
+	} else {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate a directory for the credential store lock: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "secretinit", "locks")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory %s: %w", dir, err)
+	}
+
+	lock, err := lockFile(filepath.Join(dir, "store-"+hashValue(url)[:16]), storeLockTimeout)
+	if errors.Is(err, errLockBusy) {
+		return nil, fmt.Errorf("another process is storing credentials for %s, try again once it finishes (%w)", url, err)
+	}
+	return lock, err
+}
+
+// clearCredential removes existing credentials with git credential reject, or the
+// SECRETINIT_GIT_CREDENTIAL_CMD helper; the command is killed when ctx expires
+func (b *GitBackend) clearCredential(ctx context.Context, url, username string) error {
 	input := fmt.Sprintf("url=%s\n", url)
 	if username != "" {
 		input += fmt.Sprintf("username=%s\n", username)
 	}
 	input += "\n"
 
-	cmd := exec.Command("git", "credential", "reject")
+	command := gitCredentialCommand("reject")
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Stderr = os.Stderr
 	return cmd.Run() // Ignore errors
 }
 
-// promptForCredentials prompts for credentials using git credential fill
-func (b *GitBackend) promptForCredentials(url, username string) (string, error) {
+// promptForCredentials prompts for credentials using git credential fill, or the SECRETINIT_GIT_CREDENTIAL_CMD helper.
+// Unless interactive, git fails instead of asking for a missing password on the terminal.
+func (b *GitBackend) promptForCredentials(url, username string, interactive bool) (string, error) {
 	input := fmt.Sprintf("url=%s\n", url)
 	if username != "" {
 		input += fmt.Sprintf("username=%s\n", username)
 	}
 	input += "\n"
 
-	cmd := exec.Command("git", "credential", "fill")
+	command := gitCredentialCommand("fill")
+	cmd := exec.Command(command[0], command[1:]...)
 	cmd.Stdin = strings.NewReader(input)
 	cmd.Stderr = os.Stderr
+	if !interactive {
+		// Make git fail instead of asking for the password on the terminal
+		cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	}
 	output, err := cmd.Output()
 	if err != nil {
 		return "", err
@@ -192,9 +353,10 @@ func (b *GitBackend) promptForCredentials(url, username string) (string, error)
 	return string(output), nil
 }
 
-// approveCredentials stores credentials using git credential approve
-func (b *GitBackend) approveCredentials(credentials string) error {
-	cmd := exec.Command("git", "credential", "approve")
+// approveCredentials stores credentials using git credential approve, or the SECRETINIT_GIT_CREDENTIAL_CMD helper
+func (b *GitBackend) approveCredentials(ctx context.Context, credentials string) error {
+	command := gitCredentialCommand("approve")
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
 	cmd.Stdin = strings.NewReader(credentials)
 	cmd.Stderr = os.Stderr
 	return cmd.Run()
@@ -1,9 +1,15 @@
 package backend
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/liifi/secretinit/pkg/parser"
 )
@@ -310,3 +316,359 @@ func TestParseGitCredential(t *testing.T) {
 		})
 	}
 }
+
+func TestParseGitCredential_Aliases(t *testing.T) {
+	withPassword := "protocol=https\nhost=example.com\nusername=alice\npassword=pa55\n"
+	tokenOnly := "protocol=https\nhost=example.com\nusername=alice\ntoken=tok3n\n"
+
+	tests := []struct {
+		name     string
+		response string
+		keyPath  string
+		want     string
+		wantErr  bool
+	}{
+		{name: "user alias", response: withPassword, keyPath: "user", want: "alice"},
+		{name: "pass alias", response: withPassword, keyPath: "pass", want: "pa55"},
+		{name: "token alias prefers password", response: withPassword, keyPath: "token", want: "pa55"},
+		{name: "token alias falls back to token field", response: tokenOnly, keyPath: "token", want: "tok3n"},
+		{name: "pass alias without password", response: tokenOnly, keyPath: "pass", wantErr: true},
+		{name: "canonical names unchanged", response: withPassword, keyPath: "username", want: "alice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseGitCredential(tt.response, tt.keyPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseGitCredential() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseGitCredential() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitBackend_StoreCredential_NoPrompt(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		username    string
+		errContains string
+	}{
+		{name: "missing URL", url: "", username: "alice", errContains: "no URL given"},
+		{name: "missing username", url: "https://api.example.com", username: "", errContains: "no username given for https://api.example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &GitBackend{}
+			err := b.StoreCredentialWithOptions(tt.url, tt.username, StoreOptions{NoPrompt: true, URLPrompt: "Repo: "})
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+			}
+			if !strings.Contains(err.Error(), "prompting is disabled") {
+				t.Errorf("expected the error to explain that prompting is disabled, got %v", err)
+			}
+		})
+	}
+}
+
+// gitHelperConfig points git at a credential helper running script and returns the file
+// the helper logs its actions (get, store, erase) to
+func gitHelperConfig(t *testing.T, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "helper.log")
+	helper := writeFakeSSH(t, `echo "$1" >> `+logFile+"\n"+script)
+	config := filepath.Join(dir, "gitconfig")
+	if err := os.WriteFile(config, []byte("[credential]\n\thelper = "+helper+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write git config: %v", err)
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", config)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Setenv("GIT_ASKPASS", "")
+	t.Setenv("SSH_ASKPASS", "")
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+	return logFile
+}
+
+func TestGitBackend_StoreCredential_NoPromptKeepsCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+		want    string
+	}{
+		// The helper has no password to give, so the stored credential must survive
+		{name: "fill fails", script: "cat > /dev/null\n", wantErr: true, want: "get\n"},
+		{name: "fill succeeds", script: `cat > /dev/null
+if [ "$1" = "get" ]; then
+  printf 'username=alice\npassword=from-helper\n'
+fi
+`, want: "get\nerase\nstore\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logFile := gitHelperConfig(t, tt.script)
+
+			err := (&GitBackend{}).StoreCredentialWithOptions("https://alice@example.com", "", StoreOptions{NoPrompt: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StoreCredentialWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			calls, _ := os.ReadFile(logFile)
+			if string(calls) != tt.want {
+				t.Errorf("helper calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitBackend_StoreCredential_Locked(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	oldTimeout := storeLockTimeout
+	storeLockTimeout = 50 * time.Millisecond
+	defer func() { storeLockTimeout = oldTimeout }()
+
+	// Another store for the same URL holds the lock
+	lock, err := lockStore("https://api.example.com")
+	if err != nil {
+		t.Fatalf("lockStore() error = %v", err)
+	}
+
+	b := &GitBackend{}
+	err = b.StoreCredentialWithOptions("https://alice@api.example.com", "", StoreOptions{NoPrompt: true})
+	if err == nil || !strings.Contains(err.Error(), "another process is storing credentials for https://api.example.com") {
+		t.Fatalf("expected a lock timeout error, got %v", err)
+	}
+
+	// Other URLs are not blocked, and the lock is free again once released
+	other, err := lockStore("https://other.example.com")
+	if err != nil {
+		t.Fatalf("lockStore() for another URL error = %v", err)
+	}
+	other.Unlock()
+	lock.Unlock()
+	lock, err = lockStore("https://api.example.com")
+	if err != nil {
+		t.Fatalf("lockStore() after unlock error = %v", err)
+	}
+	lock.Unlock()
+}
+
+func TestGetCredential_ContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := getCredential(ctx, "https://example.com", "")
+	if err == nil || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a canceled git credential fill, got %v", err)
+	}
+}
+
+// fakeGitOnPath puts a git script on PATH that logs each credential subcommand to a file
+func fakeGitOnPath(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "calls.log")
+	fakeGit := writeFakeSSH(t, `cat > /dev/null
+echo "$2" >> `+logFile+`
+if [ "$2" = "fill" ]; then
+  printf 'protocol=https\nhost=example.com\nusername=alice\npassword=fresh\n'
+fi
+`)
+	if err := os.Rename(fakeGit, filepath.Join(dir, "git")); err != nil {
+		t.Fatalf("failed to install fake git: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return logFile
+}
+
+func TestGitBackend_RetrieveSecret_Refresh(t *testing.T) {
+	tests := []struct {
+		name     string
+		refresh  string
+		cached   bool
+		want     string
+		password string
+	}{
+		{name: "normal run only fills", refresh: "", want: "fill\n", password: "fresh"},
+		{name: "normal run uses the cache", refresh: "", cached: true, want: "", password: "stale"},
+		{name: "refresh rejects first", refresh: "1", want: "reject\nfill\n", password: "fresh"},
+		{name: "refresh skips the cache", refresh: "1", cached: true, want: "reject\nfill\n", password: "fresh"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ClearGlobalCache()
+			defer ClearGlobalCache()
+			logFile := fakeGitOnPath(t)
+			t.Setenv("SECRETINIT_GIT_REFRESH", tt.refresh)
+			if tt.cached {
+				// E.g. an expired token loaded from a persisted cache file
+				GetGlobalCache().Set("git::https://example.com", "username=alice\npassword=stale\n")
+			}
+
+			b := &GitBackend{}
+			for _, keyPath := range []string{"password", "username"} {
+				value, err := b.RetrieveSecret("", "https://example.com", keyPath)
+				if err != nil {
+					t.Fatalf("RetrieveSecret(%s) error = %v", keyPath, err)
+				}
+				if keyPath == "password" && value != tt.password {
+					t.Errorf("RetrieveSecret(password) = %q, want %q", value, tt.password)
+				}
+			}
+
+			calls, err := os.ReadFile(logFile)
+			if err != nil && !os.IsNotExist(err) {
+				t.Fatalf("failed to read git calls: %v", err)
+			}
+			if string(calls) != tt.want {
+				t.Errorf("git credential calls = %q, want %q", calls, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitBackend_RetrieveSecret_SSHURL(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+	logFile := fakeGitOnPath(t)
+
+	for _, resource := range []string{"ssh://git@github.com/org/repo", "git+ssh://github.com/org/repo"} {
+		_, err := (&GitBackend{}).RetrieveSecret("", resource, "token")
+		if err == nil {
+			t.Fatalf("RetrieveSecret(%s) expected an error", resource)
+		}
+		for _, want := range []string{"don't serve", "git:https://github.com", "exec backend"} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("RetrieveSecret(%s) error = %v, want it to mention %q", resource, err, want)
+			}
+		}
+	}
+
+	if calls, _ := os.ReadFile(logFile); len(calls) > 0 {
+		t.Errorf("git credential ran for an SSH URL: %q", calls)
+	}
+}
+
+func TestGetCredential_CustomCommand(t *testing.T) {
+	stdinFile := filepath.Join(t.TempDir(), "stdin")
+	helper := writeFakeSSH(t, `cat > `+stdinFile+`
+echo "arg=$1"
+printf 'username=ci-bot\npassword=from-wrapper\n'
+`)
+	t.Setenv("SECRETINIT_GIT_CREDENTIAL_CMD", helper+" --sandbox")
+
+	output, err := getCredential(context.Background(), "https://example.com/org/repo", "ci-bot")
+	if err != nil {
+		t.Fatalf("getCredential() error = %v", err)
+	}
+	if password, _ := parseGitCredential(output, "password"); password != "from-wrapper" {
+		t.Errorf("password = %q, want from-wrapper (output %q)", password, output)
+	}
+	if !strings.Contains(output, "arg=--sandbox\n") {
+		t.Errorf("helper did not get its arguments, output %q", output)
+	}
+
+	stdin, err := os.ReadFile(stdinFile)
+	if err != nil {
+		t.Fatalf("failed to read helper stdin: %v", err)
+	}
+	if want := "url=https://example.com/org/repo\nusername=ci-bot\n\n"; string(stdin) != want {
+		t.Errorf("helper stdin = %q, want %q", stdin, want)
+	}
+}
+
+func TestGitBackend_RetrieveSecret_RefreshCustomCommand(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+	gitCalls := fakeGitOnPath(t)
+
+	logFile := filepath.Join(t.TempDir(), "calls.log")
+	helper := writeFakeSSH(t, `cat > /dev/null
+echo "$*" >> `+logFile+`
+if [ "$2" = "fill" ]; then
+  printf 'username=ci-bot\npassword=from-wrapper\n'
+fi
+`)
+	t.Setenv("SECRETINIT_GIT_CREDENTIAL_CMD", helper+" --sandbox fill")
+	t.Setenv("SECRETINIT_GIT_REFRESH", "1")
+
+	if _, err := (&GitBackend{}).RetrieveSecret("", "https://example.com", "password"); err != nil {
+		t.Fatalf("RetrieveSecret() error = %v", err)
+	}
+
+	calls, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read helper calls: %v", err)
+	}
+	if want := "--sandbox reject\n--sandbox fill\n"; string(calls) != want {
+		t.Errorf("helper calls = %q, want %q", calls, want)
+	}
+	if calls, _ := os.ReadFile(gitCalls); len(calls) > 0 {
+		t.Errorf("git credential ran instead of the helper: %q", calls)
+	}
+}
+
+func TestGitBackend_StoreCredential_CustomCommand(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	gitCalls := fakeGitOnPath(t)
+
+	logFile := filepath.Join(t.TempDir(), "calls.log")
+	helper := writeFakeSSH(t, `cat > /dev/null
+echo "$*" >> `+logFile+`
+if [ "$2" = "fill" ]; then
+  printf 'username=alice\npassword=from-wrapper\n'
+fi
+`)
+	t.Setenv("SECRETINIT_GIT_CREDENTIAL_CMD", helper+" --sandbox fill")
+
+	if err := (&GitBackend{}).StoreCredentialWithOptions("https://alice@example.com", "", StoreOptions{NoPrompt: true}); err != nil {
+		t.Fatalf("StoreCredentialWithOptions() error = %v", err)
+	}
+
+	calls, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("failed to read helper calls: %v", err)
+	}
+	if want := "--sandbox fill\n--sandbox reject\n--sandbox approve\n"; string(calls) != want {
+		t.Errorf("helper calls = %q, want %q", calls, want)
+	}
+	if calls, _ := os.ReadFile(gitCalls); len(calls) > 0 {
+		t.Errorf("git credential ran instead of the helper: %q", calls)
+	}
+}
+
+func TestGitCredentialCommand(t *testing.T) {
+	tests := []struct {
+		env    string
+		action string
+		want   []string
+	}{
+		{env: "", action: "fill", want: []string{"git", "credential", "fill"}},
+		{env: "", action: "reject", want: []string{"git", "credential", "reject"}},
+		{env: "wrapper --sandbox", action: "fill", want: []string{"wrapper", "--sandbox"}},
+		{env: "wrapper --sandbox", action: "reject", want: []string{"wrapper", "--sandbox", "reject"}},
+		{env: "wrapper git credential fill", action: "approve", want: []string{"wrapper", "git", "credential", "approve"}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("SECRETINIT_GIT_CREDENTIAL_CMD", tt.env)
+		if got := gitCredentialCommand(tt.action); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("gitCredentialCommand(%q) with %q = %q, want %q", tt.action, tt.env, got, tt.want)
+		}
+	}
+}
+
+func TestGetCredential_CustomCommandFailure(t *testing.T) {
+	helper := writeFakeSSH(t, "cat > /dev/null\nexit 3\n")
+	t.Setenv("SECRETINIT_GIT_CREDENTIAL_CMD", helper)
+
+	_, err := getCredential(context.Background(), "https://example.com", "")
+	if err == nil || !strings.Contains(err.Error(), helper+" failed") {
+		t.Errorf("expected the helper failure to name the helper, got %v", err)
+	}
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// RemoteBackend implements the Backend interface by running secretinit on a remote host over SSH.
+// This lets a bastion hold the cloud credentials while clients only need SSH access.
+type RemoteBackend struct {
+	SSHCommand    string // SSH client executable (default "ssh", override with SECRETINIT_SSH_COMMAND)
+	RemoteCommand string // secretinit executable on the remote host (default "secretinit", override with SECRETINIT_REMOTE_COMMAND)
+}
+
+// NewRemoteBackend creates a new RemoteBackend using the SSH client found in PATH.
+func NewRemoteBackend() (*RemoteBackend, error) {
+	b := &RemoteBackend{
+		SSHCommand:    "ssh",
+		RemoteCommand: "secretinit",
+	}
+	if sshCommand := os.Getenv("SECRETINIT_SSH_COMMAND"); sshCommand != "" {
+		b.SSHCommand = sshCommand
+	}
+	if remoteCommand := os.Getenv("SECRETINIT_REMOTE_COMMAND"); remoteCommand != "" {
+		b.RemoteCommand = remoteCommand
+	}
+	return b, nil
+}
+
+// RetrieveSecret retrieves a secret by running "secretinit -o ADDRESS" on a remote host.
+// The service parameter is empty for remote.
+// The resource has the format "ssh://[user@]host[:port]/ADDRESS", e.g. "ssh://bastion/aws:sm:myapp/key".
+// The keyPath is optional and used for JSON key extraction from the value returned by the remote host.
+func (b *RemoteBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+// RetrieveSecretCtx is RetrieveSecret with a context; the SSH client is killed when it expires
+func (b *RemoteBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	cache := GetGlobalCache()
+
+	// Cache by the full remote address (host and inner secret address)
+	cacheKey := fmt.Sprintf("remote:%s", resource)
+
+	var rawSecretValue string
+	if cached, exists := cache.Get(cacheKey); exists {
+		rawSecretValue = cached
+	} else {
+		host, port, address, err := parseRemoteResource(resource)
+		if err != nil {
+			return "", err
+		}
+
+		rawSecretValue, err = b.runRemote(ctx, host, port, address)
+		if err != nil {
+			return "", err
+		}
+
+		cache.SetWithTTL(cacheKey, rawSecretValue, BackendTTL("remote"))
+	}
+
+	if keyPath == "" {
+		return rawSecretValue, nil
+	}
+
+	return extractJSONKey(rawSecretValue, keyPath)
+}
+
+// runRemote executes secretinit on the remote host and returns its stdout without the trailing newline
+func (b *RemoteBackend) runRemote(ctx context.Context, host, port, address string) (string, error) {
+	args := []string{"-o", "BatchMode=yes"}
+	if port != "" {
+		args = append(args, "-p", port)
+	}
+	// The remote command is interpreted by the remote shell, so quote the address
+	args = append(args, "--", host, b.RemoteCommand, "-o", shellQuote(address))
+
+	debugLog("Remote backend: running %s on host %s", b.RemoteCommand, host)
+
+	cmd := exec.CommandContext(ctx, b.SSHCommand, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("remote secretinit on '%s' interrupted: %w", host, ctx.Err())
+	}
+	if err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("remote secretinit on '%s' failed: %w: %s", host, err, strings.TrimSpace(stderr.String()))
+		}
+		return "", fmt.Errorf("failed to run ssh command '%s' (is an SSH client installed?): %w", b.SSHCommand, err)
+	}
+
+	value := strings.TrimSuffix(string(output), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	return value, nil
+}
+
+// parseRemoteResource splits "ssh://[user@]host[:port]/ADDRESS" into its host, port and inner address
+func parseRemoteResource(resource string) (host, port, address string, err error) {
+	rest, found := strings.CutPrefix(resource, "ssh://")
+	if !found {
+		return "", "", "", fmt.Errorf("invalid remote resource '%s': expected 'ssh://host/ADDRESS'", resource)
+	}
+
+	host, address, found = strings.Cut(rest, "/")
+	if !found || host == "" || address == "" {
+		return "", "", "", fmt.Errorf("invalid remote resource '%s': expected 'ssh://host/ADDRESS'", resource)
+	}
+
+	// Split an optional port, keeping user@ as part of the host
+	if idx := strings.LastIndex(host, ":"); idx > strings.LastIndex(host, "@") {
+		host, port = host[:idx], host[idx+1:]
+	}
+
+	return host, port, address, nil
+}
+
+// shellQuote single-quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
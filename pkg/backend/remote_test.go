@@ -0,0 +1,138 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFakeSSH creates a fake ssh script that echoes its arguments' last value or fails
+func writeFakeSSH(t *testing.T, script string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+	path := filepath.Join(t.TempDir(), "fake-ssh")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake ssh: %v", err)
+	}
+	return path
+}
+
+func TestRemoteBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	argsFile := filepath.Join(t.TempDir(), "args")
+	fakeSSH := writeFakeSSH(t, `echo "$@" > `+argsFile+`
+echo '{"username":"admin","password":"s3cret"}'
+`)
+
+	b := &RemoteBackend{SSHCommand: fakeSSH, RemoteCommand: "secretinit"}
+
+	value, err := b.RetrieveSecret("", "ssh://deploy@bastion:2222/aws:sm:myapp/key", "password")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != "s3cret" {
+		t.Errorf("Expected 's3cret', got '%s'", value)
+	}
+
+	args, _ := os.ReadFile(argsFile)
+	expectedArgs := "-o BatchMode=yes -p 2222 -- deploy@bastion secretinit -o 'aws:sm:myapp/key'\n"
+	if string(args) != expectedArgs {
+		t.Errorf("Expected ssh args %q, got %q", expectedArgs, string(args))
+	}
+
+	// Second retrieval must be served from the cache keyed by the full remote address
+	os.Remove(argsFile)
+	value, err = b.RetrieveSecret("", "ssh://deploy@bastion:2222/aws:sm:myapp/key", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if value != `{"username":"admin","password":"s3cret"}` {
+		t.Errorf("Expected raw cached value, got '%s'", value)
+	}
+	if _, err := os.Stat(argsFile); !os.IsNotExist(err) {
+		t.Error("Expected cached value to be used without calling ssh again")
+	}
+}
+
+func TestRemoteBackend_SSHFailure(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	fakeSSH := writeFakeSSH(t, "echo 'ssh: connect to host bastion port 22: Connection refused' >&2\nexit 255\n")
+	b := &RemoteBackend{SSHCommand: fakeSSH, RemoteCommand: "secretinit"}
+
+	_, err := b.RetrieveSecret("", "ssh://bastion/aws:sm:myapp/key", "")
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if !strings.Contains(err.Error(), "Connection refused") || !strings.Contains(err.Error(), "bastion") {
+		t.Errorf("Expected error to include host and ssh stderr, got: %v", err)
+	}
+
+	b.SSHCommand = filepath.Join(t.TempDir(), "missing-ssh")
+	if _, err := b.RetrieveSecret("", "ssh://bastion/aws:sm:myapp/other", ""); err == nil || !strings.Contains(err.Error(), "is an SSH client installed") {
+		t.Errorf("Expected missing ssh client error, got: %v", err)
+	}
+}
+
+func TestParseRemoteResource(t *testing.T) {
+	tests := []struct {
+		resource string
+		host     string
+		port     string
+		address  string
+		wantErr  bool
+	}{
+		{resource: "ssh://bastion/aws:sm:myapp/key", host: "bastion", address: "aws:sm:myapp/key"},
+		{resource: "ssh://user@bastion:22/gcp:sm:proj/key", host: "user@bastion", port: "22", address: "gcp:sm:proj/key"},
+		{resource: "ssh://bastion", wantErr: true},
+		{resource: "https://bastion/aws:sm:x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.resource, func(t *testing.T) {
+			host, port, address, err := parseRemoteResource(tt.resource)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseRemoteResource() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if host != tt.host || port != tt.port || address != tt.address {
+				t.Errorf("parseRemoteResource() = (%s, %s, %s), want (%s, %s, %s)", host, port, address, tt.host, tt.port, tt.address)
+			}
+		})
+	}
+}
+
+func TestRemoteBackend_CacheTTL(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+	t.Setenv("SECRETINIT_TTL_REMOTE", "5m")
+
+	now := time.Now()
+	globalCache.now = func() time.Time { return now }
+	defer func() { globalCache.now = time.Now }()
+
+	callsFile := filepath.Join(t.TempDir(), "calls")
+	fakeSSH := writeFakeSSH(t, `echo call >> `+callsFile+`
+echo value
+`)
+	b := &RemoteBackend{SSHCommand: fakeSSH, RemoteCommand: "secretinit"}
+
+	for _, advance := range []time.Duration{0, time.Minute, 5 * time.Minute} {
+		now = now.Add(advance)
+		if _, err := b.RetrieveSecret("", "ssh://bastion/aws:sm:myapp/key", ""); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	calls, _ := os.ReadFile(callsFile)
+	if count := strings.Count(string(calls), "call"); count != 2 {
+		t.Errorf("Expected ssh to be called again only after the TTL expired (2 calls), got %d", count)
+	}
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RotationStatePath returns the default state file for --detect-rotation of address,
+// under the user cache directory so each address keeps its own stored hash
+func RotationStatePath(address string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "secretinit", "rotation", hashValue(address)[:16]+".hash"), nil
+}
+
+// DetectRotation compares the hash of value against the hash stored in statePath and
+// records the new hash. Returns true when the stored hash differs from value's hash.
+// The first check for a missing state file records a baseline and reports no rotation.
+func DetectRotation(statePath, value string) (bool, error) {
+	if err := os.MkdirAll(filepath.Dir(statePath), 0700); err != nil {
+		return false, fmt.Errorf("failed to create rotation state directory: %w", err)
+	}
+
+	current := hashValue(value)
+	var rotated bool
+	err := updateFileLocked(statePath, 0600, 0, func(data []byte) ([]byte, error) {
+		previous := strings.TrimSpace(string(data))
+		if previous == "" {
+			debugLog("No stored hash in %s, recording baseline", statePath)
+		}
+		rotated = previous != "" && previous != current
+		return []byte(current + "\n"), nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to update rotation state %s: %w", statePath, err)
+	}
+	return rotated, nil
+}
+
+// hashValue returns the full hex sha256 of value, so secrets are never written to disk
+func hashValue(value string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(value)))
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDetectRotation(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "nested", "state.hash")
+
+	steps := []struct {
+		value   string
+		rotated bool
+	}{
+		{"secret-v1", false}, // First run records the baseline
+		{"secret-v1", false},
+		{"secret-v2", true},
+		{"secret-v2", false},
+		{"secret-v1", true},
+	}
+
+	for i, step := range steps {
+		rotated, err := DetectRotation(statePath, step.value)
+		if err != nil {
+			t.Fatalf("step %d: DetectRotation() error = %v", i, err)
+		}
+		if rotated != step.rotated {
+			t.Errorf("step %d: DetectRotation(%q) = %v, want %v", i, step.value, rotated, step.rotated)
+		}
+	}
+}
+
+func TestDetectRotation_StateFileHoldsHashOnly(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.hash")
+
+	if _, err := DetectRotation(statePath, "super-secret"); err != nil {
+		t.Fatalf("DetectRotation() error = %v", err)
+	}
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		t.Fatalf("failed to read state file: %v", err)
+	}
+	if strings.Contains(string(data), "super-secret") {
+		t.Errorf("state file contains the secret value: %q", data)
+	}
+	if got := strings.TrimSpace(string(data)); got != hashValue("super-secret") {
+		t.Errorf("state file = %q, want %q", got, hashValue("super-secret"))
+	}
+}
+
+func TestRotationStatePath(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	first, err := RotationStatePath("aws:sm:app/db")
+	if err != nil {
+		t.Skipf("no user cache directory: %v", err)
+	}
+	second, _ := RotationStatePath("aws:sm:app/api")
+	if first == second {
+		t.Errorf("different addresses share state path %s", first)
+	}
+	if strings.Contains(first, "app/db") {
+		t.Errorf("state path %s exposes the address", first)
+	}
+}
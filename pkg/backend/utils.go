@@ -1,33 +1,80 @@
 package backend
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 )
 
+// ExtractJSONKey extracts keyPath from a JSON secret value like a ":::keyPath" address does.
+// Errors for a missing key wrap ErrSecretNotFound.
+func ExtractJSONKey(secretValue, keyPath string) (string, error) {
+	return extractJSONKey(secretValue, keyPath)
+}
+
 // extractJSONKey attempts to parse the secret value as JSON and extract the specified key.
 // This is a shared utility function used by multiple backends for JSON key extraction.
+// Numeric segments index into arrays, so object and array navigation can be mixed
+// (e.g. "servers.0.credentials.password"), and so do [n] suffixes ("servers[0].credentials.password").
 func extractJSONKey(secretValue, keyPath string) (string, error) {
-	var data map[string]interface{}
+	if keyPath == base64DecodeKey || strings.HasPrefix(keyPath, base64DecodeKey+".") {
+		decoded, err := decodeBase64(secretValue)
+		if err != nil {
+			return "", err
+		}
+		if keyPath == base64DecodeKey {
+			return decoded, nil
+		}
+		return extractJSONKey(decoded, strings.TrimPrefix(keyPath, base64DecodeKey+"."))
+	}
+
+	var data interface{}
 	if err := json.Unmarshal([]byte(secretValue), &data); err != nil {
 		return "", fmt.Errorf("failed to parse secret value as JSON for key extraction '%s': %w", keyPath, err)
 	}
 
 	// Support nested key paths using dot notation (e.g., "database.password")
-	keys := strings.Split(keyPath, ".")
-	var current interface{} = data
+	current := data
+
+	for i, segment := range splitKeyPath(keyPath) {
+		key := segment.key
+		if segment.bracketed {
+			v, isArray := current.([]interface{})
+			if !isArray {
+				return "", fmt.Errorf("cannot navigate to key '%s': segment %d ('[%s]') indexes a value that is not a JSON array", keyPath, i, key)
+			}
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return "", fmt.Errorf("cannot navigate to key '%s': segment %d ('[%s]') must be a non-negative array index", keyPath, i, key)
+			}
+			if index >= len(v) {
+				return "", notFound(fmt.Errorf("key '%s' not found in secret JSON (at path segment %d: index %d out of range for array of length %d)", keyPath, i, index, len(v)))
+			}
+			current = v[index]
+			continue
+		}
 
-	for i, key := range keys {
 		switch v := current.(type) {
 		case map[string]interface{}:
 			val, exists := v[key]
 			if !exists {
-				return "", fmt.Errorf("key '%s' not found in secret JSON (at path segment %d: '%s')", keyPath, i, key)
+				return "", notFound(fmt.Errorf("key '%s' not found in secret JSON (at path segment %d: '%s')", keyPath, i, key))
 			}
 			current = val
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 {
+				return "", fmt.Errorf("cannot navigate to key '%s': segment %d ('%s') must be an array index, the value there is a JSON array", keyPath, i, key)
+			}
+			if index >= len(v) {
+				return "", notFound(fmt.Errorf("key '%s' not found in secret JSON (at path segment %d: index %d out of range for array of length %d)", keyPath, i, index, len(v)))
+			}
+			current = v[index]
 		default:
-			return "", fmt.Errorf("cannot navigate to key '%s': intermediate value at segment %d ('%s') is not a JSON object", keyPath, i, key)
+			return "", fmt.Errorf("cannot navigate to key '%s': intermediate value at segment %d ('%s') is not a JSON object or array", keyPath, i, key)
 		}
 	}
 
@@ -46,3 +93,51 @@ func extractJSONKey(secretValue, keyPath string) (string, error) {
 		return string(jsonBytes), nil
 	}
 }
+
+// base64DecodeKey is the pseudo-key that base64-decodes the raw secret value (":::_base64decode"),
+// for certificates and keystores stored as base64 blobs. Any keyPath after it ("_base64decode.db.password")
+// is looked up in the decoded JSON.
+const base64DecodeKey = "_base64decode"
+
+// decodeBase64 decodes value as standard base64, with or without padding. Line breaks and other
+// whitespace are ignored, as in wrapped PEM-style output.
+func decodeBase64(value string) (string, error) {
+	compact := strings.Join(strings.Fields(value), "")
+	decoded, err := base64.StdEncoding.DecodeString(compact)
+	if err != nil {
+		decoded, err = base64.RawStdEncoding.DecodeString(compact)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to base64-decode secret value for key '%s': %w", base64DecodeKey, err)
+	}
+	return string(decoded), nil
+}
+
+// keyPathSegment is one step of a keyPath: an object key or array index, or a [n] array index
+type keyPathSegment struct {
+	key       string
+	bracketed bool
+}
+
+// bracketIndexRegex matches the [n] suffixes of a keyPath segment like "hosts[0][1]"
+var bracketIndexRegex = regexp.MustCompile(`^([^\[\]]*)((?:\[-?\d+\])+)$`)
+
+// splitKeyPath splits keyPath at dots and splits [n] suffixes off each part.
+// Parts that are not exactly NAME[n]... keep their brackets and are looked up as object keys.
+func splitKeyPath(keyPath string) []keyPathSegment {
+	var segments []keyPathSegment
+	for _, part := range strings.Split(keyPath, ".") {
+		matches := bracketIndexRegex.FindStringSubmatch(part)
+		if matches == nil {
+			segments = append(segments, keyPathSegment{key: part})
+			continue
+		}
+		if matches[1] != "" {
+			segments = append(segments, keyPathSegment{key: matches[1]})
+		}
+		for _, index := range strings.Split(strings.Trim(matches[2], "[]"), "][") {
+			segments = append(segments, keyPathSegment{key: index, bracketed: true})
+		}
+	}
+	return segments
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractJSONKey_MixedPaths(t *testing.T) {
+	const secret = `{
+		"servers": [
+			{"host": "db1", "credentials": {"user": "admin", "password": "p1"}},
+			{"host": "db2", "credentials": {"user": "admin", "password": "p2"}, "ports": [5432, 6432]}
+		],
+		"matrix": [[{"token": "t00"}, {"token": "t01"}], [{"token": "t10"}]],
+		"tags": ["a", "b"]
+	}`
+
+	tests := []struct {
+		name        string
+		secretValue string
+		keyPath     string
+		want        string
+		wantErr     string // Substring of the expected error, empty for success
+		notFound    bool
+	}{
+		{name: "index then object", secretValue: secret, keyPath: "servers.0.credentials.password", want: "p1"},
+		{name: "second element", secretValue: secret, keyPath: "servers.1.credentials.password", want: "p2"},
+		{name: "array of scalars", secretValue: secret, keyPath: "servers.1.ports.1", want: "6432"},
+		{name: "nested arrays", secretValue: secret, keyPath: "matrix.0.1.token", want: "t01"},
+		{name: "whole element as JSON", secretValue: secret, keyPath: "servers.0.credentials", want: `{"password":"p1","user":"admin"}`},
+		{name: "top level array", secretValue: `[{"key": "first"}, {"key": "second"}]`, keyPath: "1.key", want: "second"},
+		{name: "scalar element", secretValue: secret, keyPath: "tags.0", want: "a"},
+		{name: "index out of range", secretValue: secret, keyPath: "servers.2.host", wantErr: "segment 1: index 2 out of range for array of length 2", notFound: true},
+		{name: "missing key after index", secretValue: secret, keyPath: "servers.0.credentials.token", wantErr: "at path segment 3: 'token'", notFound: true},
+		{name: "name on an array", secretValue: secret, keyPath: "servers.primary.host", wantErr: "segment 1 ('primary') must be an array index"},
+		{name: "negative index", secretValue: secret, keyPath: "tags.-1", wantErr: "segment 1 ('-1') must be an array index"},
+		{name: "navigate into scalar element", secretValue: secret, keyPath: "tags.0.name", wantErr: "segment 2 ('name') is not a JSON object or array"},
+		{name: "navigate into number", secretValue: secret, keyPath: "servers.1.ports.0.x", wantErr: "segment 4 ('x') is not a JSON object or array"},
+		{name: "bracket index", secretValue: secret, keyPath: "tags[1]", want: "b"},
+		{name: "bracket then object", secretValue: secret, keyPath: "servers[1].credentials.password", want: "p2"},
+		{name: "chained brackets", secretValue: secret, keyPath: "matrix[1][0].token", want: "t10"},
+		{name: "bracket on top level array", secretValue: `["first", "second"]`, keyPath: "[1]", want: "second"},
+		{name: "bracket out of range", secretValue: secret, keyPath: "tags[5]", wantErr: "segment 1: index 5 out of range for array of length 2", notFound: true},
+		{name: "bracket on an object", secretValue: secret, keyPath: "servers[0].credentials[0]", wantErr: "segment 3 ('[0]') indexes a value that is not a JSON array"},
+		{name: "bracket negative index", secretValue: secret, keyPath: "tags[-1]", wantErr: "segment 1 ('[-1]') must be a non-negative array index"},
+		{name: "literal key with brackets", secretValue: `{"a[x]": "literal"}`, keyPath: "a[x]", want: "literal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractJSONKey(tt.secretValue, tt.keyPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("extractJSONKey(%q) error = %v, want error containing %q", tt.keyPath, err, tt.wantErr)
+				}
+				if errors.Is(err, ErrSecretNotFound) != tt.notFound {
+					t.Errorf("extractJSONKey(%q) not found = %v, want %v", tt.keyPath, !tt.notFound, tt.notFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractJSONKey(%q) unexpected error: %v", tt.keyPath, err)
+			}
+			if got != tt.want {
+				t.Errorf("extractJSONKey(%q) = %q, want %q", tt.keyPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractJSONKey_Base64Decode(t *testing.T) {
+	// {"db": {"password": "s3cret"}}, wrapped like PEM output and without padding
+	const encodedJSON = "eyJkYiI6IHsicGFzc3dvcmQiOiAiczNjcmV0In19"
+	tests := []struct {
+		name        string
+		secretValue string
+		keyPath     string
+		want        string
+		wantErr     string
+	}{
+		{name: "raw blob", secretValue: "AAEC/w==", keyPath: "_base64decode", want: "\x00\x01\x02\xff"},
+		{name: "line breaks", secretValue: "aGVsbG8g\nd29ybGQ=\n", keyPath: "_base64decode", want: "hello world"},
+		{name: "no padding", secretValue: "aGk", keyPath: "_base64decode", want: "hi"},
+		{name: "decoded JSON", secretValue: encodedJSON, keyPath: "_base64decode.db.password", want: "s3cret"},
+		{name: "invalid base64", secretValue: "not base64!", keyPath: "_base64decode", wantErr: "failed to base64-decode"},
+		{name: "decoded value not JSON", secretValue: "aGk=", keyPath: "_base64decode.key", wantErr: "failed to parse secret value as JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractJSONKey(tt.secretValue, tt.keyPath)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("extractJSONKey(%q) error = %v, want error containing %q", tt.keyPath, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractJSONKey(%q) error = %v", tt.keyPath, err)
+			}
+			if got != tt.want {
+				t.Errorf("extractJSONKey(%q) = %q, want %q", tt.keyPath, got, tt.want)
+			}
+		})
+	}
+}
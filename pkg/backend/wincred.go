@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WinCredBackend implements the Backend interface for the Windows Credential Manager.
+type WinCredBackend struct {
+	// readCredential reads a generic credential by target name (readWindowsCredential by default)
+	readCredential func(target string) (username, password string, err error)
+}
+
+// NewWinCredBackend creates a new WinCredBackend. It fails on platforms other than Windows.
+func NewWinCredBackend() (*WinCredBackend, error) {
+	if !winCredSupported {
+		return nil, fmt.Errorf("wincred backend is only available on Windows")
+	}
+	return &WinCredBackend{readCredential: readWindowsCredential}, nil
+}
+
+// RetrieveSecret retrieves a generic credential from the Windows Credential Manager.
+// The service parameter is empty for wincred.
+// The resource is the credential's target name (e.g. "git:https://github.com").
+// The keyPath selects "username" or "password" (aliases "user" and "pass"); it defaults to "password".
+func (b *WinCredBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	field, err := winCredField(keyPath)
+	if err != nil {
+		return "", err
+	}
+
+	cache := GetGlobalCache()
+	cacheKey := "wincred:" + resource
+
+	var rawCredential string
+	if cached, exists := cache.Get(cacheKey); exists {
+		rawCredential = cached
+	} else {
+		username, password, err := b.readCredential(resource)
+		if err != nil {
+			return "", fmt.Errorf("failed to read credential '%s' from Windows Credential Manager: %w", resource, err)
+		}
+		data, err := json.Marshal(map[string]string{"username": username, "password": password})
+		if err != nil {
+			return "", err
+		}
+		rawCredential = string(data)
+		cache.SetWithTTL(cacheKey, rawCredential, BackendTTL("wincred"))
+	}
+
+	return extractJSONKey(rawCredential, field)
+}
+
+// winCredField maps a keyPath to the credential field it selects
+func winCredField(keyPath string) (string, error) {
+	switch keyPath {
+	case "", "password", "pass":
+		return "password", nil
+	case "username", "user":
+		return "username", nil
+	default:
+		return "", fmt.Errorf("unsupported wincred keyPath '%s'. Supported: 'username', 'password'", keyPath)
+	}
+}
+
+// decodeCredentialBlob decodes a credential blob. Credentials stored by Windows tools (cmdkey,
+// Control Panel) are UTF-16LE, while others (e.g. Git Credential Manager) store UTF-8.
+// A blob of even size is UTF-16LE unless it reads as UTF-8 text without control characters:
+// UTF-16LE text has NUL high bytes for ASCII, control-character high bytes for Cyrillic, Greek,
+// Arabic or Hebrew, and bytes that are no valid UTF-8 sequence for most CJK text.
+func decodeCredentialBlob(blob []byte) string {
+	if len(blob)%2 != 0 || (utf8.Valid(blob) && !hasControlByte(blob)) {
+		return string(blob)
+	}
+	chars := make([]uint16, len(blob)/2)
+	for i := range chars {
+		chars[i] = uint16(blob[2*i]) | uint16(blob[2*i+1])<<8
+	}
+	return string(utf16.Decode(chars))
+}
+
+// hasControlByte reports whether data contains an ASCII control character, including NUL
+func hasControlByte(data []byte) bool {
+	for _, c := range data {
+		if c < 0x20 || c == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package backend
+
+import (
+	"errors"
+)
+
+// winCredSupported reports whether the Windows Credential Manager is available on this platform
+const winCredSupported = false
+
+// readWindowsCredential is not available outside Windows
+func readWindowsCredential(target string) (string, string, error) {
+	return "", "", errors.New("Windows Credential Manager is not available on this platform")
+}
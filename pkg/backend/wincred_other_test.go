@@ -0,0 +1,13 @@
+//go:build !windows
+
+package backend
+
+import (
+	"testing"
+)
+
+func TestNewWinCredBackend_Unsupported(t *testing.T) {
+	if _, err := NewWinCredBackend(); err == nil {
+		t.Error("Expected wincred backend to be unavailable outside Windows")
+	}
+}
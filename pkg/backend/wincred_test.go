@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestWinCredBackend_RetrieveSecret(t *testing.T) {
+	ClearGlobalCache()
+	defer ClearGlobalCache()
+
+	reads := 0
+	b := &WinCredBackend{readCredential: func(target string) (string, string, error) {
+		reads++
+		if target != "git:https://github.com" {
+			return "", "", errors.New("credential not found")
+		}
+		return "octocat", "s3cret", nil
+	}}
+
+	tests := []struct {
+		keyPath  string
+		expected string
+	}{
+		{"", "s3cret"},
+		{"password", "s3cret"},
+		{"pass", "s3cret"},
+		{"username", "octocat"},
+		{"user", "octocat"},
+	}
+	for _, tt := range tests {
+		value, err := b.RetrieveSecret("", "git:https://github.com", tt.keyPath)
+		if err != nil {
+			t.Fatalf("keyPath %q: unexpected error: %v", tt.keyPath, err)
+		}
+		if value != tt.expected {
+			t.Errorf("keyPath %q: expected %q, got %q", tt.keyPath, tt.expected, value)
+		}
+	}
+	if reads != 1 {
+		t.Errorf("Expected the credential to be read once and cached, got %d reads", reads)
+	}
+
+	if _, err := b.RetrieveSecret("", "git:https://github.com", "token"); err == nil || !strings.Contains(err.Error(), "unsupported wincred keyPath") {
+		t.Errorf("Expected unsupported keyPath error, got %v", err)
+	}
+
+	if _, err := b.RetrieveSecret("", "missing", "password"); err == nil || !strings.Contains(err.Error(), "credential not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
+
+// utf16LE encodes s as UTF-16LE, like cmdkey stores credential blobs
+func utf16LE(s string) []byte {
+	chars := utf16.Encode([]rune(s))
+	blob := make([]byte, 2*len(chars))
+	for i, c := range chars {
+		binary.LittleEndian.PutUint16(blob[2*i:], c)
+	}
+	return blob
+}
+
+func TestDecodeCredentialBlob(t *testing.T) {
+	tests := []struct {
+		name     string
+		blob     []byte
+		expected string
+	}{
+		{"utf-16le", []byte{'p', 0, 'w', 0}, "pw"},
+		{"utf-16le cyrillic", utf16LE("пароль"), "пароль"},
+		{"utf-16le cjk", utf16LE("密码安全"), "密码安全"},
+		{"utf-16le mixed", utf16LE("Пароль-2024"), "Пароль-2024"},
+		{"utf-8", []byte("s3cret"), "s3cret"},
+		{"utf-8 odd size", []byte("s3cr3"), "s3cr3"},
+		{"utf-8 non-ascii", []byte("pässwörd"), "pässwörd"},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeCredentialBlob(tt.blob); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
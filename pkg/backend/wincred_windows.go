@@ -0,0 +1,63 @@
+//go:build windows
+
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// winCredSupported reports whether the Windows Credential Manager is available on this platform
+const winCredSupported = true
+
+const credTypeGeneric = 1 // CRED_TYPE_GENERIC
+
+var (
+	advapi32      = windows.NewLazySystemDLL("advapi32.dll")
+	procCredReadW = advapi32.NewProc("CredReadW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+// winCredential mirrors the Win32 CREDENTIALW structure
+type winCredential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+// readWindowsCredential reads a generic credential with CredReadW
+func readWindowsCredential(target string) (string, string, error) {
+	targetPtr, err := windows.UTF16PtrFromString(target)
+	if err != nil {
+		return "", "", err
+	}
+
+	var cred *winCredential
+	ret, _, callErr := procCredReadW.Call(uintptr(unsafe.Pointer(targetPtr)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ret == 0 {
+		if errors.Is(callErr, windows.ERROR_NOT_FOUND) {
+			return "", "", notFound(fmt.Errorf("credential not found"))
+		}
+		return "", "", callErr
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	username := windows.UTF16PtrToString(cred.UserName)
+	var blob []byte
+	if cred.CredentialBlobSize > 0 && cred.CredentialBlob != nil {
+		blob = unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)
+	}
+	return username, decodeCredentialBlob(blob), nil
+}
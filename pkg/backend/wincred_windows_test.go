@@ -0,0 +1,15 @@
+//go:build windows
+
+package backend
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadWindowsCredential_NotFound(t *testing.T) {
+	_, _, err := readWindowsCredential("secretinit-test-missing-credential")
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+}
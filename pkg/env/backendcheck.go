@@ -0,0 +1,43 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// CheckEnvFileBackends verifies that every secretinit: value in a .env file parses and targets
+// a backend for which available returns true (e.g. one compiled into this build).
+// The returned error names the file and line of every offending declaration.
+func CheckEnvFileBackends(path string, available func(backend string) bool) error {
+	entries, err := LoadEnvFileEntries(path)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, entry := range entries {
+		location := fmt.Sprintf("%s:%d", path, entry.Line)
+		if entry.Line == 0 {
+			location = path // JSON and TOML config files have no line numbers
+		}
+		address, isSecret := strings.CutPrefix(entry.Value, "secretinit:")
+		if !isSecret {
+			continue
+		}
+		secretSource, err := parser.ParseSecretString(address)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %s: invalid secret address: %v", location, entry.Key, err))
+			continue
+		}
+		if !available(secretSource.Backend) {
+			problems = append(problems, fmt.Sprintf("%s: %s: backend '%s' is not available in this build", location, entry.Key, secretSource.Backend))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
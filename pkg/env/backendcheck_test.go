@@ -0,0 +1,58 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckEnvFileBackends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prod.env")
+	content := `# Production secrets
+DB_PASS=secretinit:aws:sm:myapp/db:::password
+LOG_LEVEL=info
+
+API_KEY=secretinit:gcp:sm:my-project/api-key
+BROKEN=secretinit:nope
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write env file: %v", err)
+	}
+
+	awsOnly := func(backend string) bool { return backend == "git" || backend == "aws" }
+	err := CheckEnvFileBackends(path, awsOnly)
+	if err == nil {
+		t.Fatal("Expected error for unavailable backend")
+	}
+	for _, want := range []string{
+		path + ":5: API_KEY: backend 'gcp' is not available in this build",
+		path + ":6: BROKEN: invalid secret address",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to contain %q, got:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "DB_PASS") {
+		t.Errorf("Expected available backend to pass, got:\n%v", err)
+	}
+
+	all := func(string) bool { return true }
+	os.WriteFile(path, []byte("DB_PASS=secretinit:aws:sm:myapp/db\nAPI_KEY=secretinit:gcp:sm:p/k\n"), 0644)
+	if err := CheckEnvFileBackends(path, all); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLoadEnvFileEntries_LineNumbers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("# comment\nA=1\n\nB=2\n"), 0644)
+
+	entries, err := LoadEnvFileEntries(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Line != 2 || entries[1].Line != 4 || entries[1].Key != "B" {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+}
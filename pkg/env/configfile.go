@@ -0,0 +1,95 @@
+package env
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configFileFormat returns "json" or "toml" for a config file given to -e, chosen by its
+// extension, or "" for a .env file
+func configFileFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	}
+	return ""
+}
+
+// loadConfigFileEntries reads a JSON or TOML config file as env file entries. Nested keys are
+// flattened with dots and array elements with their index ("database.password", "hosts.0"),
+// the same paths a ":::keyPath" uses. Values are taken as written: no $VAR interpolation.
+// Entries are sorted by key and have no line number.
+func loadConfigFileEntries(path, format string) ([]EnvFileEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config map[string]interface{}
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber() // Keep numbers as written instead of float64
+		if err := decoder.Decode(&config); err != nil {
+			return nil, fmt.Errorf("invalid JSON config file %s (expected an object): %w", path, err)
+		}
+	case "toml":
+		if _, err := toml.Decode(string(data), &config); err != nil {
+			return nil, fmt.Errorf("invalid TOML config file %s: %w", path, err)
+		}
+	}
+
+	values := make(map[string]string)
+	flattenConfig("", config, values)
+
+	entries := make([]EnvFileEntry, 0, len(values))
+	for key, value := range values {
+		entries = append(entries, EnvFileEntry{Key: key, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// flattenConfig adds the scalar values below value to values, keyed by their dotted path
+func flattenConfig(prefix string, value interface{}, values map[string]string) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenConfig(join(key), item, values)
+		}
+	case []interface{}:
+		for i, item := range v {
+			flattenConfig(join(strconv.Itoa(i)), item, values)
+		}
+	case []map[string]interface{}: // TOML arrays of tables
+		for i, item := range v {
+			flattenConfig(join(strconv.Itoa(i)), item, values)
+		}
+	case string:
+		values[prefix] = v
+	case nil:
+		values[prefix] = ""
+	case time.Time:
+		values[prefix] = v.Format(time.RFC3339Nano)
+	default: // Numbers, booleans and TOML local dates and times
+		values[prefix] = fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,92 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadEnvFile_ConfigFiles(t *testing.T) {
+	expected := map[string]string{
+		"DB_PASSWORD":       "secretinit:aws:sm:myapp/db:::password",
+		"database.host":     "db.internal",
+		"database.port":     "5432",
+		"database.ssl":      "true",
+		"hosts.0":           "a.example.com",
+		"hosts.1":           "b.example.com",
+		"servers.0.name":    "primary",
+		"servers.0.api_key": "secretinit:gcp:sm:my-project/api-key",
+	}
+
+	files := map[string]string{
+		"config.json": `{
+			"DB_PASSWORD": "secretinit:aws:sm:myapp/db:::password",
+			"database": {"host": "db.internal", "port": 5432, "ssl": true},
+			"hosts": ["a.example.com", "b.example.com"],
+			"servers": [{"name": "primary", "api_key": "secretinit:gcp:sm:my-project/api-key"}]
+		}`,
+		"config.TOML": `DB_PASSWORD = "secretinit:aws:sm:myapp/db:::password"
+hosts = ["a.example.com", "b.example.com"]
+
+[database]
+host = "db.internal" # Comments are fine
+port = 5432
+ssl = true
+
+[[servers]]
+name = "primary"
+api_key = "secretinit:gcp:sm:my-project/api-key"
+`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadEnvFile(path)
+			if err != nil {
+				t.Fatalf("LoadEnvFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("LoadEnvFile() = %v, want %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFile_ConfigFileErrors(t *testing.T) {
+	files := map[string]string{
+		"array.json":    `["not", "an", "object"]`,
+		"broken.json":   `{"key": `,
+		"broken.toml":   `key = `,
+		"unquoted.toml": `key = value`,
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadEnvFile(path); err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("LoadEnvFile(%s) error = %v, want an error naming the file", name, err)
+		}
+	}
+}
+
+func TestCheckEnvFileBackends_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"db": {"password": "secretinit:azure:kv:vault/db"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := CheckEnvFileBackends(path, func(name string) bool { return name == "aws" })
+	if err == nil || !strings.Contains(err.Error(), path+": db.password: backend 'azure'") {
+		t.Errorf("CheckEnvFileBackends() error = %v, want the file and dotted key", err)
+	}
+}
@@ -0,0 +1,53 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"unicode/utf16"
+)
+
+// Byte order marks recognized at the start of .env files
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// readEnvFileText reads a .env file as UTF-8 text. A leading byte order mark selects the
+// encoding: UTF-8 BOMs are dropped and UTF-16 (LE or BE) files, as written by some Windows
+// editors, are transcoded. Files without a BOM are assumed to be UTF-8.
+func readEnvFileText(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return string(data[len(bomUTF8):]), nil
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return decodeUTF16(path, data[len(bomUTF16LE):], false)
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return decodeUTF16(path, data[len(bomUTF16BE):], true)
+	default:
+		return string(data), nil
+	}
+}
+
+// decodeUTF16 transcodes UTF-16 data (without its BOM) to UTF-8
+func decodeUTF16(path string, data []byte, bigEndian bool) (string, error) {
+	if len(data)%2 != 0 {
+		return "", fmt.Errorf("invalid UTF-16 in %s: odd number of bytes", path)
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,63 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// encodeUTF16 encodes s as UTF-16 with a byte order mark, as Windows editors save "Unicode" files
+func encodeUTF16(s string, bigEndian bool) []byte {
+	var data []byte
+	for _, unit := range utf16.Encode([]rune("\ufeff" + s)) {
+		if bigEndian {
+			data = append(data, byte(unit>>8), byte(unit))
+		} else {
+			data = append(data, byte(unit), byte(unit>>8))
+		}
+	}
+	return data
+}
+
+func TestLoadEnvFile_ByteOrderMarks(t *testing.T) {
+	content := "DB_PASS=secretinit:aws:sm:myapp/db\r\nGREETING=\"héllo wörld\"\r\n"
+	fixtures := map[string][]byte{
+		"utf8.env":     []byte(content),
+		"utf8-bom.env": append([]byte{0xEF, 0xBB, 0xBF}, content...),
+		"utf16-le.env": encodeUTF16(content, false),
+		"utf16-be.env": encodeUTF16(content, true),
+	}
+	expected := map[string]string{
+		"DB_PASS":  "secretinit:aws:sm:myapp/db",
+		"GREETING": "héllo wörld",
+	}
+
+	dir := t.TempDir()
+	for name, data := range fixtures {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, data, 0644); err != nil {
+				t.Fatalf("failed to write fixture: %v", err)
+			}
+			got, err := LoadEnvFile(path)
+			if err != nil {
+				t.Fatalf("LoadEnvFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, expected) {
+				t.Errorf("LoadEnvFile() = %q, want %q", got, expected)
+			}
+		})
+	}
+}
+
+func TestLoadEnvFile_TruncatedUTF16(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.env")
+	if err := os.WriteFile(path, append(encodeUTF16("A=1", false), 'x'), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+	if _, err := LoadEnvFile(path); err == nil {
+		t.Error("Expected an error for UTF-16 data with an odd number of bytes")
+	}
+}
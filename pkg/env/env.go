@@ -7,8 +7,13 @@ import (
 )
 
 func ScanSecretEnvVars() map[string]string {
+	return ScanSecretEnvVarsFrom(os.Environ())
+}
+
+// ScanSecretEnvVarsFrom scans environ (KEY=VALUE entries) for secretinit: prefixed values
+func ScanSecretEnvVarsFrom(environ []string) map[string]string {
 	secretVars := make(map[string]string)
-	for _, envVar := range os.Environ() {
+	for _, envVar := range environ {
 		parts := strings.SplitN(envVar, "=", 2)
 		if len(parts) == 2 {
 			if strings.HasPrefix(parts[1], "secretinit:") {
@@ -18,3 +23,21 @@ func ScanSecretEnvVars() map[string]string {
 	}
 	return secretVars
 }
+
+// FilterSecretVars keeps the secret variables whose names start with one of prefixes.
+// Without prefixes all variables are kept.
+func FilterSecretVars(secretVars map[string]string, prefixes []string) map[string]string {
+	if len(prefixes) == 0 {
+		return secretVars
+	}
+	filtered := make(map[string]string)
+	for name, address := range secretVars {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				filtered[name] = address
+				break
+			}
+		}
+	}
+	return filtered
+}
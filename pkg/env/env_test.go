@@ -0,0 +1,44 @@
+package env
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterSecretVars(t *testing.T) {
+	secretVars := map[string]string{
+		"BILLING_DB_PASS": "aws:sm:billing/db:::password",
+		"BILLING_API_KEY": "gcp:sm:proj/billing-key",
+		"SEARCH_TOKEN":    "git:https://search.example.com",
+		"AUTH_SECRET":     "azure:kv:vault/auth",
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		want     []string
+	}{
+		{name: "no prefixes keeps all", prefixes: nil, want: []string{"AUTH_SECRET", "BILLING_API_KEY", "BILLING_DB_PASS", "SEARCH_TOKEN"}},
+		{name: "single prefix", prefixes: []string{"BILLING_"}, want: []string{"BILLING_API_KEY", "BILLING_DB_PASS"}},
+		{name: "several prefixes", prefixes: []string{"SEARCH_", "AUTH_"}, want: []string{"AUTH_SECRET", "SEARCH_TOKEN"}},
+		{name: "case sensitive", prefixes: []string{"billing_"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FilterSecretVars(secretVars, tt.prefixes)
+			names := []string{}
+			for _, name := range []string{"AUTH_SECRET", "BILLING_API_KEY", "BILLING_DB_PASS", "SEARCH_TOKEN"} {
+				if _, ok := got[name]; ok {
+					names = append(names, name)
+					if got[name] != secretVars[name] {
+						t.Errorf("%s = %q, want %q", name, got[name], secretVars[name])
+					}
+				}
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("FilterSecretVars(%v) kept %v, want %v", tt.prefixes, names, tt.want)
+			}
+		})
+	}
+}
@@ -4,53 +4,283 @@ import (
 	"bufio"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
+	"unicode"
 )
 
-// LoadEnvFile loads environment variables from a .env file
+// EnvFileEntry is a single KEY=value declaration of a .env file
+type EnvFileEntry struct {
+	Key   string
+	Value string
+	Line  int // 1-based line number in the file, 0 for JSON and TOML config files
+}
+
+// LoadEnvFile loads environment variables from a .env file, or a JSON or TOML config file
+// chosen by its extension (see loadConfigFileEntries).
 // Returns a map of key-value pairs, or an error if the file cannot be read
 func LoadEnvFile(filepath string) (map[string]string, error) {
+	entries, err := LoadEnvFileEntries(filepath)
+	if err != nil {
+		return nil, err
+	}
+
 	envVars := make(map[string]string)
+	for _, entry := range entries {
+		envVars[entry.Key] = entry.Value
+	}
+	return envVars, nil
+}
+
+// LoadEnvFileEntries loads the declarations of a .env file in file order, with their line numbers.
+// Values may reference earlier keys of the same file or the process environment as $VAR or ${VAR};
+// unknown variables expand to an empty string and \$ produces a literal dollar sign.
+// Files ending in .json or .toml are read as config files instead.
+func LoadEnvFileEntries(filepath string) ([]EnvFileEntry, error) {
+	if format := configFileFormat(filepath); format != "" {
+		return loadConfigFileEntries(filepath, format)
+	}
 
-	file, err := os.Open(filepath)
+	entries, err := scanEnvFile(filepath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	decodeEnvEntries(entries)
+	return entries, nil
+}
+
+// decodeEnvEntries decodes the values of entries in order, so a value may reference the keys
+// declared before it or the process environment (see decodeEnvValue)
+func decodeEnvEntries(entries []EnvFileEntry) {
+	parsed := make(map[string]string, len(entries))
+	lookup := func(name string) string {
+		if value, ok := parsed[name]; ok {
+			return value
+		}
+		return os.Getenv(name)
+	}
+	for i := range entries {
+		entries[i].Value = decodeEnvValue(entries[i].Value, lookup)
+		parsed[entries[i].Key] = entries[i].Value
+	}
+}
 
-	scanner := bufio.NewScanner(file)
+// scanEnvFile reads the KEY=value declarations of a .env file with their values as written.
+// A value that opens a double quote without closing it continues on the following lines
+// (kept verbatim, e.g. a PEM key) until the line holding the closing quote.
+// UTF-8 and UTF-16 files with a byte order mark are supported, see readEnvFileText.
+func scanEnvFile(filepath string) ([]EnvFileEntry, error) {
+	text, err := readEnvFileText(filepath)
+	if err != nil {
+		return nil, err
+	}
+	return scanEnvText(text, filepath)
+}
+
+// scanEnvText reads the KEY=value declarations of text, the content of the .env file filepath
+// (used in errors), like scanEnvFile
+func scanEnvText(text, filepath string) ([]EnvFileEntry, error) {
+	entries, _, err := scanEnvTextUntil(text, filepath, "")
+	return entries, err
+}
+
+// scanEnvTextUntil is scanEnvText stopping at the first line that is stop (ignoring surrounding
+// whitespace) outside a quoted value. It returns that line's number, or 0 if there is none.
+func scanEnvTextUntil(text, filepath, stop string) ([]EnvFileEntry, int, error) {
+	var entries []EnvFileEntry
+	scanner := bufio.NewScanner(strings.NewReader(text))
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
 		line := strings.TrimSpace(scanner.Text())
 
+		if stop != "" && line == stop {
+			return entries, lineNum, nil
+		}
+
 		// Skip empty lines and comments
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
 
+		// Accept shell-style "export KEY=value" lines
+		if rest, found := strings.CutPrefix(line, "export"); found && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			line = strings.TrimSpace(rest)
+		}
+
 		// Parse KEY=value format
 		parts := strings.SplitN(line, "=", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid line %d in %s: %s", lineNum, filepath, line)
+			return nil, 0, fmt.Errorf("invalid line %d in %s: %s", lineNum, filepath, line)
 		}
 
 		key := strings.TrimSpace(parts[0])
 		value := strings.TrimSpace(parts[1])
 
 		if key == "" {
-			return nil, fmt.Errorf("empty key on line %d in %s", lineNum, filepath)
+			return nil, 0, fmt.Errorf("empty key on line %d in %s", lineNum, filepath)
+		}
+
+		startLine := lineNum
+		if strings.HasPrefix(value, `"`) && !hasClosingQuote(value[1:]) {
+			closed := false
+			for !closed && scanner.Scan() {
+				lineNum++
+				value += "\n" + scanner.Text()
+				closed = hasClosingQuote(scanner.Text())
+			}
+			if !closed {
+				return nil, 0, fmt.Errorf("unterminated quoted value for %s starting on line %d in %s", key, startLine, filepath)
+			}
 		}
+		value = strings.TrimRightFunc(stripInlineComment(value), unicode.IsSpace)
 
-		envVars[key] = value
+		entries = append(entries, EnvFileEntry{Key: key, Value: value, Line: startLine})
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading %s: %v", filepath, err)
+		return nil, 0, fmt.Errorf("error reading %s: %v", filepath, err)
 	}
 
-	return envVars, nil
+	return entries, 0, nil
+}
+
+// stripInlineComment removes a trailing "# comment" from a value as written. Like in a shell, the
+// comment must follow whitespace, so "pass#word" keeps its '#', and a '#' inside a single- or
+// double-quoted value is part of the value.
+func stripInlineComment(value string) string {
+	if value != "" && (value[0] == '"' || value[0] == '\'') {
+		var end int
+		if value[0] == '"' {
+			end = closingQuoteIndex(value[1:])
+		} else {
+			end = strings.IndexByte(value[1:], '\'')
+		}
+		if end >= 0 {
+			rest := value[end+2:]
+			if trimmed := strings.TrimSpace(rest); trimmed == "" || (strings.HasPrefix(trimmed, "#") && rest != trimmed) {
+				return value[:end+2]
+			}
+		}
+		return value
+	}
+
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimRightFunc(value[:i], unicode.IsSpace)
+		}
+	}
+	return value
+}
+
+// hasClosingQuote reports whether s contains a double quote that is not escaped with a backslash
+func hasClosingQuote(s string) bool {
+	return closingQuoteIndex(s) >= 0
+}
+
+// closingQuoteIndex returns the index of the first double quote in s that is not escaped with a backslash, or -1
+func closingQuoteIndex(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++ // Skip the escaped character
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeEnvValue expands $VAR and ${VAR} references in a value as written in a .env file, using lookup.
+// A double-quoted value (as written by --write-env) also has its quotes removed and the escapes
+// \\, \", \n, \r and \t decoded; in any value \$ produces a literal dollar sign.
+// A single-quoted value is taken literally, without escapes or expansion, like in a shell.
+func decodeEnvValue(value string, lookup func(name string) string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' && !strings.Contains(value[1:len(value)-1], "'") {
+		return value[1 : len(value)-1]
+	}
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if decoded, ok := expandEnvValue(value[1:len(value)-1], true, lookup); ok {
+			return decoded
+		}
+	}
+	decoded, _ := expandEnvValue(value, false, lookup)
+	return decoded
+}
+
+// expandEnvValue decodes escapes and variable references in a single pass, so escaped or
+// expanded text is never expanded again. Returns false if a quoted value contains an
+// unescaped quote or ends in a lone backslash, i.e. it is not one quoted string.
+func expandEnvValue(value string, quoted bool, lookup func(name string) string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == '\\' && i+1 < len(value) && value[i+1] == '$':
+			sb.WriteByte('$')
+			i++
+		case c == '\\' && quoted:
+			if i+1 >= len(value) {
+				return "", false
+			}
+			i++
+			switch value[i] {
+			case '\\', '"':
+				sb.WriteByte(value[i])
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				// Unknown escapes are kept as written
+				sb.WriteByte('\\')
+				sb.WriteByte(value[i])
+			}
+		case c == '"' && quoted:
+			return "", false
+		case c == '$':
+			name, length := envVarReference(value[i:])
+			if length == 0 {
+				sb.WriteByte(c)
+				continue
+			}
+			sb.WriteString(lookup(name))
+			i += length - 1
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), true
+}
+
+// envVarReference parses a $VAR or ${VAR} reference at the start of s.
+// Returns the variable name and the reference length, or a zero length if s has none.
+func envVarReference(s string) (string, int) {
+	if strings.HasPrefix(s, "${") {
+		end := strings.IndexByte(s, '}')
+		if end <= 2 {
+			return "", 0
+		}
+		return s[2:end], end + 1
+	}
+
+	length := 1
+	for length < len(s) && isEnvNameByte(s[length], length == 1) {
+		length++
+	}
+	if length == 1 {
+		return "", 0
+	}
+	return s[1:length], length
+}
+
+// isEnvNameByte reports whether c can appear in a shell variable name (not starting with a digit)
+func isEnvNameByte(c byte, first bool) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
 }
 
 // LoadAndSetEnvFile loads a .env file and sets the variables in the current process
@@ -89,3 +319,41 @@ func LoadAndSetEnvFileOverride(filepath string) (int, error) {
 
 	return count, nil
 }
+
+// ExpandEnvFilePatterns expands glob patterns (e.g. "config/*.env") into file paths.
+// Matches of each pattern are returned in lexical order, patterns keep their given order.
+// Plain paths are returned unchanged; a glob pattern that matches nothing is an error.
+func ExpandEnvFilePatterns(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid env file pattern %s: %v", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("env file pattern %s matched no files", pattern)
+		}
+		sort.Strings(matches)
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// LoadAndSetEnvFilesOverride loads multiple .env files in order and sets the variables in the current process
+// Later files override earlier ones, and all of them override existing environment variables
+func LoadAndSetEnvFilesOverride(paths []string) (int, error) {
+	count := 0
+	for _, path := range paths {
+		loaded, err := LoadAndSetEnvFileOverride(path)
+		if err != nil {
+			return count, fmt.Errorf("%s: %w", path, err)
+		}
+		count += loaded
+	}
+	return count, nil
+}
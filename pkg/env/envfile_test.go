@@ -0,0 +1,247 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandEnvFilePatterns(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20-app.env", "10-base.env", "30-local.env", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(""), 0644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+	}
+
+	paths, err := ExpandEnvFilePatterns([]string{filepath.Join(dir, "*.env"), "explicit.env"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{
+		filepath.Join(dir, "10-base.env"),
+		filepath.Join(dir, "20-app.env"),
+		filepath.Join(dir, "30-local.env"),
+		"explicit.env",
+	}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected %v, got %v", expected, paths)
+	}
+
+	if _, err := ExpandEnvFilePatterns([]string{filepath.Join(dir, "*.missing")}); err == nil {
+		t.Error("Expected error for glob pattern matching no files")
+	}
+}
+
+func TestLoadAndSetEnvFilesOverride_Ordering(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"10-base.env": "SHARED=base\nBASE_ONLY=1\n",
+		"20-app.env":  "SHARED=app\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+	}
+
+	t.Setenv("SHARED", "system")
+	t.Setenv("BASE_ONLY", "")
+
+	paths, err := ExpandEnvFilePatterns([]string{filepath.Join(dir, "*.env")})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	count, err := LoadAndSetEnvFilesOverride(paths)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if count != 3 {
+		t.Errorf("Expected 3 loaded variables, got %d", count)
+	}
+	if got := os.Getenv("SHARED"); got != "app" {
+		t.Errorf("Expected later file to win, got SHARED=%s", got)
+	}
+	if got := os.Getenv("BASE_ONLY"); got != "1" {
+		t.Errorf("Expected BASE_ONLY=1, got %s", got)
+	}
+}
+
+func TestLoadEnvFile_DoubleQuotedValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quoted.env")
+	content := `MULTI="line1\nline2"
+ESCAPED="say \"hi\" \\ \$HOME"
+PADDED="  x  "
+UNKNOWN_ESCAPE="a\qb"
+NOT_QUOTED="a"b"
+BARE=value
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	vars, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"MULTI":          "line1\nline2",
+		"ESCAPED":        `say "hi" \ $HOME`,
+		"PADDED":         "  x  ",
+		"UNKNOWN_ESCAPE": `a\qb`,
+		"NOT_QUOTED":     `"a"b"`,
+		"BARE":           "value",
+	}
+	for key, want := range expected {
+		if got := vars[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadEnvFile_Interpolation(t *testing.T) {
+	t.Setenv("SYSTEM_HOST", "db.internal")
+	t.Setenv("OVERRIDDEN", "system")
+	os.Unsetenv("UNKNOWN_VAR")
+
+	path := filepath.Join(t.TempDir(), "interp.env")
+	content := `DB_PORT=5432
+OVERRIDDEN=file
+DB_URL=postgres://${SYSTEM_HOST}:$DB_PORT/app
+USES_FILE_VALUE=$OVERRIDDEN
+LITERAL=cost \$5 and $5
+MISSING=[${UNKNOWN_VAR}][$UNKNOWN_VAR]
+UNCLOSED=${DB_PORT
+QUOTED="${DB_PORT}\n\${DB_PORT}"
+AWS_SECRET=secretinit:aws:sm:app/${DB_PORT}
+LATER=$DEFINED_BELOW
+DEFINED_BELOW=x
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	vars, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"DB_URL":          "postgres://db.internal:5432/app",
+		"USES_FILE_VALUE": "file",
+		"LITERAL":         "cost $5 and $5",
+		"MISSING":         "[][]",
+		"UNCLOSED":        "${DB_PORT",
+		"QUOTED":          "5432\n${DB_PORT}",
+		"AWS_SECRET":      "secretinit:aws:sm:app/5432",
+		"LATER":           "",
+	}
+	for key, want := range expected {
+		if got := vars[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestLoadEnvFile_QuotedAndMultilineValues(t *testing.T) {
+	t.Setenv("HOME_DIR", "/home/app")
+
+	path := filepath.Join(t.TempDir(), "multiline.env")
+	content := "SINGLE='  literal $HOME_DIR \\n  '\n" +
+		"DOUBLE=\"  spaced ${HOME_DIR}  \"\n" +
+		"UNQUOTED=   trimmed value   \n" +
+		"PEM=\"-----BEGIN KEY-----\n" +
+		"  abc\\\"def\n" +
+		"-----END KEY-----\"  \n" +
+		"AFTER=1\n" +
+		"ESCAPED_PEM=\"-----BEGIN KEY-----\\nxyz\\n-----END KEY-----\"\n" +
+		"QUOTE_IN_SINGLE='it's'\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	entries, err := LoadEnvFileEntries(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []EnvFileEntry{
+		{Key: "SINGLE", Value: `  literal $HOME_DIR \n  `, Line: 1},
+		{Key: "DOUBLE", Value: "  spaced /home/app  ", Line: 2},
+		{Key: "UNQUOTED", Value: "trimmed value", Line: 3},
+		{Key: "PEM", Value: "-----BEGIN KEY-----\n  abc\"def\n-----END KEY-----", Line: 4},
+		{Key: "AFTER", Value: "1", Line: 7},
+		{Key: "ESCAPED_PEM", Value: "-----BEGIN KEY-----\nxyz\n-----END KEY-----", Line: 8},
+		{Key: "QUOTE_IN_SINGLE", Value: "'it's'", Line: 9},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(expected), entries)
+	}
+	for i, want := range expected {
+		if entries[i] != want {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want)
+		}
+	}
+}
+
+func TestLoadEnvFile_UnterminatedQuote(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.env")
+	if err := os.WriteFile(path, []byte("OK=1\nPEM=\"-----BEGIN KEY-----\nabc\n"), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	_, err := LoadEnvFile(path)
+	if err == nil || !strings.Contains(err.Error(), "unterminated quoted value for PEM starting on line 2") {
+		t.Errorf("expected unterminated quote error, got %v", err)
+	}
+}
+
+func TestLoadEnvFile_ExportAndInlineComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shared.env")
+	content := "export DB_PASS=secretinit:aws:sm:myapp/db\n" +
+		"export\tTABBED=1\n" +
+		"exported=plain\n" +
+		"PORT=8080 # the HTTP port\n" +
+		"TAB_COMMENT=a\t# note\n" +
+		"HASH_IN_VALUE=pass#word\n" +
+		"DOUBLE=\"has # hash\" # comment\n" +
+		"SINGLE='has # hash' # comment\n" +
+		"ESCAPED=\"say \\\"hi\\\" # not a comment\"\n" +
+		"PEM=\"-----BEGIN KEY-----\n" +
+		"abc # kept\n" +
+		"-----END KEY-----\" # trailing note\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	envVars, err := LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"DB_PASS":       "secretinit:aws:sm:myapp/db",
+		"TABBED":        "1",
+		"exported":      "plain",
+		"PORT":          "8080",
+		"TAB_COMMENT":   "a",
+		"HASH_IN_VALUE": "pass#word",
+		"DOUBLE":        "has # hash",
+		"SINGLE":        "has # hash",
+		"ESCAPED":       `say "hi" # not a comment`,
+		"PEM":           "-----BEGIN KEY-----\nabc # kept\n-----END KEY-----",
+	}
+	if len(envVars) != len(expected) {
+		t.Fatalf("got %d variables, want %d: %v", len(envVars), len(expected), envVars)
+	}
+	for key, want := range expected {
+		if envVars[key] != want {
+			t.Errorf("%s = %q, want %q", key, envVars[key], want)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ApplyPostEnvFile loads a .env file after secrets have been resolved and applies it on top of environ.
+// Values may reference variables of environ (including resolved secrets and mappings) as $VAR or ${VAR};
+// unknown references expand to an empty string, like in a shell. Post-env values override existing ones.
+func ApplyPostEnvFile(path string, environ []string) ([]string, error) {
+	entries, err := scanEnvFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]string, len(environ))
+	for _, envVar := range environ {
+		if key, value, ok := strings.Cut(envVar, "="); ok {
+			current[key] = value
+		}
+	}
+
+	// Expand every value against the environment before any post-env value is applied,
+	// so the result does not depend on the order of the file's entries
+	expanded := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		expanded[entry.Key] = decodeEnvValue(entry.Value, func(name string) string {
+			return current[name]
+		})
+	}
+
+	result := make([]string, 0, len(environ)+len(expanded))
+	for _, envVar := range environ {
+		key, _, _ := strings.Cut(envVar, "=")
+		if _, overridden := expanded[key]; !overridden {
+			result = append(result, envVar)
+		}
+	}
+
+	keys := make([]string, 0, len(expanded))
+	for key := range expanded {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		result = append(result, fmt.Sprintf("%s=%s", key, expanded[key]))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,26 @@
+//go:build linux
+
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ReadProcessEnviron returns the environment of the running process pid (KEY=VALUE entries),
+// read from /proc/PID/environ. Reading another user's process requires matching permissions.
+func ReadProcessEnviron(pid int) ([]string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment of process %d: %w", pid, err)
+	}
+
+	var environ []string
+	for _, entry := range strings.Split(string(data), "\x00") {
+		if entry != "" {
+			environ = append(environ, entry)
+		}
+	}
+	return environ, nil
+}
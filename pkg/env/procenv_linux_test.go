@@ -0,0 +1,45 @@
+//go:build linux
+
+package env
+
+import (
+	"bufio"
+	"os/exec"
+	"testing"
+)
+
+func TestReadProcessEnviron(t *testing.T) {
+	// The child writes a line once it runs, so its environ is not read before the exec
+	cmd := exec.Command("sh", "-c", "echo ready && exec sleep 30")
+	cmd.Env = []string{"DB_PASS=secretinit:aws:sm:myapp/db", "PLAIN=value"}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatalf("StdoutPipe() error = %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start child process: %v", err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+	if _, err := bufio.NewReader(stdout).ReadString('\n'); err != nil {
+		t.Fatalf("child did not start: %v", err)
+	}
+
+	environ, err := ReadProcessEnviron(cmd.Process.Pid)
+	if err != nil {
+		t.Fatalf("ReadProcessEnviron() error = %v", err)
+	}
+
+	secretVars := ScanSecretEnvVarsFrom(environ)
+	if len(secretVars) != 1 || secretVars["DB_PASS"] != "aws:sm:myapp/db" {
+		t.Errorf("ScanSecretEnvVarsFrom() = %v, want only DB_PASS", secretVars)
+	}
+}
+
+func TestReadProcessEnviron_MissingProcess(t *testing.T) {
+	if _, err := ReadProcessEnviron(-1); err == nil {
+		t.Error("Expected error for a process that does not exist")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package env
+
+import "errors"
+
+// ReadProcessEnviron is only supported on Linux, where /proc exposes process environments
+func ReadProcessEnviron(pid int) ([]string, error) {
+	return nil, errors.New("--adopt is only supported on Linux")
+}
@@ -0,0 +1,75 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// defaultRegionKey is the matrix entry used when a variable has no address for the active region
+const defaultRegionKey = "default"
+
+// RegionMatrix maps logical secret variables to a secret address per region.
+//
+// Example matrix file:
+//
+//	{
+//	  "DB_PASS": {
+//	    "us-east-1": "aws:sm:us-east-1:myapp/db:::password",
+//	    "eu-west-1": "aws:sm:eu-west-1:myapp-eu/db:::password",
+//	    "default":   "git:https://db.example.com:::password"
+//	  }
+//	}
+type RegionMatrix map[string]map[string]string
+
+// LoadRegionMatrix loads a region matrix file
+func LoadRegionMatrix(path string) (RegionMatrix, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var matrix RegionMatrix
+	if err := json.Unmarshal(data, &matrix); err != nil {
+		return nil, fmt.Errorf("failed to parse region matrix %s: %w", path, err)
+	}
+
+	for name, addresses := range matrix {
+		if len(addresses) == 0 {
+			return nil, fmt.Errorf("no addresses for variable '%s' in region matrix %s", name, path)
+		}
+	}
+	return matrix, nil
+}
+
+// Select returns the secret address of every variable for region, falling back to the
+// variable's "default" entry. Addresses carry the secretinit: prefix, like secret environment
+// variables. A variable with neither entry is an error naming the regions it does have.
+func (m RegionMatrix) Select(region string) (map[string]string, error) {
+	if region == "" {
+		return nil, fmt.Errorf("no region selected for the region matrix")
+	}
+
+	selected := make(map[string]string, len(m))
+	for name, addresses := range m {
+		address, exists := addresses[region]
+		if !exists {
+			address, exists = addresses[defaultRegionKey]
+		}
+		if !exists {
+			regions := make([]string, 0, len(addresses))
+			for r := range addresses {
+				regions = append(regions, r)
+			}
+			sort.Strings(regions)
+			return nil, fmt.Errorf("variable '%s' has no address for region '%s' (available: %s)", name, region, strings.Join(regions, ", "))
+		}
+		if !strings.HasPrefix(address, "secretinit:") {
+			address = "secretinit:" + address
+		}
+		selected[name] = address
+	}
+	return selected, nil
+}
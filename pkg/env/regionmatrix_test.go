@@ -0,0 +1,100 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRegionMatrix_Select(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regions.json")
+	content := `{
+  "DB_PASS": {
+    "us-east-1": "aws:sm:us-east-1:myapp/db:::password",
+    "eu-west-1": "secretinit:aws:sm:eu-west-1:myapp-eu/db:::password"
+  },
+  "API_KEY": {
+    "eu-west-1": "gcp:sm:eu-project/api-key",
+    "default": "gcp:sm:global-project/api-key"
+  }
+}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+
+	matrix, err := LoadRegionMatrix(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		region      string
+		expected    map[string]string
+		errContains string
+	}{
+		{
+			name:   "us-east-1 uses the default for API_KEY",
+			region: "us-east-1",
+			expected: map[string]string{
+				"DB_PASS": "secretinit:aws:sm:us-east-1:myapp/db:::password",
+				"API_KEY": "secretinit:gcp:sm:global-project/api-key",
+			},
+		},
+		{
+			name:   "eu-west-1 has its own addresses",
+			region: "eu-west-1",
+			expected: map[string]string{
+				"DB_PASS": "secretinit:aws:sm:eu-west-1:myapp-eu/db:::password",
+				"API_KEY": "secretinit:gcp:sm:eu-project/api-key",
+			},
+		},
+		{
+			name:        "unknown region without default",
+			region:      "ap-south-1",
+			errContains: "variable 'DB_PASS' has no address for region 'ap-south-1' (available: eu-west-1, us-east-1)",
+		},
+		{
+			name:        "no region",
+			region:      "",
+			errContains: "no region selected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matrix.Select(tt.region)
+			if tt.errContains != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+					t.Fatalf("expected error containing %q, got %v", tt.errContains, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoadRegionMatrix_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"empty-entry.json": `{"DB_PASS": {}}`,
+		"not-json.json":    `DB_PASS=aws:sm:x`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write fixture: %v", err)
+		}
+		if _, err := LoadRegionMatrix(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema declares the environment variables a child process expects.
+//
+// Example schema file:
+//
+//	{
+//	  "variables": {
+//	    "DB_PASSWORD": {"required": true, "pattern": "^.{12,}$"},
+//	    "LOG_LEVEL":   {"pattern": "^(debug|info|warn)$"}
+//	  }
+//	}
+type Schema struct {
+	Variables map[string]*VariableRule `json:"variables"`
+}
+
+// VariableRule describes the constraints for a single environment variable
+type VariableRule struct {
+	Required bool   `json:"required"` // Variable must be present and non-empty
+	Pattern  string `json:"pattern"`  // Optional regular expression the value must match when present
+
+	pattern *regexp.Regexp
+}
+
+// LoadSchema loads and compiles a schema file
+func LoadSchema(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema Schema
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema %s: %w", path, err)
+	}
+
+	for name, rule := range schema.Variables {
+		if rule == nil {
+			return nil, fmt.Errorf("empty rule for variable '%s' in schema %s", name, path)
+		}
+		if rule.Pattern != "" {
+			compiled, err := regexp.Compile(rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern for variable '%s' in schema %s: %w", name, path, err)
+			}
+			rule.pattern = compiled
+		}
+	}
+
+	return &schema, nil
+}
+
+// Validate checks an environment (KEY=VALUE format) against the schema.
+// It returns one error per violated rule, sorted by variable name. Values are never included in errors.
+func (s *Schema) Validate(environ []string) []error {
+	envMap := make(map[string]string)
+	for _, envVar := range environ {
+		if parts := strings.SplitN(envVar, "=", 2); len(parts) == 2 {
+			envMap[parts[0]] = parts[1]
+		}
+	}
+
+	names := make([]string, 0, len(s.Variables))
+	for name := range s.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs []error
+	for _, name := range names {
+		rule := s.Variables[name]
+		value, exists := envMap[name]
+
+		if !exists || value == "" {
+			if rule.Required {
+				errs = append(errs, fmt.Errorf("required variable '%s' is missing or empty", name))
+			}
+			continue
+		}
+
+		if rule.pattern != nil && !rule.pattern.MatchString(value) {
+			errs = append(errs, fmt.Errorf("variable '%s' does not match pattern '%s'", name, rule.Pattern))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeSchema(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+	return path
+}
+
+const testSchema = `{
+  "variables": {
+    "DB_PASSWORD": {"required": true, "pattern": "^.{8,}$"},
+    "LOG_LEVEL":   {"pattern": "^(debug|info|warn)$"}
+  }
+}`
+
+func TestSchema_Validate(t *testing.T) {
+	schema, err := LoadSchema(writeSchema(t, testSchema))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		environ  []string
+		expected []string
+	}{
+		{
+			name:    "passing environment",
+			environ: []string{"DB_PASSWORD=supersecret", "LOG_LEVEL=info", "OTHER=x"},
+		},
+		{
+			name:    "optional variable may be absent",
+			environ: []string{"DB_PASSWORD=supersecret"},
+		},
+		{
+			name:     "missing required variable",
+			environ:  []string{"LOG_LEVEL=info"},
+			expected: []string{"required variable 'DB_PASSWORD' is missing or empty"},
+		},
+		{
+			name:     "empty required variable",
+			environ:  []string{"DB_PASSWORD="},
+			expected: []string{"required variable 'DB_PASSWORD' is missing or empty"},
+		},
+		{
+			name:    "pattern mismatches",
+			environ: []string{"DB_PASSWORD=short", "LOG_LEVEL=trace"},
+			expected: []string{
+				"variable 'DB_PASSWORD' does not match pattern '^.{8,}$'",
+				"variable 'LOG_LEVEL' does not match pattern '^(debug|info|warn)$'",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := schema.Validate(tt.environ)
+			if len(errs) != len(tt.expected) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.expected), len(errs), errs)
+			}
+			for i, err := range errs {
+				if err.Error() != tt.expected[i] {
+					t.Errorf("Expected error '%s', got '%s'", tt.expected[i], err.Error())
+				}
+				if strings.Contains(err.Error(), "short") {
+					t.Errorf("Error must not contain the variable value: %s", err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadSchema_Errors(t *testing.T) {
+	if _, err := LoadSchema(writeSchema(t, `{"variables": {"X": {"pattern": "("}}}`)); err == nil {
+		t.Error("Expected error for invalid pattern")
+	}
+	if _, err := LoadSchema(writeSchema(t, `not json`)); err == nil {
+		t.Error("Expected error for invalid JSON")
+	}
+}
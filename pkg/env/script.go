@@ -0,0 +1,89 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ScriptCommandMarker separates secret declarations from the command in a secretinit script
+const ScriptCommandMarker = "--- command ---"
+
+// Script represents a self-contained secretinit script file.
+// The file starts with a shebang line (e.g. "#!/usr/bin/env secretinit"),
+// followed by KEY=value declarations, a "--- command ---" marker line and the command to run.
+type Script struct {
+	Vars    map[string]string // Declared variables, typically secretinit: addresses
+	Command string            // Command line to execute (multiple lines are joined with spaces)
+}
+
+// maxShebangLength bounds how much of a file IsScriptFile reads, like the kernel's limit on shebang lines
+const maxShebangLength = 256
+
+// IsScriptFile reports whether the given path is a file starting with a secretinit shebang line.
+// Only the first line is read, up to maxShebangLength bytes.
+func IsScriptFile(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(io.LimitReader(file, maxShebangLength))
+	firstLine, err := reader.ReadString('\n')
+	if err != nil && firstLine == "" {
+		return false
+	}
+
+	return strings.HasPrefix(firstLine, "#!") && strings.Contains(firstLine, "secretinit")
+}
+
+// LoadScriptFile parses a secretinit script file into its variable declarations and command.
+// The declarations are read like a .env file (see LoadEnvFileEntries): quoted and multi-line
+// values, "export" prefixes, inline comments and $VAR references all work the same way.
+func LoadScriptFile(path string) (*Script, error) {
+	text, err := readEnvFileText(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// The shebang line is a comment to the .env parser, so line numbers stay those of the file.
+	// The marker is only found outside quoted values, which may contain the same text.
+	entries, marker, err := scanEnvTextUntil(text, path, ScriptCommandMarker)
+	if err != nil {
+		return nil, err
+	}
+	if marker == 0 {
+		return nil, fmt.Errorf("missing '%s' section in %s", ScriptCommandMarker, path)
+	}
+	decodeEnvEntries(entries)
+
+	script := &Script{Vars: make(map[string]string)}
+	for _, entry := range entries {
+		script.Vars[entry.Key] = entry.Value
+	}
+
+	var commandLines []string
+	lines := strings.Split(text, "\n")
+	for i, line := range lines[marker:] {
+		line = strings.TrimSpace(line)
+
+		// Skip empty lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == ScriptCommandMarker {
+			return nil, fmt.Errorf("duplicate command marker on line %d in %s", marker+i+1, path)
+		}
+		commandLines = append(commandLines, line)
+	}
+
+	if len(commandLines) == 0 {
+		return nil, fmt.Errorf("empty command section in %s", path)
+	}
+
+	script.Command = strings.Join(commandLines, " ")
+	return script, nil
+}
@@ -0,0 +1,160 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeScript(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "myscript")
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write script: %v", err)
+	}
+	return path
+}
+
+func TestLoadScriptFile(t *testing.T) {
+	path := writeScript(t, `#!/usr/bin/env secretinit
+# Secrets for the deploy job
+DB_PASS=secretinit:aws:sm:myapp/db:::password
+API=secretinit:git:https://api.example.com
+
+--- command ---
+deploy.sh --env prod
+  --verbose
+`)
+
+	if !IsScriptFile(path) {
+		t.Fatal("Expected file to be detected as a secretinit script")
+	}
+
+	script, err := LoadScriptFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if got := script.Vars["DB_PASS"]; got != "secretinit:aws:sm:myapp/db:::password" {
+		t.Errorf("Expected DB_PASS address, got '%s'", got)
+	}
+	if got := script.Vars["API"]; got != "secretinit:git:https://api.example.com" {
+		t.Errorf("Expected API address, got '%s'", got)
+	}
+	if len(script.Vars) != 2 {
+		t.Errorf("Expected 2 variables, got %d: %v", len(script.Vars), script.Vars)
+	}
+	if script.Command != "deploy.sh --env prod --verbose" {
+		t.Errorf("Unexpected command: '%s'", script.Command)
+	}
+}
+
+func TestLoadScriptFile_EnvFileSyntax(t *testing.T) {
+	t.Setenv("SCRIPT_TEST_REGION", "eu-west-1")
+	path := writeScript(t, `#!/usr/bin/env secretinit
+export DB_PASS=secretinit:aws:sm:myapp/db:::password # Production database
+APP_NAME="my app"
+LITERAL='$NOT_EXPANDED'
+CONFIG=secretinit:aws:ps:/${SCRIPT_TEST_REGION}/$APP_NAME/config
+CERT="-----BEGIN CERTIFICATE-----
+MIIB
+-----END CERTIFICATE-----"
+
+--- command ---
+deploy.sh
+`)
+
+	script, err := LoadScriptFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"DB_PASS":  "secretinit:aws:sm:myapp/db:::password",
+		"APP_NAME": "my app",
+		"LITERAL":  "$NOT_EXPANDED",
+		"CONFIG":   "secretinit:aws:ps:/eu-west-1/my app/config",
+		"CERT":     "-----BEGIN CERTIFICATE-----\nMIIB\n-----END CERTIFICATE-----",
+	}
+	if !reflect.DeepEqual(script.Vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, script.Vars)
+	}
+	if script.Command != "deploy.sh" {
+		t.Errorf("Unexpected command: '%s'", script.Command)
+	}
+}
+
+func TestLoadScriptFile_MarkerInQuotedValue(t *testing.T) {
+	path := writeScript(t, `#!/usr/bin/env secretinit
+NOTES="first part
+--- command ---
+second part"
+TOKEN=secretinit:git:https://example.com
+
+--- command ---
+myapp
+`)
+
+	script, err := LoadScriptFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"NOTES": "first part\n--- command ---\nsecond part",
+		"TOKEN": "secretinit:git:https://example.com",
+	}
+	if !reflect.DeepEqual(script.Vars, expected) {
+		t.Errorf("Expected %v, got %v", expected, script.Vars)
+	}
+	if script.Command != "myapp" {
+		t.Errorf("Unexpected command: '%s'", script.Command)
+	}
+}
+
+func TestLoadScriptFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name:    "missing command marker",
+			content: "#!/usr/bin/env secretinit\nTOKEN=secretinit:git:https://example.com\n",
+		},
+		{
+			name:    "empty command section",
+			content: "#!/usr/bin/env secretinit\nTOKEN=secretinit:git:https://example.com\n--- command ---\n",
+		},
+		{
+			name:    "invalid declaration",
+			content: "#!/usr/bin/env secretinit\nNOT_A_DECLARATION\n--- command ---\nmyapp\n",
+		},
+		{
+			name:    "unterminated quoted value",
+			content: "#!/usr/bin/env secretinit\nTOKEN=\"secretinit:git:https://example.com\n--- command ---\nmyapp\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadScriptFile(writeScript(t, tt.content)); err == nil {
+				t.Error("Expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestIsScriptFile(t *testing.T) {
+	if IsScriptFile(writeScript(t, "#!/bin/sh\necho hello\n")) {
+		t.Error("Expected plain shell script not to be detected as a secretinit script")
+	}
+	// The shebang is only looked for in the first maxShebangLength bytes
+	if IsScriptFile(writeScript(t, "#!"+strings.Repeat(" ", maxShebangLength)+"secretinit\n")) {
+		t.Error("Expected a first line longer than a shebang not to be read in full")
+	}
+	if IsScriptFile(filepath.Join(t.TempDir(), "missing")) {
+		t.Error("Expected missing file not to be detected as a secretinit script")
+	}
+}
@@ -0,0 +1,90 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadSecretsFile loads a secrets catalog mapping variable names to secret addresses,
+// one "NAME: address" entry per line (a YAML-style subset):
+//
+//	# Database
+//	DB_PASSWORD: aws:sm:myapp/db:::password
+//	API_TOKEN: "git:https://api.example.com"
+//
+// Blank lines and lines starting with '#' are ignored, values may be quoted and the
+// secretinit: prefix is optional. Addresses are returned without the prefix, like
+// ScanSecretEnvVars. A repeated name is an error.
+func LoadSecretsFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, address, found := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !found || !isEnvName(name) {
+			return nil, fmt.Errorf("%s:%d: expected 'NAME: address'", path, lineNumber)
+		}
+		address = unquoteSecretsFileValue(strings.TrimSpace(address))
+		address = strings.TrimPrefix(address, "secretinit:")
+		if address == "" {
+			return nil, fmt.Errorf("%s:%d: no secret address for %s", path, lineNumber, name)
+		}
+		if _, exists := secrets[name]; exists {
+			return nil, fmt.Errorf("%s:%d: %s is defined more than once", path, lineNumber, name)
+		}
+		secrets[name] = address
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secrets, nil
+}
+
+// MergeSecretVars adds the catalog entries of fromFile to scanned secret variables.
+// Variables already set inline (in the environment or .env files) take precedence.
+func MergeSecretVars(scanned, fromFile map[string]string) map[string]string {
+	merged := make(map[string]string, len(scanned)+len(fromFile))
+	for name, address := range fromFile {
+		merged[name] = address
+	}
+	for name, address := range scanned {
+		merged[name] = address
+	}
+	return merged
+}
+
+// unquoteSecretsFileValue strips matching single or double quotes around a value
+func unquoteSecretsFileValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// isEnvName reports whether name is a valid shell variable name
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if !isEnvNameByte(name[i], i == 0) {
+			return false
+		}
+	}
+	return true
+}
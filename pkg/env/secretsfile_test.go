@@ -0,0 +1,65 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadSecretsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.yaml")
+	content := `# Secret catalog
+DB_PASSWORD: aws:sm:myapp/db:::password
+
+API_TOKEN: "secretinit:git:https://api.example.com:::token"
+FLAGS: 'aws:ps:/myapp/flags:::enabled||false'
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadSecretsFile(path)
+	if err != nil {
+		t.Fatalf("LoadSecretsFile() error = %v", err)
+	}
+	expected := map[string]string{
+		"DB_PASSWORD": "aws:sm:myapp/db:::password",
+		"API_TOKEN":   "git:https://api.example.com:::token",
+		"FLAGS":       "aws:ps:/myapp/flags:::enabled||false",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("LoadSecretsFile() = %v, want %v", got, expected)
+	}
+}
+
+func TestLoadSecretsFile_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no-separator.yaml": "DB_PASSWORD aws:sm:myapp/db\n",
+		"bad-name.yaml":     "DB-PASSWORD: aws:sm:myapp/db\n",
+		"empty-value.yaml":  "DB_PASSWORD:\n",
+		"duplicate.yaml":    "A: aws:sm:one\nA: aws:sm:two\n",
+	}
+
+	dir := t.TempDir()
+	for name, content := range tests {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadSecretsFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMergeSecretVars_InlineWins(t *testing.T) {
+	scanned := map[string]string{"DB_PASSWORD": "git:https://db.example.com:::password"}
+	fromFile := map[string]string{"DB_PASSWORD": "aws:sm:myapp/db:::password", "API_TOKEN": "aws:sm:myapp/api"}
+
+	got := MergeSecretVars(scanned, fromFile)
+	expected := map[string]string{"DB_PASSWORD": "git:https://db.example.com:::password", "API_TOKEN": "aws:sm:myapp/api"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("MergeSecretVars() = %v, want %v", got, expected)
+	}
+}
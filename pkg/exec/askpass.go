@@ -0,0 +1,63 @@
+package exec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteAskpass writes a GIT_ASKPASS helper into a new private temp directory. The helper answers
+// git's "Username for ..." prompt with username and any other prompt with password.
+// With owner set the files belong to that user, so a --run-as child can execute the helper.
+// Returns the helper path and a cleanup that removes the directory.
+func WriteAskpass(username, password string, owner *RunAs) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "secretinit-askpass-")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to create askpass directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	script := "#!/bin/sh\n" +
+		"case \"$1\" in\n" +
+		"Username*) printf '%s\\n' " + shellQuote(username) + " ;;\n" +
+		"*) printf '%s\\n' " + shellQuote(password) + " ;;\n" +
+		"esac\n"
+	path := filepath.Join(dir, "askpass")
+	if err := os.WriteFile(path, []byte(script), 0700); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write askpass helper: %w", err)
+	}
+	if err := os.Chmod(path, 0700); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("failed to write askpass helper: %w", err)
+	}
+
+	if owner != nil {
+		for _, p := range []string{dir, path} {
+			if err := os.Chown(p, int(owner.UID), int(owner.GID)); err != nil {
+				cleanup()
+				return "", nil, fmt.Errorf("failed to hand askpass helper to uid %d: %w", owner.UID, err)
+			}
+		}
+	}
+	return path, cleanup, nil
+}
+
+// AskpassEnv removes passVar from env and points GIT_ASKPASS at helper instead
+func AskpassEnv(env []string, passVar, helper string) []string {
+	result := make([]string, 0, len(env)+1)
+	for _, entry := range env {
+		name, _, _ := strings.Cut(entry, "=")
+		if name == passVar || name == "GIT_ASKPASS" {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return append(result, "GIT_ASKPASS="+helper)
+}
+
+// shellQuote quotes s as a single POSIX shell word
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
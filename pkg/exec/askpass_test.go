@@ -0,0 +1,62 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWriteAskpass(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	helper, cleanup, err := WriteAskpass("alice", "it's s3cret", nil)
+	if err != nil {
+		t.Fatalf("WriteAskpass() error = %v", err)
+	}
+
+	info, err := os.Stat(helper)
+	if err != nil {
+		t.Fatalf("failed to stat askpass helper: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0700 {
+		t.Errorf("askpass helper mode = %o, want 0700", perm)
+	}
+
+	prompts := []struct {
+		prompt string
+		want   string
+	}{
+		{"Username for 'https://example.com': ", "alice"},
+		{"Password for 'https://alice@example.com': ", "it's s3cret"},
+	}
+	for _, p := range prompts {
+		out, err := exec.Command(helper, p.prompt).Output()
+		if err != nil {
+			t.Fatalf("askpass %q failed: %v", p.prompt, err)
+		}
+		if got := strings.TrimSuffix(string(out), "\n"); got != p.want {
+			t.Errorf("askpass %q = %q, want %q", p.prompt, got, p.want)
+		}
+	}
+
+	cleanup()
+	if _, err := os.Stat(filepath.Dir(helper)); !os.IsNotExist(err) {
+		t.Errorf("askpass directory still exists after cleanup: %v", err)
+	}
+}
+
+func TestAskpassEnv(t *testing.T) {
+	// Only the password variable goes, not unrelated variables that happen to share its value
+	env := []string{"PATH=/bin", "API_USER=alice", "API_PASS=s3cret", "DB_PASSWORD=s3cret", "GIT_ASKPASS=/old"}
+	got := AskpassEnv(env, "API_PASS", "/tmp/askpass")
+	want := []string{"PATH=/bin", "API_USER=alice", "DB_PASSWORD=s3cret", "GIT_ASKPASS=/tmp/askpass"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("AskpassEnv() = %v, want %v", got, want)
+	}
+}
@@ -2,11 +2,15 @@ package exec
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/logging"
 )
 
 // parseCommand parses a command string into executable and arguments
@@ -59,68 +63,185 @@ func parseCommand(cmdStr string) (string, []string) {
 	return args[0], args[1:]
 }
 
+// ParseCommandLine is a public wrapper for parseCommand that returns the full argument list
+// This is used by other packages that need to split a command string without a shell
+func ParseCommandLine(cmdStr string) []string {
+	executable, args := parseCommand(cmdStr)
+	if executable == "" {
+		return nil
+	}
+	return append([]string{executable}, args...)
+}
+
+// PostCondition selects when the post-command runs, based on the main command's exit code
+type PostCondition int
+
+const (
+	PostAlways    PostCondition = iota // Run after every main command (default)
+	PostOnSuccess                      // Run only when the main command exits 0
+	PostOnFailure                      // Run only when the main command fails
+)
+
+// shouldRun reports whether the post-command runs after a main command that exited with exitCode
+func (c PostCondition) shouldRun(exitCode int) bool {
+	switch c {
+	case PostOnSuccess:
+		return exitCode == 0
+	case PostOnFailure:
+		return exitCode != 0
+	default:
+		return true
+	}
+}
+
+// Options configures ExecuteCommandWithHooks
+type Options struct {
+	PreCommand     string                            // Command executed before the main process
+	PostCommand    string                            // Command executed after the main process (see PostWhen)
+	PostWhen       PostCondition                     // When PostCommand runs (default: always)
+	NoPostOnSignal bool                              // Skip PostCommand when the main command was killed by a signal
+	ScrubValues    []string                          // Secret values masked in the output of the pre and post commands
+	ScrubMain      bool                              // Also mask ScrubValues in the main command's output, which then goes through pipes instead of the terminal
+	RunAs          *RunAs                            // User and group the main command runs as (nil keeps the current user)
+	LazySecrets    map[string]string                 // Values served on demand over LazyFD to the main command, from LazyEnv
+	Verbose        bool                              // Log the "[MAIN] Running:" line even when stdout is not a terminal
+	Cleanup        func()                            // Called once all commands finished, before exiting (e.g. to remove a WriteAskpass helper)
+	ReloadSignal   os.Signal                         // Signal that triggers Reload while the main command runs (see ParseReloadSignal)
+	Reload         func() (map[string]string, error) // Re-resolves the secrets and returns the values to scrub from then on; on success ReloadSignal is passed on to the main command
+	RetryFailed    func() (resolved, pending bool)   // Retries the secrets skipped at startup; once one resolves, Reload runs as if ReloadSignal was received
+	RetryInterval  time.Duration                     // Initial wait between RetryFailed calls, doubled while nothing resolves
+	DebugLog       func(string, ...interface{})      // Debug logger
+	InfoLog        func(string, ...interface{})      // Info logger
+}
+
 // ExecuteCommandWithHooks executes the given command with optional pre/post commands.
 // It includes proper signal handling and ensures post commands run even if main command fails.
-func ExecuteCommandWithHooks(args []string, env []string, preCommand, postCommand string, debugLog func(string, ...interface{}), infoLog func(string, ...interface{})) {
+func ExecuteCommandWithHooks(args []string, env []string, opts Options) {
+	debugLog, infoLog := opts.DebugLog, opts.InfoLog
+	preCommand, postCommand := opts.PreCommand, opts.PostCommand
+	cleanup := opts.Cleanup
+	if cleanup == nil {
+		cleanup = func() {}
+	}
+
 	if len(args) == 0 {
-		fmt.Fprintln(os.Stderr, "Error: No command provided to execute.")
+		logging.Printf(logging.LevelError, "Error: No command provided to execute.")
+		cleanup()
 		os.Exit(1)
 	}
 
+	// Set up hook output writers, masking secret values when requested
+	var stdout, stderr io.Writer = os.Stdout, os.Stderr
+	var scrubWriters []*ScrubWriter
+	if len(opts.ScrubValues) > 0 {
+		scrubStdout := NewScrubWriter(os.Stdout, opts.ScrubValues)
+		scrubStderr := NewScrubWriter(os.Stderr, opts.ScrubValues)
+		defer scrubStderr.Close()
+		defer scrubStdout.Close()
+		stdout, stderr = scrubStdout, scrubStderr
+		scrubWriters = []*ScrubWriter{scrubStdout, scrubStderr}
+	}
+
 	// Execute pre-command if specified
 	if preCommand != "" {
 		debugLog("Executing pre-command: %s", preCommand)
 		infoLog("[PRE] Running: %s", preCommand)
-		exitCode, err := executeCommand(preCommand, env, debugLog)
+		exitCode, err := executeCommand(preCommand, env, stdout, stderr, debugLog)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "[PRE] Command failed with exit code %d: %v\n", exitCode, err)
+			logging.Printf(logging.LevelError, "[PRE] Command failed with exit code %d: %v", exitCode, err)
+			flushWriters(stdout, stderr)
+			cleanup()
 			os.Exit(exitCode)
 		}
 		infoLog("[PRE] Completed successfully")
 	}
 
-	// Track exit code for proper cleanup
+	// Track exit code for proper cleanup, and whether a signal ended the main command
 	var exitCode int
+	var signaled bool
 
-	// Ensure post-command runs even if main command fails
+	// Ensure post-command runs even if main command fails, unless limited by PostWhen
 	defer func() {
-		if postCommand != "" {
+		if postCommand != "" && !opts.PostWhen.shouldRun(exitCode) {
+			debugLog("Skipping post-command for main command exit code %d", exitCode)
+		} else if postCommand != "" && signaled && opts.NoPostOnSignal {
+			infoLog("[POST] Skipped: main command was killed by a signal")
+		} else if postCommand != "" {
 			debugLog("Executing post-command: %s", postCommand)
 			infoLog("[POST] Running: %s", postCommand)
-			postExitCode, err := executeCommand(postCommand, env, debugLog)
+			postExitCode, err := executeCommand(postCommand, env, stdout, stderr, debugLog)
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "[POST] Command failed with exit code %d: %v\n", postExitCode, err)
+				logging.Printf(logging.LevelError, "[POST] Command failed with exit code %d: %v", postExitCode, err)
 				// Don't exit here - we want to preserve the main command's exit code
 			} else {
 				infoLog("[POST] Completed successfully")
 			}
 		}
+		cleanup()
 		// Exit with the recorded exit code after post-command completes
 		if exitCode != 0 {
+			flushWriters(stdout, stderr)
 			os.Exit(exitCode)
 		}
 	}()
 
-	// Execute main command
-	infoLog("[MAIN] Running: %s%s", args[0], func() string {
-		if len(args) > 1 {
-			return " " + strings.Join(args[1:], " ")
-		}
-		return ""
-	}())
+	// Execute main command, announcing it unless the output is piped to another tool
+	if showBanner(opts.Verbose, os.Stdout) {
+		infoLog("[MAIN] Running: %s%s", args[0], func() string {
+			if len(args) > 1 {
+				return " " + strings.Join(args[1:], " ")
+			}
+			return ""
+		}())
+	}
 
 	cmd := exec.Command(args[0], args[1:]...)
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
+	// The main command keeps the terminal unless its output is scrubbed too
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if opts.ScrubMain {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	}
+
+	// Drop privileges for the main command after secrets were resolved with ours
+	if opts.RunAs != nil {
+		if err := applyRunAs(cmd, opts.RunAs); err != nil {
+			logging.Printf(logging.LevelError, "Failed to start command: %v", err)
+			exitCode = 1
+			return
+		}
+		debugLog("Running main command as uid %d, gid %d", opts.RunAs.UID, opts.RunAs.GID)
+	}
+
+	// Serve lazily delivered secrets to the main command only
+	closeLazyChild := func() {}
+	if opts.LazySecrets != nil {
+		var err error
+		closeLazyChild, err = startLazyServer(cmd, opts.LazySecrets, debugLog)
+		if err != nil {
+			logging.Printf(logging.LevelError, "Failed to start command: %v", err)
+			exitCode = 1
+			return
+		}
+	}
 
 	// Handle signals
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
-	if err := cmd.Start(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to start command: %v\n", err)
+	// Register the reload signal before the command starts, so an early signal isn't fatal
+	var reloadChan chan os.Signal
+	if opts.ReloadSignal != nil && opts.Reload != nil {
+		reloadChan = make(chan os.Signal, 1)
+		signal.Notify(reloadChan, opts.ReloadSignal)
+		defer signal.Stop(reloadChan)
+	}
+
+	err := cmd.Start()
+	closeLazyChild()
+	if err != nil {
+		logging.Printf(logging.LevelError, "Failed to start command: %v", err)
 		exitCode = 1
 		return
 	}
@@ -133,10 +254,24 @@ func ExecuteCommandWithHooks(args []string, env []string, preCommand, postComman
 			cmd.Process.Signal(sig)
 		}
 	}()
+	if reloadChan != nil {
+		go reloadOnSignal(reloadChan, cmd.Process, scrubReloaded(opts.Reload, scrubWriters...), infoLog)
+	}
+	// Keep retrying the secrets skipped at startup, reloading once one of them resolves
+	if reloadChan != nil && opts.RetryFailed != nil && opts.RetryInterval > 0 {
+		stopRetry := make(chan struct{})
+		defer close(stopRetry)
+		go retryFailed(stopRetry, opts.RetryInterval, opts.RetryFailed, func() {
+			infoLog("[RETRY] A secret skipped at startup resolved, reloading")
+			triggerReload(reloadChan, opts.ReloadSignal)
+		})
+	}
 
 	if err := cmd.Wait(); err != nil {
 		if exitError, ok := err.(*exec.ExitError); ok {
 			exitCode = exitError.ExitCode()
+			// ExitCode is -1 when the process did not exit on its own
+			signaled = exitCode == -1
 			infoLog("[MAIN] Command exited with code: %d", exitCode)
 		} else {
 			exitCode = 1
@@ -147,9 +282,65 @@ func ExecuteCommandWithHooks(args []string, env []string, preCommand, postComman
 	}
 }
 
+// reloadOnSignal runs reload for each signal received on sigChan and passes the signal on to process
+// once it succeeded. Signals arriving during a reload are coalesced into one more reload.
+// A failed reload is logged and the main command keeps running with its previous secrets.
+func reloadOnSignal(sigChan <-chan os.Signal, process signaler, reload func() error, infoLog func(string, ...interface{})) {
+	for sig := range sigChan {
+		infoLog("[RELOAD] Received %v, resolving secrets again", sig)
+		if err := reload(); err != nil {
+			logging.Printf(logging.LevelError, "[RELOAD] Failed, the main command keeps its previous secrets: %v", err)
+			continue
+		}
+		infoLog("[RELOAD] Completed, sending %v to the main command", sig)
+		if err := process.Signal(sig); err != nil {
+			logging.Printf(logging.LevelError, "[RELOAD] Failed to signal the main command: %v", err)
+		}
+	}
+}
+
+// scrubReloaded wraps reload so the values it resolves are masked by writers as well. The previous
+// values stay masked too, since the commands may still print them.
+func scrubReloaded(reload func() (map[string]string, error), writers ...*ScrubWriter) func() error {
+	return func() error {
+		secrets, err := reload()
+		if err != nil {
+			return err
+		}
+		values := make([]string, 0, len(secrets))
+		for _, value := range secrets {
+			values = append(values, value)
+		}
+		for _, w := range writers {
+			w.AddValues(values)
+		}
+		return nil
+	}
+}
+
+// signaler is the part of *os.Process used to pass signals on
+type signaler interface {
+	Signal(sig os.Signal) error
+}
+
+// showBanner reports whether the "[MAIN] Running:" line is logged: with verbose always,
+// otherwise only when stdout is a terminal, so piped and captured output stays clean
+func showBanner(verbose bool, stdout *os.File) bool {
+	return verbose || isTerminal(stdout)
+}
+
+// flushWriters flushes buffered scrub writers before the process exits
+func flushWriters(writers ...io.Writer) {
+	for _, w := range writers {
+		if scrubWriter, ok := w.(*ScrubWriter); ok {
+			scrubWriter.Close()
+		}
+	}
+}
+
 // executeCommand executes a command string by parsing it directly (no shell)
 // Returns the exit code and error for better error reporting
-func executeCommand(cmdStr string, env []string, debugLog func(string, ...interface{})) (int, error) {
+func executeCommand(cmdStr string, env []string, stdout, stderr io.Writer, debugLog func(string, ...interface{})) (int, error) {
 	executable, args := parseCommand(cmdStr)
 	if executable == "" {
 		return 1, fmt.Errorf("empty command")
@@ -159,8 +350,8 @@ func executeCommand(cmdStr string, env []string, debugLog func(string, ...interf
 
 	cmd := exec.Command(executable, args...)
 	cmd.Env = env
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 	cmd.Stdin = os.Stdin
 
 	err := cmd.Run()
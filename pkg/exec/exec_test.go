@@ -0,0 +1,143 @@
+package exec
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func noopLog(string, ...interface{}) {}
+
+func TestExecuteCommand_HookOutputScrubbed(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	var stdout, stderr bytes.Buffer
+	scrubStdout := NewScrubWriter(&stdout, []string{"hunter2-password"})
+	scrubStderr := NewScrubWriter(&stderr, []string{"hunter2-password"})
+
+	env := []string{"PATH=/usr/bin:/bin", "TOKEN=hunter2-password"}
+	exitCode, err := executeCommand(`sh -c "echo token is $TOKEN; echo $TOKEN >&2"`, env, scrubStdout, scrubStderr, noopLog)
+	if err != nil {
+		t.Fatalf("Unexpected error (exit code %d): %v", exitCode, err)
+	}
+	scrubStdout.Close()
+	scrubStderr.Close()
+
+	if stdout.String() != "token is ****\n" {
+		t.Errorf("Expected masked stdout, got %q", stdout.String())
+	}
+	if stderr.String() != "****\n" {
+		t.Errorf("Expected masked stderr, got %q", stderr.String())
+	}
+}
+
+func TestPostCondition_ShouldRun(t *testing.T) {
+	tests := []struct {
+		condition PostCondition
+		exitCode  int
+		expected  bool
+	}{
+		{PostAlways, 0, true},
+		{PostAlways, 1, true},
+		{PostOnSuccess, 0, true},
+		{PostOnSuccess, 2, false},
+		{PostOnFailure, 0, false},
+		{PostOnFailure, 2, true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.condition.shouldRun(tt.exitCode); got != tt.expected {
+			t.Errorf("PostCondition(%d).shouldRun(%d) = %v, want %v", tt.condition, tt.exitCode, got, tt.expected)
+		}
+	}
+}
+
+// TestPostOnSignalHelper is run as a separate process by TestExecuteCommandWithHooks_NoPostOnSignal,
+// since ExecuteCommandWithHooks exits with the main command's exit code
+func TestPostOnSignalHelper(t *testing.T) {
+	if os.Getenv("SECRETINIT_TEST_POST_MARKER") == "" {
+		t.Skip("helper process for TestExecuteCommandWithHooks_NoPostOnSignal")
+	}
+	ExecuteCommandWithHooks([]string{"sh", "-c", os.Getenv("SECRETINIT_TEST_MAIN")}, os.Environ(), Options{
+		PostCommand:    "touch " + os.Getenv("SECRETINIT_TEST_POST_MARKER"),
+		NoPostOnSignal: os.Getenv("SECRETINIT_TEST_NO_POST_ON_SIGNAL") == "1",
+		DebugLog:       noopLog,
+		InfoLog:        noopLog,
+	})
+	os.Exit(0)
+}
+
+func TestExecuteCommandWithHooks_NoPostOnSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires POSIX signals")
+	}
+
+	tests := []struct {
+		name           string
+		main           string
+		noPostOnSignal bool
+		wantPost       bool
+	}{
+		{name: "signaled, post runs by default", main: "kill -TERM $$", wantPost: true},
+		{name: "signaled, post skipped", main: "kill -TERM $$", noPostOnSignal: true, wantPost: false},
+		{name: "failed normally, post runs", main: "exit 3", noPostOnSignal: true, wantPost: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			marker := filepath.Join(t.TempDir(), "post-ran")
+			noPost := "0"
+			if tt.noPostOnSignal {
+				noPost = "1"
+			}
+			cmd := exec.Command(os.Args[0], "-test.run=^TestPostOnSignalHelper$")
+			cmd.Env = append(os.Environ(),
+				"SECRETINIT_TEST_POST_MARKER="+marker,
+				"SECRETINIT_TEST_MAIN="+tt.main,
+				"SECRETINIT_TEST_NO_POST_ON_SIGNAL="+noPost,
+			)
+			if err := cmd.Run(); err == nil {
+				t.Fatal("expected secretinit to exit non-zero after the main command failed")
+			}
+
+			_, err := os.Stat(marker)
+			if ran := err == nil; ran != tt.wantPost {
+				t.Errorf("post-command ran = %v, want %v", ran, tt.wantPost)
+			}
+		})
+	}
+}
+
+func TestShowBanner(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	defer writer.Close()
+
+	if showBanner(false, writer) {
+		t.Error("banner shown for piped stdout")
+	}
+	if !showBanner(true, writer) {
+		t.Error("banner hidden for piped stdout with --verbose")
+	}
+
+	// A pseudo-terminal stands in for an interactive stdout
+	if runtime.GOOS != "linux" {
+		t.Skip("pseudo-terminal check is Linux only")
+	}
+	pty, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal available: %v", err)
+	}
+	defer pty.Close()
+	if !showBanner(false, pty) {
+		t.Error("banner hidden for a terminal stdout")
+	}
+}
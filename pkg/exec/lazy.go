@@ -0,0 +1,84 @@
+package exec
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/lazyenv"
+)
+
+// LazyFD is the file descriptor the main command inherits for lazily delivered secrets
+const LazyFD = 3
+
+// LazyEnv replaces each variable of env named in secrets (the resolved secrets and the mapping
+// targets copied from them) with a lazy reference the child can resolve over LazyFD (see package lazyenv).
+// Variables are matched by name only, so an unrelated variable that happens to hold the same value is kept.
+// Returns the new environment and the values to serve, keyed by variable name.
+func LazyEnv(env []string, secrets map[string]string) ([]string, map[string]string) {
+	lazy := make([]string, 0, len(env))
+	served := make(map[string]string)
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		if _, isSecret := secrets[name]; isSecret && value != "" {
+			served[name] = value
+			entry = name + "=" + lazyenv.Reference(LazyFD, name)
+		}
+		lazy = append(lazy, entry)
+	}
+	return lazy, served
+}
+
+// ServeLazySecrets answers "GET NAME" requests read from conn with the values in secrets,
+// one line per request, until conn is closed by the child
+func ServeLazySecrets(conn io.ReadWriter, secrets map[string]string, debugLog func(string, ...interface{})) error {
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var reply string
+		command, name, _ := strings.Cut(strings.TrimSuffix(line, "\n"), " ")
+		value, exists := secrets[name]
+		switch {
+		case command != "GET":
+			reply = "ERR unknown request\n"
+		case !exists:
+			reply = fmt.Sprintf("ERR unknown secret %s\n", name)
+		default:
+			debugLog("Serving lazy secret %s", name)
+			reply = "OK " + base64.StdEncoding.EncodeToString([]byte(value)) + "\n"
+		}
+		if _, err := io.WriteString(conn, reply); err != nil {
+			return err
+		}
+	}
+}
+
+// startLazyServer gives cmd an inherited LazyFD connected to a goroutine serving secrets.
+// Call the returned function once cmd has started, to close the child's end in this process.
+func startLazyServer(cmd *exec.Cmd, secrets map[string]string, debugLog func(string, ...interface{})) (func(), error) {
+	parent, child, err := lazyChannel()
+	if err != nil {
+		return nil, err
+	}
+
+	// ExtraFiles[0] becomes fd 3 in the child
+	cmd.ExtraFiles = append([]*os.File{child}, cmd.ExtraFiles...)
+	go func() {
+		defer parent.Close()
+		if err := ServeLazySecrets(parent, secrets, debugLog); err != nil {
+			debugLog("Lazy secret server stopped: %v", err)
+		}
+	}()
+	return func() { child.Close() }, nil
+}
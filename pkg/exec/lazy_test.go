@@ -0,0 +1,123 @@
+package exec
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/lazyenv"
+)
+
+func TestLazyEnv(t *testing.T) {
+	env := []string{"PATH=/usr/bin", "DB_PASS=s3cret", "API_KEY=key", "DATABASE_PASSWORD=s3cret", "EMPTY="}
+	got, served := LazyEnv(env, map[string]string{"DB_PASS": "s3cret", "API_KEY": "key", "DATABASE_PASSWORD": "s3cret", "EMPTY": ""})
+
+	// Mapping targets passed with the secrets are served too, under their own name
+	expected := []string{"PATH=/usr/bin", "DB_PASS=secretinit-fd://3#DB_PASS", "API_KEY=secretinit-fd://3#API_KEY", "DATABASE_PASSWORD=secretinit-fd://3#DATABASE_PASSWORD", "EMPTY="}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("LazyEnv() = %v, want %v", got, expected)
+	}
+	expectedServed := map[string]string{"DB_PASS": "s3cret", "API_KEY": "key", "DATABASE_PASSWORD": "s3cret"}
+	if fmt.Sprint(served) != fmt.Sprint(expectedServed) {
+		t.Errorf("LazyEnv() served = %v, want %v", served, expectedServed)
+	}
+}
+
+func TestLazyEnv_SameValueNotSecret(t *testing.T) {
+	env := []string{"SHLVL=1", "FEATURE_ENABLED=1", "HOME=/home/app", "APP_DIR=/home/app"}
+	got, served := LazyEnv(env, map[string]string{"FEATURE_ENABLED": "1", "APP_DIR": "/home/app"})
+
+	// Variables only sharing a secret's value keep their value
+	expected := []string{"SHLVL=1", "FEATURE_ENABLED=secretinit-fd://3#FEATURE_ENABLED", "HOME=/home/app", "APP_DIR=secretinit-fd://3#APP_DIR"}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("LazyEnv() = %v, want %v", got, expected)
+	}
+	if len(served) != 2 {
+		t.Errorf("LazyEnv() served = %v, want FEATURE_ENABLED and APP_DIR only", served)
+	}
+}
+
+func TestServeLazySecrets(t *testing.T) {
+	requests := strings.NewReader("GET DB_PASS\nGET MISSING\nPUT DB_PASS\n")
+	var replies bytes.Buffer
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{requests, &replies}
+
+	if err := ServeLazySecrets(conn, map[string]string{"DB_PASS": "s3cret"}, noopLog); err != nil {
+		t.Fatalf("ServeLazySecrets() error = %v", err)
+	}
+
+	expected := "OK czNjcmV0\nERR unknown secret MISSING\nERR unknown request\n"
+	if replies.String() != expected {
+		t.Errorf("replies = %q, want %q", replies.String(), expected)
+	}
+}
+
+// TestLazyChildHelper is run as the child process by TestStartLazyServer_ChildRequestsOne
+func TestLazyChildHelper(t *testing.T) {
+	if os.Getenv("SECRETINIT_TEST_LAZY_CHILD") != "1" {
+		t.Skip("helper process for TestStartLazyServer_ChildRequestsOne")
+	}
+	value, err := lazyenv.Getenv("DB_PASS")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	fmt.Printf("DB_PASS=%s API_KEY=%s\n", value, os.Getenv("API_KEY"))
+	os.Exit(0)
+}
+
+func TestStartLazyServer_ChildRequestsOne(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("--lazy is not supported on Windows")
+	}
+
+	secrets := map[string]string{"DB_PASS": "s3cret", "API_KEY": "key-value", "TOKEN": "token-value"}
+	env := []string{"SECRETINIT_TEST_LAZY_CHILD=1", "DB_PASS=s3cret", "API_KEY=key-value", "TOKEN=token-value"}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLazyChildHelper$")
+	cmd.Env, secrets = LazyEnv(env, secrets)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	var servedMutex sync.Mutex
+	var served []string
+	debugLog := func(format string, args ...interface{}) {
+		servedMutex.Lock()
+		defer servedMutex.Unlock()
+		served = append(served, fmt.Sprintf(format, args...))
+	}
+
+	closeChild, err := startLazyServer(cmd, secrets, debugLog)
+	if err != nil {
+		t.Fatalf("startLazyServer() error = %v", err)
+	}
+	err = cmd.Start()
+	closeChild()
+	if err != nil {
+		t.Fatalf("failed to start child: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child failed: %v\n%s", err, stderr.String())
+	}
+
+	// The child resolved the secret it asked for, the others stayed references
+	expected := "DB_PASS=s3cret API_KEY=secretinit-fd://3#API_KEY\n"
+	if stdout.String() != expected {
+		t.Errorf("child output = %q, want %q", stdout.String(), expected)
+	}
+
+	servedMutex.Lock()
+	defer servedMutex.Unlock()
+	if len(served) != 1 || served[0] != "Serving lazy secret DB_PASS" {
+		t.Errorf("expected only DB_PASS to be served, got %v", served)
+	}
+}
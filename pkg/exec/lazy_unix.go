@@ -0,0 +1,26 @@
+//go:build !windows
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// lazyChannel returns the two connected ends of a Unix socket pair
+func lazyChannel() (parent, child *os.File, err error) {
+	// Hold ForkLock so no other command inherits the sockets before they are close-on-exec;
+	// the child's end reaches the main command through ExtraFiles
+	syscall.ForkLock.RLock()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err == nil {
+		syscall.CloseOnExec(fds[0])
+		syscall.CloseOnExec(fds[1])
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create lazy secret channel: %w", err)
+	}
+	return os.NewFile(uintptr(fds[0]), "secretinit-lazy"), os.NewFile(uintptr(fds[1]), "secretinit-lazy-child"), nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+// lazyChannel is not supported on Windows, where commands can't inherit extra file descriptors
+func lazyChannel() (parent, child *os.File, err error) {
+	return nil, nil, errors.New("--lazy is not supported on Windows")
+}
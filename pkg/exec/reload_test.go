@@ -0,0 +1,204 @@
+//go:build !windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestParseReloadSignal(t *testing.T) {
+	for _, name := range []string{"SIGHUP", "HUP", "sighup"} {
+		if sig, err := ParseReloadSignal(name); err != nil || sig != syscall.SIGHUP {
+			t.Errorf("ParseReloadSignal(%q) = %v, %v, want SIGHUP", name, sig, err)
+		}
+	}
+	if sig, err := ParseReloadSignal("SIGUSR1"); err != nil || sig != syscall.SIGUSR1 {
+		t.Errorf("ParseReloadSignal(SIGUSR1) = %v, %v", sig, err)
+	}
+	for _, name := range []string{"SIGTERM", "SIGINT", "KILL", ""} {
+		if _, err := ParseReloadSignal(name); err == nil {
+			t.Errorf("ParseReloadSignal(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+// recordingProcess records the signals passed on to the main command
+type recordingProcess struct {
+	signals []os.Signal
+}
+
+func (p *recordingProcess) Signal(sig os.Signal) error {
+	p.signals = append(p.signals, sig)
+	return nil
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	sigChan := make(chan os.Signal, 2)
+	sigChan <- syscall.SIGHUP
+	sigChan <- syscall.SIGHUP
+	close(sigChan)
+
+	reloads := 0
+	process := &recordingProcess{}
+	reloadOnSignal(sigChan, process, func() error {
+		reloads++
+		if reloads == 2 {
+			return errors.New("backend unavailable")
+		}
+		return nil
+	}, noopLog)
+
+	if reloads != 2 {
+		t.Errorf("expected 2 reloads, got %d", reloads)
+	}
+	// The failed second reload must not signal the main command
+	if len(process.signals) != 1 || process.signals[0] != syscall.SIGHUP {
+		t.Errorf("expected a single SIGHUP for the main command, got %v", process.signals)
+	}
+}
+
+// TestReloadSignalHelper is run as a separate process by TestExecuteCommandWithHooks_ReloadSignal,
+// which signals it like an external file watcher would
+func TestReloadSignalHelper(t *testing.T) {
+	reloadLog := os.Getenv("SECRETINIT_TEST_RELOAD_LOG")
+	if reloadLog == "" {
+		t.Skip("helper process for TestExecuteCommandWithHooks_ReloadSignal")
+	}
+	ExecuteCommandWithHooks([]string{"sh", "-c", os.Getenv("SECRETINIT_TEST_MAIN")}, os.Environ(), Options{
+		ReloadSignal: syscall.SIGHUP,
+		Reload: func() (map[string]string, error) {
+			file, err := os.OpenFile(reloadLog, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+			if err != nil {
+				return nil, err
+			}
+			defer file.Close()
+			_, err = file.WriteString("reload\n")
+			return nil, err
+		},
+		DebugLog: noopLog,
+		InfoLog:  noopLog,
+	})
+	os.Exit(0)
+}
+
+func TestExecuteCommandWithHooks_ReloadSignal(t *testing.T) {
+	dir := t.TempDir()
+	reloadLog := filepath.Join(dir, "reloads")
+	ready := filepath.Join(dir, "ready")
+	childLog := filepath.Join(dir, "child")
+
+	// The main command records the reload signal and exits, so the helper exits too
+	main := "trap 'echo hup >> " + childLog + "; exit 0' HUP; touch " + ready + "; while :; do sleep 0.05; done"
+	cmd := exec.Command(os.Args[0], "-test.run=^TestReloadSignalHelper$")
+	cmd.Env = append(os.Environ(), "SECRETINIT_TEST_RELOAD_LOG="+reloadLog, "SECRETINIT_TEST_MAIN="+main)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			t.Fatal("main command did not start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := cmd.Process.Signal(syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("helper exited with %v, want 0", err)
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("main command was not signaled after the reload")
+	}
+
+	reloads, _ := os.ReadFile(reloadLog)
+	if got := strings.Count(string(reloads), "reload\n"); got != 1 {
+		t.Errorf("expected a single reload, got %d", got)
+	}
+	child, _ := os.ReadFile(childLog)
+	if string(child) != "hup\n" {
+		t.Errorf("expected the main command to get one SIGHUP, got %q", child)
+	}
+}
+
+// TestRetryFailedHelper is run as a separate process by TestExecuteCommandWithHooks_RetryFailed.
+// Its skipped secret resolves on the second retry, without any signal from outside.
+func TestRetryFailedHelper(t *testing.T) {
+	reloadLog := os.Getenv("SECRETINIT_TEST_RETRY_LOG")
+	if reloadLog == "" {
+		t.Skip("helper process for TestExecuteCommandWithHooks_RetryFailed")
+	}
+	attempts := 0
+	ExecuteCommandWithHooks([]string{"sh", "-c", os.Getenv("SECRETINIT_TEST_MAIN")}, os.Environ(), Options{
+		ReloadSignal: syscall.SIGHUP,
+		Reload: func() (map[string]string, error) {
+			// Don't signal the main command before it trapped the signal
+			for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+				if _, err := os.Stat(os.Getenv("SECRETINIT_TEST_READY")); err == nil {
+					break
+				}
+			}
+			return map[string]string{"API_KEY": "key"}, os.WriteFile(reloadLog, []byte("API_KEY=key\n"), 0600)
+		},
+		RetryFailed: func() (bool, bool) {
+			attempts++
+			return attempts == 2, attempts < 2
+		},
+		RetryInterval: 10 * time.Millisecond,
+		DebugLog:      noopLog,
+		InfoLog:       noopLog,
+	})
+	os.Exit(0)
+}
+
+func TestExecuteCommandWithHooks_RetryFailed(t *testing.T) {
+	dir := t.TempDir()
+	reloadLog := filepath.Join(dir, "secrets.env")
+	ready := filepath.Join(dir, "ready")
+	childLog := filepath.Join(dir, "child")
+
+	// The main command waits for the reload signal that follows the retried secret
+	main := "trap 'echo hup >> " + childLog + "; exit 0' HUP; touch " + ready + "; while :; do sleep 0.05; done"
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRetryFailedHelper$")
+	cmd.Env = append(os.Environ(), "SECRETINIT_TEST_RETRY_LOG="+reloadLog, "SECRETINIT_TEST_READY="+ready, "SECRETINIT_TEST_MAIN="+main)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("helper exited with %v, want 0", err)
+		}
+	case <-time.After(10 * time.Second):
+		cmd.Process.Kill()
+		t.Fatal("main command was not signaled after the skipped secret resolved")
+	}
+
+	if written, _ := os.ReadFile(reloadLog); string(written) != "API_KEY=key\n" {
+		t.Errorf("expected the reload to write the retried secret, got %q", written)
+	}
+	if child, _ := os.ReadFile(childLog); string(child) != "hup\n" {
+		t.Errorf("expected the main command to get one SIGHUP, got %q", child)
+	}
+}
@@ -0,0 +1,26 @@
+//go:build !windows
+
+package exec
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// reloadSignals are the signals --reload-signal accepts, by name without the SIG prefix
+var reloadSignals = map[string]syscall.Signal{
+	"HUP":  syscall.SIGHUP,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// ParseReloadSignal parses a --reload-signal name such as SIGHUP, HUP or USR1
+func ParseReloadSignal(name string) (os.Signal, error) {
+	sig, ok := reloadSignals[strings.TrimPrefix(strings.ToUpper(name), "SIG")]
+	if !ok {
+		return nil, fmt.Errorf("unsupported reload signal '%s': expected SIGHUP, SIGUSR1 or SIGUSR2", name)
+	}
+	return sig, nil
+}
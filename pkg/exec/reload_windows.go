@@ -0,0 +1,13 @@
+//go:build windows
+
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+// ParseReloadSignal is not supported on Windows, which has no reload signals
+func ParseReloadSignal(name string) (os.Signal, error) {
+	return nil, errors.New("--reload-signal is not supported on Windows")
+}
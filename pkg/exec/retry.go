@@ -0,0 +1,43 @@
+package exec
+
+import (
+	"os"
+	"time"
+)
+
+// maxRetryBackoff caps the wait between retries of secrets that keep failing
+const maxRetryBackoff = 5 * time.Minute
+
+// retryFailed calls retry every interval, doubling the wait up to maxRetryBackoff while nothing
+// resolves, until retry reports that no secrets are pending or stop is closed. Each time a pending
+// secret resolves, trigger is called and the wait starts over at interval.
+func retryFailed(stop <-chan struct{}, interval time.Duration, retry func() (resolved, pending bool), trigger func()) {
+	limit := max(maxRetryBackoff, interval)
+	wait := interval
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+
+		resolved, pending := retry()
+		if resolved {
+			trigger()
+			wait = interval
+		} else {
+			wait = min(2*wait, limit)
+		}
+		if !pending {
+			return
+		}
+	}
+}
+
+// triggerReload queues sig on reloadChan as if it had been received, unless a reload is already queued
+func triggerReload(reloadChan chan os.Signal, sig os.Signal) {
+	select {
+	case reloadChan <- sig:
+	default:
+	}
+}
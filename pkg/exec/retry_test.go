@@ -0,0 +1,67 @@
+package exec
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRetryFailed(t *testing.T) {
+	// The backend is down for two attempts, then one secret resolves, then the last one
+	attempts := 0
+	triggers := 0
+	retry := func() (bool, bool) {
+		attempts++
+		switch attempts {
+		case 1, 2:
+			return false, true
+		case 3:
+			return true, true
+		default:
+			return true, false
+		}
+	}
+	retryFailed(make(chan struct{}), time.Millisecond, retry, func() { triggers++ })
+
+	if attempts != 4 {
+		t.Errorf("expected 4 attempts until nothing is pending, got %d", attempts)
+	}
+	if triggers != 2 {
+		t.Errorf("expected a reload for each attempt that resolved a secret, got %d", triggers)
+	}
+}
+
+func TestRetryFailed_Stop(t *testing.T) {
+	stop := make(chan struct{})
+	close(stop)
+
+	done := make(chan struct{})
+	go func() {
+		retryFailed(stop, time.Hour, func() (bool, bool) {
+			t.Error("retry called after stop")
+			return false, true
+		}, func() {})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("retryFailed did not return after stop")
+	}
+}
+
+func TestTriggerReload(t *testing.T) {
+	reloadChan := make(chan os.Signal, 1)
+	triggerReload(reloadChan, syscall.SIGINT)
+	triggerReload(reloadChan, syscall.SIGINT) // Coalesced with the queued reload instead of blocking
+
+	if sig := <-reloadChan; sig != syscall.SIGINT {
+		t.Errorf("expected the queued signal, got %v", sig)
+	}
+	select {
+	case sig := <-reloadChan:
+		t.Errorf("expected a single queued reload, got another %v", sig)
+	default:
+	}
+}
@@ -0,0 +1,81 @@
+package exec
+
+import (
+	"fmt"
+	"os/user"
+	"strconv"
+	"strings"
+)
+
+// RunAs is the user and group the main command runs as (--run-as uid:gid)
+type RunAs struct {
+	UID uint32
+	GID uint32
+}
+
+// ParseRunAs parses a "uid:gid" run-as spec. Both parts may be numeric IDs or names;
+// a user without a group ("app" or "1000") uses the user's primary group.
+func ParseRunAs(spec string) (*RunAs, error) {
+	userPart, groupPart, hasGroup := strings.Cut(spec, ":")
+	if userPart == "" || (hasGroup && groupPart == "") {
+		return nil, fmt.Errorf("invalid run-as '%s': expected uid:gid", spec)
+	}
+
+	uid, primaryGID, err := lookupRunAsUser(userPart, !hasGroup)
+	if err != nil {
+		return nil, fmt.Errorf("invalid run-as user '%s': %w", userPart, err)
+	}
+
+	gid := primaryGID
+	if hasGroup {
+		gid, err = lookupRunAsGroup(groupPart)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run-as group '%s': %w", groupPart, err)
+		}
+	}
+
+	return &RunAs{UID: uid, GID: gid}, nil
+}
+
+// lookupRunAsUser resolves a user name or numeric uid, and the user's primary gid when needed
+func lookupRunAsUser(name string, needPrimaryGroup bool) (uint32, uint32, error) {
+	id, numErr := strconv.ParseUint(name, 10, 32)
+	if numErr == nil && !needPrimaryGroup {
+		return uint32(id), 0, nil
+	}
+
+	lookup := user.Lookup
+	if numErr == nil {
+		lookup = user.LookupId
+	}
+	u, err := lookup(name)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("non-numeric uid %s", u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return 0, 0, fmt.Errorf("non-numeric gid %s", u.Gid)
+	}
+	return uint32(uid), uint32(gid), nil
+}
+
+// lookupRunAsGroup resolves a group name or numeric gid
+func lookupRunAsGroup(name string) (uint32, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, err
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("non-numeric gid %s", g.Gid)
+	}
+	return uint32(gid), nil
+}
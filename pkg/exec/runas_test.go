@@ -0,0 +1,72 @@
+package exec
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseRunAs(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected *RunAs
+		wantErr  bool
+	}{
+		{spec: "1000:1000", expected: &RunAs{UID: 1000, GID: 1000}},
+		{spec: "65534:65533", expected: &RunAs{UID: 65534, GID: 65533}},
+		{spec: "", wantErr: true},
+		{spec: ":1000", wantErr: true},
+		{spec: "1000:", wantErr: true},
+		{spec: "no-such-user-secretinit:1000", wantErr: true},
+		{spec: "1000:no-such-group-secretinit", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseRunAs(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.expected != nil && *got != *tt.expected {
+				t.Errorf("got %+v, want %+v", *got, *tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseRunAs_UserName(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("user names are resolved from the Unix user database")
+	}
+	got, err := ParseRunAs("root")
+	if err != nil {
+		t.Skipf("root user not found: %v", err)
+	}
+	if got.UID != 0 || got.GID != 0 {
+		t.Errorf("Expected root to resolve to 0:0, got %+v", *got)
+	}
+}
+
+func TestApplyRunAs_ChildCredentials(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("--run-as is not supported on Windows")
+	}
+	if os.Getuid() != 0 {
+		t.Skip("dropping privileges requires running the tests as root")
+	}
+
+	cmd := exec.Command("sh", "-c", "id -u; id -g")
+	if err := applyRunAs(cmd, &RunAs{UID: 65534, GID: 65533}); err != nil {
+		t.Fatalf("applyRunAs failed: %v", err)
+	}
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("child failed: %v", err)
+	}
+	if got := strings.Fields(string(out)); len(got) != 2 || got[0] != "65534" || got[1] != "65533" {
+		t.Errorf("Expected child to run as 65534:65533, got %q", string(out))
+	}
+}
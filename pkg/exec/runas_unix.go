@@ -0,0 +1,21 @@
+//go:build !windows
+
+package exec
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// applyRunAs makes cmd run with the given uid and gid, dropping supplementary groups
+func applyRunAs(cmd *exec.Cmd, runAs *RunAs) error {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Credential = &syscall.Credential{
+		Uid:    runAs.UID,
+		Gid:    runAs.GID,
+		Groups: []uint32{},
+	}
+	return nil
+}
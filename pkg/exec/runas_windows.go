@@ -0,0 +1,13 @@
+//go:build windows
+
+package exec
+
+import (
+	"errors"
+	"os/exec"
+)
+
+// applyRunAs is not supported on Windows
+func applyRunAs(cmd *exec.Cmd, runAs *RunAs) error {
+	return errors.New("--run-as is not supported on Windows")
+}
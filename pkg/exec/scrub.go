@@ -0,0 +1,118 @@
+package exec
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ScrubMask replaces secret values in scrubbed output
+const ScrubMask = "****"
+
+// minScrubLength is the shortest value that gets scrubbed.
+// Very short values (e.g. "1", "on") would mask unrelated output everywhere.
+const minScrubLength = 4
+
+// ScrubWriter wraps an io.Writer and masks known secret values in everything written to it.
+// Output is passed on as soon as it can't be the start of a secret, so values split across
+// writes or lines (PEM keys, JSON documents) are still masked while at most the length of the
+// longest secret is held back; call Close to flush it.
+type ScrubWriter struct {
+	out    io.Writer
+	values []string
+	buf    []byte
+	mutex  sync.Mutex
+}
+
+// NewScrubWriter creates a ScrubWriter masking the given values
+func NewScrubWriter(out io.Writer, values []string) *ScrubWriter {
+	return &ScrubWriter{
+		out:    out,
+		values: scrubValues(values),
+	}
+}
+
+// AddValues masks values from now on, next to the values already masked
+func (w *ScrubWriter) AddValues(values []string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.values = scrubValues(append(append([]string(nil), w.values...), values...))
+}
+
+// scrubValues returns the distinct values long enough to scrub, longest first,
+// so a secret that contains another secret is masked as a whole
+func scrubValues(values []string) []string {
+	unique := make(map[string]bool)
+	for _, value := range values {
+		if len(value) >= minScrubLength {
+			unique[value] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(unique))
+	for value := range unique {
+		sorted = append(sorted, value)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if len(sorted[i]) != len(sorted[j]) {
+			return len(sorted[i]) > len(sorted[j])
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// Write buffers p and writes out everything that can no longer be part of a secret value,
+// with secret values masked
+func (w *ScrubWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buf = append(w.buf, p...)
+	if err := w.flush(false); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close flushes any held back output
+func (w *ScrubWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.flush(true)
+}
+
+// flush masks the buffered output and writes it out. Unless final, output from the first
+// position that may still turn out to be a secret value is kept for the next write.
+// The caller must hold the mutex.
+func (w *ScrubWriter) flush(final bool) error {
+	var out bytes.Buffer
+	i := 0
+scan:
+	for i < len(w.buf) {
+		rest := w.buf[i:]
+		for _, value := range w.values {
+			if bytes.HasPrefix(rest, []byte(value)) {
+				out.WriteString(ScrubMask)
+				i += len(value)
+				continue scan
+			}
+			if !final && len(rest) < len(value) && strings.HasPrefix(value, string(rest)) {
+				break scan // Wait for the rest of a possible secret
+			}
+		}
+		out.WriteByte(w.buf[i])
+		i++
+	}
+
+	w.buf = append(w.buf[:0], w.buf[i:]...)
+	if out.Len() == 0 {
+		return nil
+	}
+	_, err := w.out.Write(out.Bytes())
+	return err
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !windows
+
+package exec
+
+import "os"
+
+// isTerminal reports whether f is a character device, the closest check without termios
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
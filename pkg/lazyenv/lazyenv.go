@@ -0,0 +1,107 @@
+// Package lazyenv reads secrets that secretinit --lazy delivers on demand.
+//
+// With --lazy, secretinit sets each secret variable to a reference such as
+// "secretinit-fd://3#DB_PASSWORD" and serves the value over the inherited file descriptor 3
+// only when the child asks for it. Getenv resolves such references and returns plain values as is:
+//
+//	password, err := lazyenv.Getenv("DB_PASSWORD")
+//
+// The protocol is line based: the child writes "GET NAME\n" and secretinit replies with
+// "OK BASE64VALUE\n" or "ERR MESSAGE\n". Requests are answered in order, one at a time.
+package lazyenv
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Scheme prefixes lazy secret references
+const Scheme = "secretinit-fd://"
+
+// Reference returns the reference for the secret name served on fd
+func Reference(fd int, name string) string {
+	return fmt.Sprintf("%s%d#%s", Scheme, fd, name)
+}
+
+// ParseReference splits a "secretinit-fd://FD#NAME" reference. ok is false for other values.
+func ParseReference(value string) (fd int, name string, ok bool) {
+	rest, found := strings.CutPrefix(value, Scheme)
+	if !found {
+		return 0, "", false
+	}
+	fdText, name, found := strings.Cut(rest, "#")
+	fd, err := strconv.Atoi(fdText)
+	if !found || err != nil || fd < 0 || name == "" {
+		return 0, "", false
+	}
+	return fd, name, true
+}
+
+// conn is a connection to the secretinit process on one inherited file descriptor
+type conn struct {
+	file   *os.File
+	reader *bufio.Reader
+}
+
+var (
+	connsMutex sync.Mutex
+	conns      = make(map[int]*conn)
+)
+
+// Getenv returns the value of the environment variable name, fetching it from secretinit
+// when it holds a lazy reference. Unset variables return an empty string, like os.Getenv.
+func Getenv(name string) (string, error) {
+	value := os.Getenv(name)
+	fd, secretName, ok := ParseReference(value)
+	if !ok {
+		return value, nil
+	}
+	return Fetch(fd, secretName)
+}
+
+// Fetch requests the secret name from secretinit over the inherited file descriptor fd
+func Fetch(fd int, name string) (string, error) {
+	if strings.ContainsAny(name, "\r\n") {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+
+	connsMutex.Lock()
+	defer connsMutex.Unlock()
+
+	c, exists := conns[fd]
+	if !exists {
+		file := os.NewFile(uintptr(fd), "secretinit-fd")
+		if file == nil {
+			return "", fmt.Errorf("file descriptor %d is not open (was the command started by secretinit --lazy?)", fd)
+		}
+		c = &conn{file: file, reader: bufio.NewReader(file)}
+		conns[fd] = c
+	}
+
+	if _, err := fmt.Fprintf(c.file, "GET %s\n", name); err != nil {
+		return "", fmt.Errorf("failed to request secret %s from secretinit: %w", name, err)
+	}
+	reply, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s from secretinit: %w", name, err)
+	}
+
+	status, payload, _ := strings.Cut(strings.TrimSuffix(reply, "\n"), " ")
+	switch status {
+	case "OK":
+		decoded, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return "", fmt.Errorf("invalid reply for secret %s: %w", name, err)
+		}
+		return string(decoded), nil
+	case "ERR":
+		return "", fmt.Errorf("secretinit could not serve secret %s: %s", name, payload)
+	default:
+		return "", fmt.Errorf("invalid reply for secret %s from secretinit", name)
+	}
+}
@@ -0,0 +1,33 @@
+package lazyenv
+
+import "testing"
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		value string
+		fd    int
+		name  string
+		ok    bool
+	}{
+		{value: "secretinit-fd://3#DB_PASSWORD", fd: 3, name: "DB_PASSWORD", ok: true},
+		{value: Reference(7, "API_KEY"), fd: 7, name: "API_KEY", ok: true},
+		{value: "plain-value"},
+		{value: "secretinit-fd://3"},
+		{value: "secretinit-fd://x#NAME"},
+		{value: "secretinit-fd://3#"},
+	}
+
+	for _, tt := range tests {
+		fd, name, ok := ParseReference(tt.value)
+		if fd != tt.fd || name != tt.name || ok != tt.ok {
+			t.Errorf("ParseReference(%q) = %d, %q, %v, want %d, %q, %v", tt.value, fd, name, ok, tt.fd, tt.name, tt.ok)
+		}
+	}
+}
+
+func TestGetenv_PlainValue(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+	if got, err := Getenv("DB_HOST"); err != nil || got != "db.internal" {
+		t.Errorf("Getenv() = %q, %v, want the plain value", got, err)
+	}
+}
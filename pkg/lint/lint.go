@@ -0,0 +1,121 @@
+package lint
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// maxFileSize is the largest file that gets scanned; bigger files are usually generated or binary
+const maxFileSize = 1 << 20
+
+// addressPattern matches secretinit: references up to whitespace, a quote character or a
+// backslash, so a quoted literal ending in an escape ("secretinit:git:https://host\n") stops before it
+var addressPattern = regexp.MustCompile("secretinit:[^\\s\"'`\\\\]+")
+
+// skippedDirs are directories that never contain hand-written secret references
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// Finding describes a secretinit: reference that failed validation
+type Finding struct {
+	File    string
+	Line    int
+	Address string
+	Err     error
+}
+
+// String formats the finding as "file:line: message"
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d: invalid secret address '%s': %v", f.File, f.Line, parser.RedactAddress(f.Address), f.Err)
+}
+
+// LintDir walks dir and validates every secretinit: reference found in text files.
+// It returns the invalid references sorted by file and line, and the number of references checked.
+func LintDir(dir string) ([]Finding, int, error) {
+	var findings []Finding
+	checked := 0
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && skippedDirs[d.Name()] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil || info.Size() > maxFileSize {
+			return nil
+		}
+
+		fileFindings, fileChecked, err := lintFile(path)
+		if err != nil {
+			return err
+		}
+		findings = append(findings, fileFindings...)
+		checked += fileChecked
+		return nil
+	})
+	if err != nil {
+		return nil, checked, err
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+	return findings, checked, nil
+}
+
+// lintFile validates the secretinit: references in a single file, skipping binary files
+func lintFile(path string) ([]Finding, int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, 0, nil // Binary file
+	}
+
+	var findings []Finding
+	checked := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), maxFileSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		for _, match := range addressPattern.FindAllString(scanner.Text(), -1) {
+			address := strings.TrimPrefix(match, "secretinit:")
+			checked++
+			if _, err := parser.ParseSecretString(address); err != nil {
+				findings = append(findings, Finding{File: path, Line: lineNum, Address: match, Err: err})
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error reading %s: %v", path, err)
+	}
+
+	return findings, checked, nil
+}
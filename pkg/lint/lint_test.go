@@ -0,0 +1,85 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFixture(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create fixture dir: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write fixture: %v", err)
+	}
+}
+
+func TestLintDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, ".env", `DB_PASS=secretinit:aws:sm:myapp/db:::password
+API=secretinit:git:https://api.example.com
+BROKEN=secretinit:aws:myapp
+`)
+	writeFixture(t, dir, "deploy/k8s.yaml", `env:
+  - name: TOKEN
+    value: "secretinit:awss:sm:myapp/token"
+  - name: CERT
+    value: "secretinit:azure:kv:vault/cert:::private_key"
+`)
+	writeFixture(t, dir, ".git/config", "url = secretinit:bogus\n")
+	writeFixture(t, dir, "binary.bin", "secretinit:bogus\x00\x01")
+
+	findings, checked, err := LintDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if checked != 5 {
+		t.Errorf("Expected 5 checked references, got %d", checked)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("Expected 2 findings, got %d: %v", len(findings), findings)
+	}
+
+	if findings[0].File != filepath.Join(dir, ".env") || findings[0].Line != 3 || findings[0].Address != "secretinit:aws:myapp" {
+		t.Errorf("Unexpected first finding: %s", findings[0])
+	}
+	if findings[1].File != filepath.Join(dir, "deploy", "k8s.yaml") || findings[1].Line != 3 {
+		t.Errorf("Unexpected second finding: %s", findings[1])
+	}
+	if !strings.Contains(findings[1].String(), "unsupported backend: awss") {
+		t.Errorf("Expected finding message to include the parse error, got: %s", findings[1])
+	}
+}
+
+func TestLintDir_Clean(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "app.env", "TOKEN=secretinit:gcp:sm:proj/token\n")
+
+	findings, checked, err := LintDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(findings) != 0 || checked != 1 {
+		t.Errorf("Expected 1 valid reference and no findings, got %d checked, findings %v", checked, findings)
+	}
+}
+
+func TestLintDir_EscapedQuotedLiterals(t *testing.T) {
+	dir := t.TempDir()
+	writeFixture(t, dir, "main_test.go", `input := "API=secretinit:git:https://example.com\n---\n"
+cmd := "echo secretinit:aws:sm:myapp/db:::password\t| cat"
+`)
+
+	findings, checked, err := LintDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(findings) != 0 || checked != 2 {
+		t.Errorf("Expected 2 valid references and no findings, got %d checked, findings %v", checked, findings)
+	}
+}
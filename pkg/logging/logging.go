@@ -0,0 +1,57 @@
+// Package logging writes secretinit's log lines to stderr, as plain text by default or as one
+// JSON object per line with SECRETINIT_LOG_FORMAT=json. Callers decide which levels are enabled
+// and must never pass secret values or raw cache keys.
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log levels, as written to the "level" field of JSON lines
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+var (
+	mu         sync.Mutex
+	jsonFormat = strings.EqualFold(os.Getenv("SECRETINIT_LOG_FORMAT"), "json")
+)
+
+// jsonLine is one log line in JSON format
+type jsonLine struct {
+	Level string `json:"level"`
+	Msg   string `json:"msg"`
+	TS    string `json:"ts"`
+}
+
+// Printf writes one log line at level to stderr. Plain text debug lines are prefixed with
+// [DEBUG]; other levels print the message as is.
+func Printf(level, format string, args ...interface{}) {
+	write(os.Stderr, jsonFormat, level, fmt.Sprintf(format, args...), time.Now())
+}
+
+// write formats a single log line for w
+func write(w io.Writer, asJSON bool, level, msg string, ts time.Time) {
+	var line string
+	if asJSON {
+		data, _ := json.Marshal(jsonLine{Level: level, Msg: msg, TS: ts.UTC().Format(time.RFC3339Nano)})
+		line = string(data) + "\n"
+	} else if level == LevelDebug {
+		line = "[DEBUG] " + msg + "\n"
+	} else {
+		line = msg + "\n"
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	io.WriteString(w, line)
+}
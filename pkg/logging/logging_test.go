@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		asJSON bool
+		level  string
+		msg    string
+		want   string
+	}{
+		{name: "text debug", level: LevelDebug, msg: "Cache miss", want: "[DEBUG] Cache miss\n"},
+		{name: "text info", level: LevelInfo, msg: "[MAIN] Running: app", want: "[MAIN] Running: app\n"},
+		{name: "json", asJSON: true, level: LevelDebug, msg: `say "hi"`, want: `{"level":"debug","msg":"say \"hi\"","ts":"2026-01-02T03:04:05Z"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			write(&buf, tt.asJSON, tt.level, tt.msg, ts)
+			if buf.String() != tt.want {
+				t.Errorf("write() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWrite_JSONIsOneObjectPerLine(t *testing.T) {
+	var buf bytes.Buffer
+	write(&buf, true, LevelInfo, "line one\nline two", time.Now())
+
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 1 {
+		t.Fatalf("expected a single line, got %q", buf.String())
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal(lines[0], &decoded); err != nil {
+		t.Fatalf("log line is not JSON: %v", err)
+	}
+	if decoded["msg"] != "line one\nline two" || decoded["level"] != "info" || decoded["ts"] == "" {
+		t.Errorf("unexpected log line %v", decoded)
+	}
+}
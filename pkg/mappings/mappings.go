@@ -1,14 +1,126 @@
 package mappings
 
 import (
+	"encoding/base64"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+
+	"github.com/liifi/secretinit/pkg/backend"
 )
 
+// literalPrefix marks a mapping source as a literal value instead of a variable name (DB_SSLMODE=:require)
+const literalPrefix = ":"
+
+// defaultMarker starts the stored source of a TARGET:=VALUE mapping, which sets a literal value only
+// when TARGET is unset. No copy or literal source can start with '=', so it never clashes with them.
+const defaultMarker = "="
+
+// keyPathSeparator separates a variable source from a key to extract from its JSON value
+// (DB_USER=DBCREDS:::username), the same delimiter secret addresses use
+const keyPathSeparator = ":::"
+
+// transformSeparator separates a variable source from the transforms applied to its value (HOST|upper)
+const transformSeparator = "|"
+
+// transforms are the functions a mapping can apply to a copied value
+var transforms = map[string]func(string) string{
+	"base64": func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+	"upper":  strings.ToUpper,
+	"lower":  strings.ToLower,
+	"trim":   strings.TrimSpace,
+}
+
+// mappingValue returns the value a mapping source resolves to in env.
+// Literal sources (":value") always resolve to the text after the prefix.
+// Variable sources may select a key of a JSON value with ":::keyPath", extracted like in a secret
+// address, and may end in "|transform" suffixes, applied from left to right.
+func mappingValue(env map[string]string, source string) (string, bool, error) {
+	if value, isDefault := defaultValue(source); isDefault {
+		return value, true, nil
+	}
+	if literal, isLiteral := strings.CutPrefix(source, literalPrefix); isLiteral {
+		return literal, true, nil
+	}
+
+	parts := strings.Split(source, transformSeparator)
+	name, keyPath, hasKeyPath := strings.Cut(strings.TrimSpace(parts[0]), keyPathSeparator)
+	value, ok := env[name]
+	if ok && hasKeyPath {
+		var err error
+		if value, err = backend.ExtractJSONKey(value, keyPath); err != nil {
+			return "", false, fmt.Errorf("mapping source '%s': %w", source, err)
+		}
+	}
+	for _, name := range parts[1:] {
+		transform, exists := transforms[strings.TrimSpace(name)]
+		if !exists {
+			return "", false, fmt.Errorf("unknown mapping transform '%s' in '%s' (supported: %s)", strings.TrimSpace(name), source, transformNames())
+		}
+		value = transform(value)
+	}
+	return value, ok, nil
+}
+
+// defaultValue returns the value of a TARGET:=VALUE mapping source
+func defaultValue(source string) (string, bool) {
+	return strings.CutPrefix(source, defaultMarker)
+}
+
+// transformNames returns the supported transform names, sorted
+func transformNames() string {
+	names := make([]string, 0, len(transforms))
+	for name := range transforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// CheckMappings returns an error for the first mapping with an unknown transform,
+// so callers of ApplyMappingsToEnv can reject bad mappings up front
+func CheckMappings(mappings map[string]string) error {
+	targets := make([]string, 0, len(mappings))
+	for target := range mappings {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		if _, _, err := mappingValue(nil, mappings[target]); err != nil {
+			return fmt.Errorf("invalid mapping %s=%s: %w", target, mappings[target], err)
+		}
+	}
+	return nil
+}
+
+// CheckMappingKeys returns an error for the first mapping whose ":::keyPath" cannot be extracted
+// from its source variable in env (KEY=VALUE format), so a mistyped key or a source that is not
+// JSON fails before ApplyMappingsToEnv would skip the mapping
+func CheckMappingKeys(env []string, mappings map[string]string) error {
+	envMap := envToMap(env)
+	targets := make([]string, 0, len(mappings))
+	for target := range mappings {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+
+	for _, target := range targets {
+		if _, _, err := mappingValue(envMap, mappings[target]); err != nil {
+			return fmt.Errorf("invalid mapping %s=%s: %w", target, mappings[target], err)
+		}
+	}
+	return nil
+}
+
 // ApplyMappings takes a map of environment variables and a mapping string
 // and applies the mappings to the environment map.
 // The mapping string should be in the format "TARGET=SOURCE,TARGET2=SOURCE2".
+// A SOURCE starting with ':' is a literal value (e.g. "DB_SSLMODE=:require"), and "TARGET:=VALUE"
+// sets a literal value only if TARGET is not set yet (e.g. "LOG_LEVEL:=info").
+// A variable SOURCE may select a JSON key with ":::keyPath" (e.g. "DB_USER=DBCREDS:::username")
+// and may end in "|base64", "|upper", "|lower" or "|trim" to transform the copied value.
 func ApplyMappings(env map[string]string, mappings string) (map[string]string, error) {
 	if mappings == "" {
 		return env, nil
@@ -23,14 +135,21 @@ func ApplyMappings(env map[string]string, mappings string) (map[string]string, e
 	}
 
 	for _, pair := range mappingPairs {
-		parts := strings.Split(pair, "=")
-		if len(parts) != 2 {
+		target, source, ok := splitMappingPair(pair)
+		if !ok {
 			return nil, fmt.Errorf("invalid mapping format: %s", pair)
 		}
-		target := strings.TrimSpace(parts[0])
-		source := strings.TrimSpace(parts[1])
-		// Apply mapping: if source exists, set target to source's value
-		if value, ok := appliedEnv[source]; ok {
+		// Apply mapping: if source exists (or is a literal), set target to its value
+		value, ok, err := mappingValue(appliedEnv, source)
+		if err != nil {
+			return nil, err
+		}
+		if _, isDefault := defaultValue(source); isDefault {
+			if _, exists := appliedEnv[target]; exists {
+				continue
+			}
+		}
+		if ok {
 			appliedEnv[target] = value
 		}
 	}
@@ -68,7 +187,9 @@ func ParseMappingsFromArgs(args []string) (map[string]string, int) {
 	return mappings, cmdStart
 }
 
-// ParseMappingString parses a comma-separated string of TARGET=SOURCE mappings
+// ParseMappingString parses a comma-separated string of TARGET=SOURCE mappings.
+// A SOURCE starting with ':' is kept as a literal value (e.g. "DB_SSLMODE=:require"),
+// and "TARGET:=VALUE" is kept as a default for TARGET (e.g. "LOG_LEVEL:=info").
 func ParseMappingString(mappingStr string, mappings map[string]string) {
 	if mappingStr == "" {
 		return
@@ -76,34 +197,63 @@ func ParseMappingString(mappingStr string, mappings map[string]string) {
 
 	pairs := strings.Split(mappingStr, ",")
 	for _, pair := range pairs {
-		parts := strings.Split(pair, "=")
-		if len(parts) == 2 {
-			target := strings.TrimSpace(parts[0])
-			source := strings.TrimSpace(parts[1])
+		if target, source, ok := splitMappingPair(pair); ok {
 			mappings[target] = source
 		}
 	}
 }
 
-// ApplyMappingsToEnv applies mappings to a slice of environment variables (KEY=VALUE format)
-func ApplyMappingsToEnv(env []string, mappings map[string]string) []string {
+// splitMappingPair splits a TARGET=SOURCE pair. Literal sources (":value") may contain '=',
+// variable-name sources may not. A TARGET:=VALUE pair returns VALUE behind defaultMarker.
+func splitMappingPair(pair string) (string, string, bool) {
+	target, source, found := strings.Cut(pair, "=")
+	if !found {
+		return "", "", false
+	}
+	target = strings.TrimSpace(target)
+	source = strings.TrimSpace(source)
+	if name, isDefault := strings.CutSuffix(target, ":"); isDefault {
+		return strings.TrimSpace(name), defaultMarker + source, true
+	}
+	if !strings.HasPrefix(source, literalPrefix) && strings.Contains(source, "=") {
+		return "", "", false
+	}
+	return target, source, true
+}
+
+// ApplyMappingsToEnv applies mappings to a slice of environment variables (KEY=VALUE format).
+// Defaults (TARGET:=VALUE) are applied first and only to unset targets, so copies can read them.
+// Mappings with an unknown transform or a key that cannot be extracted are skipped,
+// use CheckMappings and CheckMappingKeys to reject them first.
+// It also returns the targets copied from one of the secrets variables, with their values, since
+// a ":::keyPath" or a transform makes a value that callers masking the secrets would not recognize.
+func ApplyMappingsToEnv(env []string, mappings map[string]string, secrets map[string]string) ([]string, map[string]string) {
+	derived := make(map[string]string)
 	if len(mappings) == 0 {
-		return env
+		return env, derived
 	}
 
-	envMap := make(map[string]string)
+	envMap := envToMap(env)
 
-	// Convert slice to map
-	for _, envVar := range env {
-		if parts := strings.SplitN(envVar, "=", 2); len(parts) == 2 {
-			envMap[parts[0]] = parts[1]
+	// Apply defaults for unset targets
+	for target, source := range mappings {
+		if value, isDefault := defaultValue(source); isDefault {
+			if _, exists := envMap[target]; !exists {
+				envMap[target] = value
+			}
 		}
 	}
 
-	// Apply mappings
+	// Apply mappings (copies from source variables and literal values)
 	for target, source := range mappings {
-		if value, exists := envMap[source]; exists {
+		if _, isDefault := defaultValue(source); isDefault {
+			continue
+		}
+		if value, exists, err := mappingValue(envMap, source); err == nil && exists {
 			envMap[target] = value
+			if _, isSecret := secrets[sourceName(source)]; isSecret {
+				derived[target] = value
+			}
 		}
 	}
 
@@ -113,5 +263,28 @@ func ApplyMappingsToEnv(env []string, mappings map[string]string) []string {
 		result = append(result, fmt.Sprintf("%s=%s", key, value))
 	}
 
-	return result
+	return result, derived
+}
+
+// sourceName returns the variable a mapping source copies from, or "" for literals and defaults
+func sourceName(source string) string {
+	if _, isDefault := defaultValue(source); isDefault {
+		return ""
+	}
+	if strings.HasPrefix(source, literalPrefix) {
+		return ""
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(strings.Split(source, transformSeparator)[0]), keyPathSeparator)
+	return name
+}
+
+// envToMap converts a slice of KEY=VALUE environment variables to a map
+func envToMap(env []string) map[string]string {
+	envMap := make(map[string]string, len(env))
+	for _, envVar := range env {
+		if parts := strings.SplitN(envVar, "=", 2); len(parts) == 2 {
+			envMap[parts[0]] = parts[1]
+		}
+	}
+	return envMap
 }
@@ -0,0 +1,238 @@
+package mappings
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestParseMappingString(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+	}{
+		{
+			name:     "source copy",
+			input:    "DB_USER=API_USER,DB_PASS=API_PASS",
+			expected: map[string]string{"DB_USER": "API_USER", "DB_PASS": "API_PASS"},
+		},
+		{
+			name:     "literal value",
+			input:    "DB_SSLMODE=:require",
+			expected: map[string]string{"DB_SSLMODE": ":require"},
+		},
+		{
+			name:     "literal value containing equals sign",
+			input:    "OPTS=:a=b",
+			expected: map[string]string{"OPTS": ":a=b"},
+		},
+		{
+			name:     "empty literal",
+			input:    "EMPTY=:",
+			expected: map[string]string{"EMPTY": ":"},
+		},
+		{
+			name:     "mixed with spaces",
+			input:    " DB_USER = API_USER , DB_SSLMODE = :require ",
+			expected: map[string]string{"DB_USER": "API_USER", "DB_SSLMODE": ":require"},
+		},
+		{
+			name:     "default value",
+			input:    "LOG_LEVEL:=info, OPTS := a=b",
+			expected: map[string]string{"LOG_LEVEL": "=info", "OPTS": "=a=b"},
+		},
+		{
+			name:     "invalid pairs are ignored",
+			input:    "NOPE,A=B=C,DB_USER=API_USER",
+			expected: map[string]string{"DB_USER": "API_USER"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			ParseMappingString(tt.input, got)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("got = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestApplyMappingsToEnv_Literals(t *testing.T) {
+	env := []string{"API_USER=bob", "require=from-variable"}
+	mappings := map[string]string{
+		"DB_USER":    "API_USER",
+		"DB_SSLMODE": ":require",
+		"COPY":       "require",
+		"MISSING":    "NOT_SET",
+		"EMPTY":      ":",
+	}
+
+	got, _ := ApplyMappingsToEnv(env, mappings, nil)
+	sort.Strings(got)
+
+	// A literal never reads a variable of the same name, and a plain source never becomes a literal
+	expected := []string{
+		"API_USER=bob",
+		"COPY=from-variable",
+		"DB_SSLMODE=require",
+		"DB_USER=bob",
+		"EMPTY=",
+		"require=from-variable",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got = %v, want %v", got, expected)
+	}
+}
+
+func TestApplyMappingsToEnv_Defaults(t *testing.T) {
+	env := []string{"LOG_LEVEL=debug", "EMPTY="}
+	mappings := map[string]string{
+		"LOG_LEVEL":  "=info",
+		"EMPTY":      "=filled",
+		"REGION":     "=eu-west-1",
+		"AWS_REGION": "REGION",
+	}
+
+	got, _ := ApplyMappingsToEnv(env, mappings, nil)
+	sort.Strings(got)
+
+	// Set variables keep their value, even when empty, and copies see the defaults
+	expected := []string{"AWS_REGION=eu-west-1", "EMPTY=", "LOG_LEVEL=debug", "REGION=eu-west-1"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got = %v, want %v", got, expected)
+	}
+}
+
+func TestApplyMappings_Defaults(t *testing.T) {
+	got, err := ApplyMappings(map[string]string{"LOG_LEVEL": "debug"}, "LOG_LEVEL:=info,PORT:=8080,COPY=PORT")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"LOG_LEVEL": "debug", "PORT": "8080", "COPY": "8080"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got = %v, want %v", got, expected)
+	}
+}
+
+func TestApplyMappings_Literals(t *testing.T) {
+	got, err := ApplyMappings(map[string]string{"API_USER": "bob"}, "DB_USER=API_USER,DB_SSLMODE=:require")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	expected := map[string]string{"API_USER": "bob", "DB_USER": "bob", "DB_SSLMODE": "require"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("got = %v, want %v", got, expected)
+	}
+
+	if _, err := ApplyMappings(map[string]string{}, "A=B=C"); err == nil {
+		t.Error("Expected error for invalid mapping format")
+	}
+}
+
+func TestApplyMappings_Transforms(t *testing.T) {
+	env := map[string]string{"MYAPP_PASS": "s3cret", "HOST": " db.example.com "}
+	got, err := ApplyMappings(env, "DB_PASS_B64=MYAPP_PASS|base64,HOST_UPPER=HOST|trim|upper,HOST_LOWER=HOST|lower,RAW=:a|upper")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"DB_PASS_B64": "czNjcmV0",
+		"HOST_UPPER":  "DB.EXAMPLE.COM",
+		"HOST_LOWER":  " db.example.com ",
+		"RAW":         "a|upper", // Literals are kept verbatim
+	}
+	for key, want := range expected {
+		if got[key] != want {
+			t.Errorf("%s = %q, want %q", key, got[key], want)
+		}
+	}
+
+	if _, err := ApplyMappings(env, "X=HOST|reverse"); err == nil || !strings.Contains(err.Error(), "reverse") {
+		t.Errorf("Expected error naming the unknown transform, got %v", err)
+	}
+}
+
+func TestApplyMappingsToEnv_Transforms(t *testing.T) {
+	mappingMap := make(map[string]string)
+	ParseMappingString("HOST_UPPER=HOST|upper", mappingMap)
+	if err := CheckMappings(mappingMap); err != nil {
+		t.Fatalf("CheckMappings() error = %v", err)
+	}
+
+	got, _ := ApplyMappingsToEnv([]string{"HOST=db"}, mappingMap, nil)
+	if !slices.Contains(got, "HOST_UPPER=DB") {
+		t.Errorf("ApplyMappingsToEnv() = %v, want HOST_UPPER=DB", got)
+	}
+
+	if err := CheckMappings(map[string]string{"X": "HOST|bogus"}); err == nil {
+		t.Error("Expected CheckMappings error for unknown transform")
+	}
+}
+
+func TestApplyMappingsToEnv_TransformedSecrets(t *testing.T) {
+	env := []string{"DB=hunter2pw", "HOST=db"}
+	mappingMap := make(map[string]string)
+	ParseMappingString("DB_B64=DB|base64,DB_UPPER=DB|upper|trim,HOST_UPPER=HOST|upper,MODE=:ro|upper", mappingMap)
+
+	// Only transforms of the secret are reported, not of plain variables or literals
+	_, derived := ApplyMappingsToEnv(env, mappingMap, map[string]string{"DB": "hunter2pw"})
+	expected := map[string]string{"DB_B64": "aHVudGVyMnB3", "DB_UPPER": "HUNTER2PW"}
+	if !reflect.DeepEqual(derived, expected) {
+		t.Errorf("ApplyMappingsToEnv() derived = %v, want %v", derived, expected)
+	}
+}
+
+func TestApplyMappingsToEnv_KeyPaths(t *testing.T) {
+	env := []string{`DBCREDS={"username":"app","password":"s3cret","db":{"host":"db.internal"}}`}
+	mappingMap := make(map[string]string)
+	ParseMappingString("DB_USER=DBCREDS:::username,DB_PASS=DBCREDS:::password,DB_HOST=DBCREDS:::db.host|upper,MISSING=NOPE:::x", mappingMap)
+	if err := CheckMappings(mappingMap); err != nil {
+		t.Fatalf("CheckMappings() error = %v", err)
+	}
+	if err := CheckMappingKeys(env, mappingMap); err != nil {
+		t.Fatalf("CheckMappingKeys() error = %v", err)
+	}
+
+	got, derived := ApplyMappingsToEnv(env, mappingMap, map[string]string{"DBCREDS": ""})
+	for _, want := range []string{"DB_USER=app", "DB_PASS=s3cret", "DB_HOST=DB.INTERNAL", env[0]} {
+		if !slices.Contains(got, want) {
+			t.Errorf("ApplyMappingsToEnv() = %v, want %s", got, want)
+		}
+	}
+	// Keys taken from a secret are reported, so they can be masked like the secret itself
+	expectedDerived := map[string]string{"DB_USER": "app", "DB_PASS": "s3cret", "DB_HOST": "DB.INTERNAL"}
+	if !reflect.DeepEqual(derived, expectedDerived) {
+		t.Errorf("ApplyMappingsToEnv() derived = %v, want %v", derived, expectedDerived)
+	}
+	for _, envVar := range got {
+		if strings.HasPrefix(envVar, "MISSING=") {
+			t.Errorf("mapping from an unset variable should be skipped, got %s", envVar)
+		}
+	}
+}
+
+func TestCheckMappingKeys_Errors(t *testing.T) {
+	env := []string{`DBCREDS={"username":"app"}`, "PLAIN=not-json"}
+	tests := map[string]string{
+		"DB_PASS=DBCREDS:::pasword": "key 'pasword' not found",
+		"DB_USER=PLAIN:::username":  "failed to parse secret value as JSON",
+	}
+	for spec, want := range tests {
+		mappingMap := make(map[string]string)
+		ParseMappingString(spec, mappingMap)
+		err := CheckMappingKeys(env, mappingMap)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("CheckMappingKeys(%s) error = %v, want %q", spec, err, want)
+		}
+	}
+
+	if _, err := ApplyMappings(map[string]string{"DBCREDS": `{"username":"app"}`}, "DB_PASS=DBCREDS:::password"); err == nil {
+		t.Error("Expected ApplyMappings error for a missing key")
+	}
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// DBCredential is a database login written to .pgpass and .my.cnf files.
+// Empty fields are wildcards in .pgpass and left out of .my.cnf.
+type DBCredential struct {
+	Name     string // Variable the login came from, e.g. DB for DB_USER and DB_PASS
+	Host     string
+	Port     string
+	Database string
+	User     string
+	Password string
+}
+
+// DBSuffixes are the variable suffixes of git multi-credential sets
+type DBSuffixes struct {
+	URL, User, Pass string
+}
+
+// DBCredentials collects database logins from resolved secrets, sorted by name:
+//   - multi-credential sets NAME_USER and NAME_PASS, with host, port and database from NAME_URL
+//     (e.g. postgres://db.internal:5432/app)
+//   - JSON values with username and password fields, like RDS secrets in Secrets Manager,
+//     with optional host, port and dbname (or database) fields
+func DBCredentials(secrets map[string]string, suffixes DBSuffixes) []DBCredential {
+	var creds []DBCredential
+	for name, value := range secrets {
+		if suffixes.Pass != "" && strings.HasSuffix(name, suffixes.Pass) {
+			prefix := strings.TrimSuffix(name, suffixes.Pass)
+			user, hasUser := secrets[prefix+suffixes.User]
+			if prefix == "" || !hasUser {
+				continue
+			}
+			cred := DBCredential{Name: prefix, User: user, Password: value}
+			if suffixes.URL != "" {
+				cred.Host, cred.Port, cred.Database = parseDBURL(secrets[prefix+suffixes.URL])
+			}
+			creds = append(creds, cred)
+			continue
+		}
+		if cred, ok := parseDBJSON(name, value); ok {
+			creds = append(creds, cred)
+		}
+	}
+	sort.Slice(creds, func(i, j int) bool { return creds[i].Name < creds[j].Name })
+	return creds
+}
+
+// parseDBURL returns the host, port and database (first path segment) of rawURL
+func parseDBURL(rawURL string) (host, port, database string) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return "", "", ""
+	}
+	database, _, _ = strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	return u.Hostname(), u.Port(), database
+}
+
+// parseDBJSON reads a login from a JSON object with username and password fields
+func parseDBJSON(name, value string) (DBCredential, bool) {
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		return DBCredential{}, false
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return DBCredential{}, false
+	}
+	field := func(keys ...string) string {
+		for _, key := range keys {
+			switch v := fields[key].(type) {
+			case string:
+				return v
+			case float64:
+				return fmt.Sprintf("%v", v) // Ports are usually numbers in RDS secrets
+			}
+		}
+		return ""
+	}
+
+	cred := DBCredential{
+		Name:     name,
+		Host:     field("host"),
+		Port:     field("port"),
+		Database: field("dbname", "database"),
+		User:     field("username", "user"),
+		Password: field("password"),
+	}
+	return cred, cred.User != "" && cred.Password != ""
+}
+
+// WritePgpass writes creds as PostgreSQL password file lines (host:port:database:user:password).
+// Empty fields become the * wildcard, and colons and backslashes in values are escaped.
+func WritePgpass(w io.Writer, creds []DBCredential) error {
+	for _, cred := range creds {
+		fields := []string{cred.Host, cred.Port, cred.Database, cred.User, cred.Password}
+		for i, field := range fields {
+			if field == "" && i < 4 {
+				fields[i] = "*"
+			} else {
+				fields[i] = escapePgpassField(field)
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(fields, ":")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMyCnf writes creds as a MySQL option file. A single login goes to the [client] group;
+// several logins get one [client_name] group each, selected with mysql --defaults-group-suffix=_name.
+func WriteMyCnf(w io.Writer, creds []DBCredential) error {
+	for i, cred := range creds {
+		group := "client"
+		if len(creds) > 1 {
+			group = "client_" + strings.ToLower(cred.Name)
+		}
+		if i > 0 {
+			if _, err := fmt.Fprintln(w); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "[%s]\n", group); err != nil {
+			return err
+		}
+		options := [][2]string{{"user", cred.User}, {"password", cred.Password}, {"host", cred.Host}, {"port", cred.Port}, {"database", cred.Database}}
+		for _, option := range options {
+			if option[1] == "" {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "%s=%s\n", option[0], quoteMyCnfValue(option[1])); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WritePgpassFile writes creds to path in .pgpass format. psql ignores the file unless only its
+// owner can read it.
+func WritePgpassFile(path string, creds []DBCredential, owner *FileOwner) error {
+	return writeDBCredentialFile(path, creds, owner, WritePgpass)
+}
+
+// WriteMyCnfFile writes creds to path in .my.cnf format, for mysql --defaults-extra-file
+func WriteMyCnfFile(path string, creds []DBCredential, owner *FileOwner) error {
+	return writeDBCredentialFile(path, creds, owner, WriteMyCnf)
+}
+
+// writeDBCredentialFile creates path with createSecretFile and writes creds to it with write
+func writeDBCredentialFile(path string, creds []DBCredential, owner *FileOwner, write func(io.Writer, []DBCredential) error) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := write(file, creds); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// escapePgpassField escapes the characters .pgpass gives a special meaning
+func escapePgpassField(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ":", `\:`).Replace(value)
+}
+
+// quoteMyCnfValue double-quotes value so MySQL keeps #, ; and surrounding spaces,
+// using the escape sequences option files understand for backslashes and control characters
+func quoteMyCnfValue(value string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "\b", `\b`).Replace(value)
+	return `"` + escaped + `"`
+}
@@ -0,0 +1,103 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+var testDBSuffixes = DBSuffixes{URL: "_URL", User: "_USER", Pass: "_PASS"}
+
+func TestDBCredentials(t *testing.T) {
+	secrets := map[string]string{
+		"PG_URL":    "postgres://db.internal:5432/app",
+		"PG_USER":   "admin",
+		"PG_PASS":   "s3cret",
+		"RDS":       `{"username":"app","password":"p:w","host":"rds.example.com","port":3306,"dbname":"orders","engine":"mysql"}`,
+		"LONE_PASS": "no user",
+		"API_KEY":   "not a login",
+		"CONFIG":    `{"enabled":true}`,
+	}
+
+	got := DBCredentials(secrets, testDBSuffixes)
+	want := []DBCredential{
+		{Name: "PG", Host: "db.internal", Port: "5432", Database: "app", User: "admin", Password: "s3cret"},
+		{Name: "RDS", Host: "rds.example.com", Port: "3306", Database: "orders", User: "app", Password: "p:w"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DBCredentials() = %+v, want %+v", got, want)
+	}
+}
+
+func TestWritePgpass(t *testing.T) {
+	creds := []DBCredential{
+		{Host: "db.internal", Port: "5432", Database: "app", User: "admin", Password: `p:ss\word`},
+		{Host: "api.example.com", User: "deploy", Password: "with space#"},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePgpass(&buf, creds); err != nil {
+		t.Fatalf("WritePgpass() error = %v", err)
+	}
+	want := "db.internal:5432:app:admin:p\\:ss\\\\word\n" +
+		"api.example.com:*:*:deploy:with space#\n"
+	if buf.String() != want {
+		t.Errorf("WritePgpass() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWriteMyCnf(t *testing.T) {
+	single := []DBCredential{{Name: "DB", Host: "db.internal", Port: "3306", User: "admin", Password: `p#ss "x" \ ;`}}
+	var buf bytes.Buffer
+	if err := WriteMyCnf(&buf, single); err != nil {
+		t.Fatalf("WriteMyCnf() error = %v", err)
+	}
+	want := "[client]\n" +
+		"user=\"admin\"\n" +
+		"password=\"p#ss \"x\" \\\\ ;\"\n" +
+		"host=\"db.internal\"\n" +
+		"port=\"3306\"\n"
+	if buf.String() != want {
+		t.Errorf("WriteMyCnf() = %q, want %q", buf.String(), want)
+	}
+
+	// Several logins get one group each
+	buf.Reset()
+	several := []DBCredential{{Name: "ORDERS", User: "a", Password: "1"}, {Name: "USERS", User: "b", Password: "2\n"}}
+	if err := WriteMyCnf(&buf, several); err != nil {
+		t.Fatalf("WriteMyCnf() error = %v", err)
+	}
+	want = "[client_orders]\nuser=\"a\"\npassword=\"1\"\n\n[client_users]\nuser=\"b\"\npassword=\"2\\n\"\n"
+	if buf.String() != want {
+		t.Errorf("WriteMyCnf() = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestWritePgpassFile_Permissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions only")
+	}
+
+	path := filepath.Join(t.TempDir(), ".pgpass")
+	if err := os.WriteFile(path, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePgpassFile(path, []DBCredential{{User: "u", Password: "p"}}, nil); err != nil {
+		t.Fatalf("WritePgpassFile() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "*:*:*:u:p\n" {
+		t.Errorf("file content = %q", data)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+}
@@ -0,0 +1,73 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FormatDotenvLine formats a single KEY=value line that env.LoadEnvFile reads back unchanged.
+// Values are written bare when possible and double-quoted with escapes otherwise.
+func FormatDotenvLine(key, value string) string {
+	return fmt.Sprintf("%s=%s", key, quoteDotenvValue(value))
+}
+
+// WriteDotenv writes secrets as KEY=value lines with sorted keys
+func WriteDotenv(w io.Writer, secrets map[string]string) error {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintln(w, FormatDotenvLine(key, secrets[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteDotenvFile writes secrets to path as sorted KEY=value lines that -e reads back unchanged
+func WriteDotenvFile(path string, secrets map[string]string, owner *FileOwner) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := WriteDotenv(file, secrets); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// quoteDotenvValue double-quotes value if it would not survive a bare KEY=value line:
+// surrounding whitespace is trimmed on load, and quotes, escapes and line breaks need escaping.
+func quoteDotenvValue(value string) string {
+	needsQuotes := value != strings.TrimSpace(value) ||
+		strings.ContainsAny(value, "\"\\$#\n\r\t")
+	if !needsQuotes {
+		return value
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
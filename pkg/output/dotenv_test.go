@@ -0,0 +1,107 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/env"
+)
+
+func TestFormatDotenvLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"plain value", "s3cret", "KEY=s3cret"},
+		{"contains equals", "a=b", "KEY=a=b"},
+		{"empty", "", "KEY="},
+		{"newline", "line1\nline2", `KEY="line1\nline2"`},
+		{"quotes and backslash", `say "hi" \o/`, `KEY="say \"hi\" \\o/"`},
+		{"surrounding spaces", " padded ", `KEY=" padded "`},
+		{"dollar sign", "pa$$word", `KEY="pa\$\$word"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDotenvLine("KEY", tt.value); got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteDotenvFile_RoundTrip(t *testing.T) {
+	secrets := map[string]string{
+		"PLAIN":     "value",
+		"PEM":       "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+		"QUOTED":    `"already quoted"`,
+		"BACKSLASH": `C:\path\n`,
+		"SPACES":    "  x  ",
+		"TABS":      "a\tb\r\n",
+		"HASH":      "#not-a-comment",
+		"EMPTY":     "",
+		"DOLLAR":    "$HOME",
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets.env")
+	if err := WriteDotenvFile(path, secrets, nil); err != nil {
+		t.Fatalf("WriteDotenvFile() error = %v", err)
+	}
+
+	loaded, err := env.LoadEnvFile(path)
+	if err != nil {
+		t.Fatalf("LoadEnvFile() error = %v", err)
+	}
+	for key, want := range secrets {
+		if got := loaded[key]; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Stat() error = %v", err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("permissions = %o, want 600", perm)
+		}
+	}
+}
+
+func TestWriteDotenvFile_TightensExistingPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions only")
+	}
+
+	path := filepath.Join(t.TempDir(), "secrets.env")
+	if err := os.WriteFile(path, []byte("OLD=1\nSTALE=2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteDotenvFile(path, map[string]string{"NEW": "1"}, nil); err != nil {
+		t.Fatalf("WriteDotenvFile() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "NEW=1\n" {
+		t.Errorf("file = %q, want %q", data, "NEW=1\n")
+	}
+	info, _ := os.Stat(path)
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("permissions = %o, want 600", perm)
+	}
+}
+
+func TestWriteDotenv_SortedKeys(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteDotenv(&buf, map[string]string{"B": "2", "A": "1"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "A=1\nB=2\n" {
+		t.Errorf("got %q", got)
+	}
+}
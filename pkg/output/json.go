@@ -0,0 +1,49 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// FormatValueJSON formats a single secret value as JSON. Values that are already valid JSON
+// (e.g. a whole Secrets Manager secret) are printed compacted as-is, anything else as a JSON string.
+func FormatValueJSON(value string) (string, error) {
+	if json.Valid([]byte(value)) {
+		var compacted bytes.Buffer
+		if err := json.Compact(&compacted, []byte(value)); err != nil {
+			return "", err
+		}
+		return compacted.String(), nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// WriteJSONObject writes secrets as a single JSON object ({"VAR":"value",...}) with sorted keys
+func WriteJSONObject(w io.Writer, secrets map[string]string) error {
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to encode secrets as JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// WriteJSONObjectFile writes secrets to path as a single JSON object of strings, for tools that
+// read their settings from a JSON file
+func WriteJSONObjectFile(path string, secrets map[string]string, owner *FileOwner) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := WriteJSONObject(file, secrets); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFormatValueJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"JSON object", "{\n  \"username\": \"admin\",\n  \"password\": \"p\\\"w\"\n}", `{"username":"admin","password":"p\"w"}`},
+		{"plain string", "s3cret", `"s3cret"`},
+		{"string needing escapes", "line1\n\"quoted\"\\", `"line1\n\"quoted\"\\"`},
+		{"number stays JSON", "42", "42"},
+		{"empty", "", `""`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatValueJSON(tt.value)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteJSONObject(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteJSONObject(&buf, map[string]string{
+		"DB_PASS": "p\"w\n",
+		"API_URL": "https://api.example.com",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `{"API_URL":"https://api.example.com","DB_PASS":"p\"w\n"}` + "\n"
+	if buf.String() != expected {
+		t.Errorf("got %s, want %s", buf.String(), expected)
+	}
+}
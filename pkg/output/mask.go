@@ -0,0 +1,46 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMasks writes every distinct secret value on its own line, sorted, for CI systems and log
+// shippers that mask known values in their output. Multiline values are written line by line,
+// since masking works per line. Empty lines are left out, as they would mask everything.
+func WriteMasks(w io.Writer, secrets map[string]string) error {
+	seen := make(map[string]bool)
+	var masks []string
+	for _, value := range secrets {
+		for _, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+			if strings.TrimSpace(line) == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			masks = append(masks, line)
+		}
+	}
+	sort.Strings(masks)
+
+	for _, mask := range masks {
+		if _, err := fmt.Fprintln(w, mask); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMaskFile writes the mask list (see WriteMasks) to path, for a CI step to register line by line
+func WriteMaskFile(path string, secrets map[string]string, owner *FileOwner) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := WriteMasks(file, secrets); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
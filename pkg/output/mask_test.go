@@ -0,0 +1,43 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteMasks(t *testing.T) {
+	secrets := map[string]string{
+		"DB_PASS":  "s3cret",
+		"DB_COPY":  "s3cret",
+		"TLS_KEY":  "-----BEGIN KEY-----\r\nabc\r\n-----END KEY-----\r\n",
+		"EMPTY":    "",
+		"BLANKISH": "  ",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMasks(&buf, secrets); err != nil {
+		t.Fatalf("WriteMasks() error = %v", err)
+	}
+	expected := "-----BEGIN KEY-----\n-----END KEY-----\nabc\ns3cret\n"
+	if buf.String() != expected {
+		t.Errorf("WriteMasks() = %q, want %q", buf.String(), expected)
+	}
+}
+
+func TestWriteMaskFile_Permissions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "masks.txt")
+	if err := WriteMaskFile(path, map[string]string{"TOKEN": "abc"}, nil); err != nil {
+		t.Fatalf("WriteMaskFile() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "abc\n" {
+		t.Errorf("mask file = %q", data)
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package output
+
+import (
+	"fmt"
+	"os"
+)
+
+// FileOwner is the user and group given to the files secretinit writes (--creds-owner uid:gid),
+// so a command started with --run-as can read them. A nil *FileOwner keeps the current user.
+type FileOwner struct {
+	UID int
+	GID int
+}
+
+// chown gives path to the owner; it does nothing for a nil owner
+func (o *FileOwner) chown(path string) error {
+	if o == nil {
+		return nil
+	}
+	if err := os.Chown(path, o.UID, o.GID); err != nil {
+		return fmt.Errorf("failed to set owner of %s to %d:%d: %w", path, o.UID, o.GID, err)
+	}
+	return nil
+}
+
+// createSecretFile opens path for writing secret values; every Write*File function goes through it.
+// An existing file is truncated and its permissions tightened to 0600, however it was created
+// before, and a non-nil owner is given the file.
+func createSecretFile(path string, owner *FileOwner) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := owner.chown(path); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return file, nil
+}
@@ -0,0 +1,82 @@
+//go:build !windows
+
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// fileOwnerOf returns the uid and gid of path
+func fileOwnerOf(t *testing.T, path string) (int, int) {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stat := info.Sys().(*syscall.Stat_t)
+	return int(stat.Uid), int(stat.Gid)
+}
+
+func TestFileWriters_Owner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing file ownership requires root")
+	}
+
+	owner := &FileOwner{UID: 12345, GID: 23456}
+	dir := t.TempDir()
+	secrets := map[string]string{"DB_USER": "app", "DB_PASS": "s3cret"}
+	creds := []DBCredential{{Name: "DB", User: "app", Password: "s3cret"}}
+	src := filepath.Join(dir, "app.conf.tmpl")
+	if err := os.WriteFile(src, []byte(`password={{ .DB_PASS }}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	writers := map[string]func(path string) error{
+		".env":        func(path string) error { return WriteDotenvFile(path, secrets, owner) },
+		"vars.tfvars": func(path string) error { return WriteTfvarsFile(path, secrets, owner) },
+		"out.json":    func(path string) error { return WriteJSONObjectFile(path, secrets, owner) },
+		"masks.txt":   func(path string) error { return WriteMaskFile(path, secrets, owner) },
+		".pgpass":     func(path string) error { return WritePgpassFile(path, creds, owner) },
+		".my.cnf":     func(path string) error { return WriteMyCnfFile(path, creds, owner) },
+		"map.json":    func(path string) error { return WriteResolveMapFile(path, secrets, backend.ShortHash, owner) },
+		"app.conf":    func(path string) error { return WriteTemplateFile(src, path, secrets, owner) },
+	}
+	for name, write := range writers {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := write(path); err != nil {
+				t.Fatalf("write error = %v", err)
+			}
+			if uid, gid := fileOwnerOf(t, path); uid != owner.UID || gid != owner.GID {
+				t.Errorf("%s owned by %d:%d, want %d:%d", name, uid, gid, owner.UID, owner.GID)
+			}
+		})
+	}
+
+	t.Run("systemd credentials", func(t *testing.T) {
+		credsDir := filepath.Join(dir, "credentials")
+		if err := WriteSystemdCredentials(credsDir, secrets, owner); err != nil {
+			t.Fatalf("WriteSystemdCredentials() error = %v", err)
+		}
+		for _, path := range []string{credsDir, filepath.Join(credsDir, "DB_USER"), filepath.Join(credsDir, "DB_PASS")} {
+			if uid, gid := fileOwnerOf(t, path); uid != owner.UID || gid != owner.GID {
+				t.Errorf("%s owned by %d:%d, want %d:%d", path, uid, gid, owner.UID, owner.GID)
+			}
+		}
+	})
+}
+
+func TestFileWriters_NilOwnerKeepsCurrentUser(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := WriteDotenvFile(path, map[string]string{"A": "1"}, nil); err != nil {
+		t.Fatalf("WriteDotenvFile() error = %v", err)
+	}
+	if uid, _ := fileOwnerOf(t, path); uid != os.Geteuid() {
+		t.Errorf("file owned by uid %d, want %d", uid, os.Geteuid())
+	}
+}
@@ -0,0 +1,36 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// WriteResolveMap writes a JSON object mapping each variable name to hash(value), with sorted keys,
+// so deployments can be compared without exposing any secret value
+func WriteResolveMap(w io.Writer, secrets map[string]string, hash func(string) string) error {
+	hashes := make(map[string]string, len(secrets))
+	for name, value := range secrets {
+		hashes[name] = hash(value)
+	}
+	data, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode resolve map as JSON: %w", err)
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
+
+// WriteResolveMapFile writes the resolve map (see WriteResolveMap) to path. It holds no values, but
+// is kept as private as the secret files since short hashes of low-entropy values can be guessed.
+func WriteResolveMapFile(path string, secrets map[string]string, hash func(string) string, owner *FileOwner) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := WriteResolveMap(file, secrets, hash); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
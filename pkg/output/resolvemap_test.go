@@ -0,0 +1,60 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+func resolveMapOf(t *testing.T, secrets map[string]string) map[string]string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteResolveMap(&buf, secrets, backend.ShortHash); err != nil {
+		t.Fatalf("WriteResolveMap() error = %v", err)
+	}
+	var hashes map[string]string
+	if err := json.Unmarshal(buf.Bytes(), &hashes); err != nil {
+		t.Fatalf("resolve map is not a JSON object: %v\n%s", err, buf.String())
+	}
+	return hashes
+}
+
+func TestWriteResolveMap(t *testing.T) {
+	first := resolveMapOf(t, map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "key-v1"})
+	second := resolveMapOf(t, map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "key-v2"})
+
+	if first["DB_PASSWORD"] != second["DB_PASSWORD"] {
+		t.Errorf("identical values hashed differently: %s vs %s", first["DB_PASSWORD"], second["DB_PASSWORD"])
+	}
+	if first["API_KEY"] == second["API_KEY"] {
+		t.Errorf("changed value kept hash %s", first["API_KEY"])
+	}
+	for name, hash := range first {
+		if len(hash) != 8 {
+			t.Errorf("%s: expected a short hash, got %q", name, hash)
+		}
+		if strings.Contains(hash, "s3cret") || strings.Contains(hash, "key-v1") {
+			t.Errorf("%s: hash exposes the value", name)
+		}
+	}
+}
+
+func TestWriteResolveMapFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolve-map.json")
+	if err := WriteResolveMapFile(path, map[string]string{"TOKEN": "abc"}, backend.ShortHash, nil); err != nil {
+		t.Fatalf("WriteResolveMapFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"TOKEN\": \"" + backend.ShortHash("abc") + "\"\n}\n"
+	if string(data) != expected {
+		t.Errorf("unexpected file content %q, want %q", data, expected)
+	}
+}
@@ -0,0 +1,48 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ShellSyntaxes are the shells WriteShellExports can write for
+var ShellSyntaxes = []string{"sh", "fish"}
+
+// WriteShellExports writes secrets as statements for eval with sorted keys: export KEY='value' lines
+// for POSIX shells (sh, bash, zsh), or set -x KEY 'value' lines for fish.
+func WriteShellExports(w io.Writer, secrets map[string]string, shell string) error {
+	var format func(key, value string) string
+	switch shell {
+	case "sh":
+		format = func(key, value string) string { return fmt.Sprintf("export %s=%s", key, quotePOSIXShell(value)) }
+	case "fish":
+		format = func(key, value string) string { return fmt.Sprintf("set -x %s %s", key, quoteFishShell(value)) }
+	default:
+		return fmt.Errorf("unsupported shell '%s' (expected %s)", shell, strings.Join(ShellSyntaxes, " or "))
+	}
+
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if _, err := fmt.Fprintln(w, format(key, secrets[key])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// quotePOSIXShell single-quotes value; each embedded quote closes the quotes, adds an escaped quote and reopens them
+func quotePOSIXShell(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
+
+// quoteFishShell single-quotes value for fish, where \ and ' are escaped inside single quotes
+func quoteFishShell(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
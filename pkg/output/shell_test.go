@@ -0,0 +1,58 @@
+package output
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+)
+
+func TestWriteShellExports(t *testing.T) {
+	secrets := map[string]string{
+		"B_PASS": `it's $HOME \n`,
+		"A_USER": "app",
+	}
+
+	tests := []struct {
+		shell    string
+		expected string
+	}{
+		{"sh", "export A_USER='app'\nexport B_PASS='it'\\''s $HOME \\n'\n"},
+		{"fish", "set -x A_USER 'app'\nset -x B_PASS 'it\\'s $HOME \\\\n'\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.shell, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteShellExports(&buf, secrets, tt.shell); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("WriteShellExports() = %q, want %q", buf.String(), tt.expected)
+			}
+		})
+	}
+
+	if err := WriteShellExports(&bytes.Buffer{}, secrets, "powershell"); err == nil {
+		t.Error("expected an error for an unsupported shell")
+	}
+}
+
+func TestWriteShellExports_EvalRoundTrip(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("sh not available")
+	}
+	value := "multi\nline 'quoted' \"double\" $(echo no) `echo no` \\ end"
+
+	var buf bytes.Buffer
+	if err := WriteShellExports(&buf, map[string]string{"SECRET": value}, "sh"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out, err := exec.Command(sh, "-c", buf.String()+`printf '%s' "$SECRET"`).Output()
+	if err != nil {
+		t.Fatalf("eval failed: %v", err)
+	}
+	if string(out) != value {
+		t.Errorf("eval round trip = %q, want %q", out, value)
+	}
+}
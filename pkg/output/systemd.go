@@ -0,0 +1,42 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// WriteSystemdCredentials writes each secret to its own file under dir, matching the layout
+// systemd uses for LoadCredential= ($CREDENTIALS_DIRECTORY/NAME). Files are created read-only
+// for the owner (0400) and the directory is created with 0700 if it doesn't exist.
+// A non-nil owner is given the directory and the files.
+func WriteSystemdCredentials(dir string, secrets map[string]string, owner *FileOwner) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create credentials directory %s: %w", dir, err)
+	}
+	if err := owner.chown(dir); err != nil {
+		return err
+	}
+
+	for name, value := range secrets {
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return fmt.Errorf("invalid credential name '%s'", name)
+		}
+
+		path := filepath.Join(dir, name)
+
+		// Existing files are read-only, so remove them before writing the new value
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to replace credential %s: %w", path, err)
+		}
+		if err := os.WriteFile(path, []byte(value), 0400); err != nil {
+			return fmt.Errorf("failed to write credential %s: %w", path, err)
+		}
+		if err := owner.chown(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
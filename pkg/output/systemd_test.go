@@ -0,0 +1,52 @@
+package output
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteSystemdCredentials(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "credentials", "myapp")
+	secrets := map[string]string{
+		"DB_PASSWORD": "s3cret",
+		"TLS_KEY":     "-----BEGIN KEY-----\nabc\n-----END KEY-----\n",
+	}
+
+	if err := WriteSystemdCredentials(dir, secrets, nil); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for name, expected := range secrets {
+		path := filepath.Join(dir, name)
+		content, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("Expected credential file %s: %v", path, err)
+		}
+		if string(content) != expected {
+			t.Errorf("Credential %s: expected %q, got %q", name, expected, string(content))
+		}
+
+		if runtime.GOOS != "windows" {
+			info, _ := os.Stat(path)
+			if info.Mode().Perm() != 0400 {
+				t.Errorf("Credential %s: expected mode 0400, got %o", name, info.Mode().Perm())
+			}
+		}
+	}
+
+	// Writing again must replace the read-only files
+	if err := WriteSystemdCredentials(dir, map[string]string{"DB_PASSWORD": "rotated"}, nil); err != nil {
+		t.Fatalf("Unexpected error rewriting credentials: %v", err)
+	}
+	if content, _ := os.ReadFile(filepath.Join(dir, "DB_PASSWORD")); string(content) != "rotated" {
+		t.Errorf("Expected rotated value, got %q", string(content))
+	}
+}
+
+func TestWriteSystemdCredentials_InvalidName(t *testing.T) {
+	if err := WriteSystemdCredentials(t.TempDir(), map[string]string{"../escape": "x"}, nil); err == nil {
+		t.Error("Expected error for credential name containing a path separator")
+	}
+}
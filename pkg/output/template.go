@@ -0,0 +1,80 @@
+package output
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the helpers available in --template files to shape resolved values,
+// e.g. {{ .DB_PASS | urlquery }} when building a connection URL
+var templateFuncs = template.FuncMap{
+	"lower":  strings.ToLower,
+	"upper":  strings.ToUpper,
+	"b64enc": func(value string) string { return base64.StdEncoding.EncodeToString([]byte(value)) },
+	"b64dec": func(value string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("b64dec: %w", err)
+		}
+		return string(decoded), nil
+	},
+	"quote":    strconv.Quote,
+	"urlquery": url.QueryEscape,
+}
+
+// RenderTemplate executes a Go text/template with the resolved secrets as data ({{ .NAME }}).
+// Referencing a variable that was not resolved is an error instead of rendering "<no value>".
+func RenderTemplate(w io.Writer, name, text string, secrets map[string]string) error {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	if err := tmpl.Execute(w, secrets); err != nil {
+		return fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return nil
+}
+
+// ParseTemplateSpec splits a --template "SRC[:DEST]" spec. Without DEST the template renders to
+// stdout ("-"). A colon after a Windows drive letter (C:\...) is not treated as a separator.
+func ParseTemplateSpec(spec string) (src, dest string) {
+	idx := strings.LastIndex(spec, ":")
+	if idx <= 1 {
+		return spec, "-"
+	}
+	return spec[:idx], spec[idx+1:]
+}
+
+// WriteTemplateFile renders the template file src to dest ("-" for stdout).
+// The template is rendered in memory first, so a failure leaves dest untouched.
+func WriteTemplateFile(src, dest string, secrets map[string]string, owner *FileOwner) error {
+	text, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("failed to read template: %w", err)
+	}
+	var rendered bytes.Buffer
+	if err := RenderTemplate(&rendered, src, string(text), secrets); err != nil {
+		return err
+	}
+	if dest == "-" {
+		_, err = rendered.WriteTo(os.Stdout)
+		return err
+	}
+
+	file, err := createSecretFile(dest, owner)
+	if err != nil {
+		return err
+	}
+	if _, err := rendered.WriteTo(file); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", dest, err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,106 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRenderTemplate_Functions(t *testing.T) {
+	secrets := map[string]string{
+		"DB_USER": "App",
+		"DB_PASS": "p@ss/w:rd &x",
+		"TOKEN":   "czNjcmV0",
+		"NOTE":    `say "hi"`,
+	}
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{`{{ .DB_USER | lower }}`, "app"},
+		{`{{ .DB_USER | upper }}`, "APP"},
+		{`{{ .DB_USER | b64enc }}`, "QXBw"},
+		{`{{ .TOKEN | b64dec }}`, "s3cret"},
+		{`{{ .NOTE | quote }}`, `"say \"hi\""`},
+		{`postgres://{{ .DB_USER | lower }}:{{ .DB_PASS | urlquery }}@db/app`, "postgres://app:p%40ss%2Fw%3Ard+%26x@db/app"},
+		{`{{ .DB_USER | b64enc | b64dec | upper }}`, "APP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := RenderTemplate(&buf, "test", tt.text, secrets); err != nil {
+				t.Fatalf("RenderTemplate() error = %v", err)
+			}
+			if buf.String() != tt.expected {
+				t.Errorf("RenderTemplate(%s) = %q, want %q", tt.text, buf.String(), tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderTemplate_Errors(t *testing.T) {
+	secrets := map[string]string{"TOKEN": "not base64!"}
+
+	for _, text := range []string{
+		`{{ .TOKEN | b64dec }}`, // Invalid base64
+		`{{ .MISSING }}`,        // Unresolved variable
+		`{{ .TOKEN | nope }}`,   // Unknown function
+	} {
+		if err := RenderTemplate(&bytes.Buffer{}, "test", text, secrets); err == nil {
+			t.Errorf("RenderTemplate(%s): expected an error", text)
+		}
+	}
+}
+
+func TestParseTemplateSpec(t *testing.T) {
+	tests := []struct {
+		spec, src, dest string
+	}{
+		{"app.conf.tmpl", "app.conf.tmpl", "-"},
+		{"app.conf.tmpl:/etc/app.conf", "app.conf.tmpl", "/etc/app.conf"},
+		{`C:\app.tmpl`, `C:\app.tmpl`, "-"},
+	}
+	for _, tt := range tests {
+		if src, dest := ParseTemplateSpec(tt.spec); src != tt.src || dest != tt.dest {
+			t.Errorf("ParseTemplateSpec(%q) = %q, %q, want %q, %q", tt.spec, src, dest, tt.src, tt.dest)
+		}
+	}
+}
+
+func TestWriteTemplateFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "app.conf.tmpl")
+	dest := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(src, []byte("password = {{ .DB_PASS | quote }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dest, []byte("stale content that is longer\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteTemplateFile(src, dest, map[string]string{"DB_PASS": "s3cret"}, nil); err != nil {
+		t.Fatalf("WriteTemplateFile() error = %v", err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "password = \"s3cret\"\n" {
+		t.Errorf("rendered file = %q", data)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(dest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("expected mode 0600, got %o", perm)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// FormatTfvarsAssignment formats a single `name = "value"` HCL assignment for a .tfvars file.
+// Values are always strings, so numeric-looking secrets keep leading zeros and exact digits.
+// Multiline values ending in a newline use heredoc syntax, others a quoted string with escapes.
+func FormatTfvarsAssignment(name, value string) (string, error) {
+	if !isHCLIdentifier(name) {
+		return "", fmt.Errorf("variable name '%s' is not a valid Terraform identifier", name)
+	}
+	return fmt.Sprintf("%s = %s", name, formatHCLString(value)), nil
+}
+
+// WriteTfvars writes secrets as HCL assignments with sorted names
+func WriteTfvars(w io.Writer, secrets map[string]string) error {
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		assignment, err := FormatTfvarsAssignment(name, secrets[name])
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(w, assignment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteTfvarsFile writes secrets to path as Terraform string variables for terraform -var-file
+func WriteTfvarsFile(path string, secrets map[string]string, owner *FileOwner) error {
+	file, err := createSecretFile(path, owner)
+	if err != nil {
+		return err
+	}
+	if err := WriteTfvars(file, secrets); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return file.Close()
+}
+
+// formatHCLString returns value as an HCL string expression. Template sequences ("${" and "%{")
+// are escaped in both forms so secrets are never interpolated by Terraform.
+func formatHCLString(value string) string {
+	// A heredoc value always ends with a newline, so only use one when the value does
+	if strings.Contains(value, "\n") && strings.HasSuffix(value, "\n") && !strings.Contains(value, "\r") {
+		delimiter := heredocDelimiter(value)
+		return fmt.Sprintf("<<%s\n%s%s", delimiter, escapeHCLTemplate(value), delimiter)
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range escapeHCLTemplate(value) {
+		switch r {
+		case '\\', '"':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// escapeHCLTemplate escapes "${" as "$${" and "%{" as "%%{"
+func escapeHCLTemplate(value string) string {
+	value = strings.ReplaceAll(value, "${", "$${")
+	return strings.ReplaceAll(value, "%{", "%%{")
+}
+
+// heredocDelimiter returns a delimiter (EOT, EOT1, ...) that doesn't appear as a line of value
+func heredocDelimiter(value string) string {
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(value, "\n") {
+		lines[strings.TrimSpace(line)] = true
+	}
+	delimiter := "EOT"
+	for i := 1; lines[delimiter]; i++ {
+		delimiter = fmt.Sprintf("EOT%d", i)
+	}
+	return delimiter
+}
+
+// isHCLIdentifier reports whether name is a valid HCL identifier (letters, digits, '_' and '-',
+// not starting with a digit or '-')
+func isHCLIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'):
+		case i > 0 && (r == '-' || (r >= '0' && r <= '9')):
+		default:
+			return false
+		}
+	}
+	return true
+}
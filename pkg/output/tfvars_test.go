@@ -0,0 +1,97 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestFormatTfvarsAssignment(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"plain value", "s3cret", `db_password = "s3cret"`},
+		{"numeric stays a string", "007", `db_password = "007"`},
+		{"empty", "", `db_password = ""`},
+		{"quotes and backslash", `say "hi" \o/`, `db_password = "say \"hi\" \\o/"`},
+		{"template sequences", "${var.x} %{if}", `db_password = "$${var.x} %%{if}"`},
+		{"lone dollar and percent", "pa$$ 100%", `db_password = "pa$$ 100%"`},
+		{"newline without trailing newline", "line1\nline2", `db_password = "line1\nline2"`},
+		{"tab and control character", "a\tb\x01", `db_password = "a\tb\u0001"`},
+		{"heredoc", "line1\nline2\n", "db_password = <<EOT\nline1\nline2\nEOT"},
+		{"heredoc escapes templates", "a ${b}\n", "db_password = <<EOT\na $${b}\nEOT"},
+		{"heredoc avoids delimiter", "EOT\nEOT1\n", "db_password = <<EOT2\nEOT\nEOT1\nEOT2"},
+		{"carriage returns are escaped", "a\r\nb\r\n", `db_password = "a\r\nb\r\n"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatTfvarsAssignment("db_password", tt.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.expected {
+				t.Errorf("FormatTfvarsAssignment(%q) = %q, want %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatTfvarsAssignment_InvalidName(t *testing.T) {
+	for _, name := range []string{"", "1password", "-flag", "db.password", "db password"} {
+		if _, err := FormatTfvarsAssignment(name, "value"); err == nil {
+			t.Errorf("expected an error for variable name %q", name)
+		}
+	}
+	for _, name := range []string{"DB_PASSWORD", "_private", "api-key2"} {
+		if _, err := FormatTfvarsAssignment(name, "value"); err != nil {
+			t.Errorf("unexpected error for variable name %q: %v", name, err)
+		}
+	}
+}
+
+func TestWriteTfvars(t *testing.T) {
+	var buf bytes.Buffer
+	secrets := map[string]string{"TOKEN": "abc", "API_KEY": "123", "CERT": "-----BEGIN-----\nMIIB\n-----END-----\n"}
+	if err := WriteTfvars(&buf, secrets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := "API_KEY = \"123\"\nCERT = <<EOT\n-----BEGIN-----\nMIIB\n-----END-----\nEOT\nTOKEN = \"abc\"\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTfvars() =\n%s\nwant\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteTfvarsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secrets.auto.tfvars")
+	if err := os.WriteFile(path, []byte("stale = \"old\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteTfvarsFile(path, map[string]string{"db_password": "s3cret"}, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "db_password = \"s3cret\"\n" {
+		t.Errorf("unexpected file content %q", data)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("expected mode 0600, got %o", perm)
+		}
+	}
+}
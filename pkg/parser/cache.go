@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"sync"
+)
+
+// parseCacheSize bounds the number of cached parse results
+const parseCacheSize = 256
+
+// parseCache is a bounded cache of parsed secret addresses. Parsing is pure, so results never go stale.
+// When the cache is full it is reset, which keeps it bounded without tracking usage.
+type parseCache struct {
+	mutex   sync.Mutex
+	entries map[string]SecretSource
+	limit   int
+}
+
+// defaultParseCache is used by ParseSecretString
+var defaultParseCache = newParseCache(parseCacheSize)
+
+// newParseCache creates a parse cache holding at most limit entries
+func newParseCache(limit int) *parseCache {
+	return &parseCache{
+		entries: make(map[string]SecretSource),
+		limit:   limit,
+	}
+}
+
+// get returns a copy of the cached parse result for an address
+func (c *parseCache) get(address string) (SecretSource, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	secretSource, ok := c.entries[address]
+	if !ok {
+		return SecretSource{}, false
+	}
+	return secretSource.clone(), true
+}
+
+// put stores a parse result for an address
+func (c *parseCache) put(address string, secretSource SecretSource) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if len(c.entries) >= c.limit {
+		c.entries = make(map[string]SecretSource)
+	}
+	c.entries[address] = secretSource.clone()
+}
+
+// size returns the number of cached parse results
+func (c *parseCache) size() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return len(c.entries)
+}
+
+// clone returns a copy of the SecretSource that shares no mutable state (the Options map)
+func (s SecretSource) clone() SecretSource {
+	if s.Options != nil {
+		options := make(map[string]string, len(s.Options))
+		for name, value := range s.Options {
+			options[name] = value
+		}
+		s.Options = options
+	}
+	return s
+}
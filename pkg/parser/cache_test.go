@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+var parseCacheAddresses = []string{
+	"git:https://api.example.com",
+	"git:user@github.com:::password",
+	"aws:sm:myapp/db-creds:::password",
+	"aws:ps:/myapp/config:::database.host",
+	"gcp:sm:my-project/api-key",
+	"azure:kv:my-vault/app-secret:::username",
+	"aws:sm:cfg:::hosts?join=,&wrap=prefix:hosts=",
+	"remote:ssh://bastion/aws:sm:myapp/key",
+	"wincred:git:https://github.com:::password",
+}
+
+func TestParseSecretString_CachedEqualsFresh(t *testing.T) {
+	for _, address := range parseCacheAddresses {
+		fresh, err := parseSecretString(address)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", address, err)
+		}
+		for i := 0; i < 2; i++ { // First call fills the cache, second is served from it
+			cached, err := ParseSecretString(address)
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", address, err)
+			}
+			if !reflect.DeepEqual(cached, fresh) {
+				t.Errorf("%s: cached parse %+v differs from fresh parse %+v", address, cached, fresh)
+			}
+		}
+	}
+}
+
+func TestParseSecretString_CachedOptionsAreCopies(t *testing.T) {
+	address := "aws:sm:cfg:::hosts?join=;"
+	first, err := ParseSecretString(address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first.Options["join"] = "modified"
+
+	second, err := ParseSecretString(address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Options["join"] != ";" {
+		t.Errorf("Expected cached options to be unaffected by callers, got %q", second.Options["join"])
+	}
+}
+
+func TestParseSecretString_ErrorsNotCached(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		if _, err := ParseSecretString("unknown:foo"); err == nil {
+			t.Fatal("Expected error for unsupported backend")
+		}
+	}
+}
+
+func TestParseCache_Bounded(t *testing.T) {
+	cache := newParseCache(4)
+	for i := 0; i < 10; i++ {
+		cache.put(fmt.Sprintf("aws:sm:secret-%d", i), SecretSource{Backend: "aws"})
+		if cache.size() > 4 {
+			t.Fatalf("Expected at most 4 entries, got %d", cache.size())
+		}
+	}
+	if _, ok := cache.get("aws:sm:secret-9"); !ok {
+		t.Error("Expected the most recent entry to be cached")
+	}
+}
+
+func BenchmarkParseSecretString_Fresh(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, address := range parseCacheAddresses {
+			parseSecretString(address)
+		}
+	}
+}
+
+func BenchmarkParseSecretString_Cached(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, address := range parseCacheAddresses {
+			ParseSecretString(address)
+		}
+	}
+}
@@ -10,16 +10,35 @@ import (
 // SecretSource represents the parsed components of a secret string
 type SecretSource struct {
 	Backend  string
-	Service  string // For cloud providers (sm, ps, kv, etc.)
-	Resource string // The actual identifier (URL, name, ARN)
-	KeyPath  string // Optional path for JSON extraction or specific credential part. Empty means raw content.
+	Service  string            // For cloud providers (sm, ps, kv, etc.)
+	Resource string            // The actual identifier (URL, name, ARN)
+	KeyPath  string            // Optional path for JSON extraction or specific credential part. Empty means raw content.
+	Options  map[string]string // Optional "?name=value&..." options following the KeyPath (e.g. join)
+
+	Default    string // Fallback value from a trailing "||default", used when the secret is not found
+	HasDefault bool   // Whether a "||default" was given (the default itself may be empty)
 }
 
 // ParseSecretString parses the input string into a SecretSource struct.
-// It uses ":::" as the explicit delimiter for the optional KeyPath.
+// It uses ":::" as the explicit delimiter for the optional KeyPath, which may end in "?options"
+// and a "||default" fallback value (e.g. "aws:sm:myapp/flags:::enabled||false").
 // Conventionally, the resource string should not contain ":::".
 // Any string is now valid for KeyPath across all backends.
+// Successful parses are cached by address, since the same addresses are parsed repeatedly.
 func ParseSecretString(s string) (SecretSource, error) {
+	if cached, ok := defaultParseCache.get(s); ok {
+		return cached, nil
+	}
+	secretSource, err := parseSecretString(s)
+	if err != nil {
+		return SecretSource{}, err
+	}
+	defaultParseCache.put(s, secretSource)
+	return secretSource.clone(), nil
+}
+
+// parseSecretString parses the input string without using the parse cache
+func parseSecretString(s string) (SecretSource, error) {
 	var keyPath string
 	mainString := s
 
@@ -30,6 +49,22 @@ func ParseSecretString(s string) (SecretSource, error) {
 		keyPath = keyPathParts[1]    // The part after ":::" is the KeyPath
 	}
 
+	// Step 1a: Split off a trailing "||default" fallback, which may itself contain colons or '?'
+	var defaultValue string
+	var hasDefault bool
+	keyPath, defaultValue, hasDefault = strings.Cut(keyPath, "||")
+
+	// Step 1b: Split off "?name=value&..." options from the KeyPath
+	var options map[string]string
+	if idx := strings.Index(keyPath, "?"); idx >= 0 {
+		var err error
+		options, err = parseOptions(keyPath[idx+1:])
+		if err != nil {
+			return SecretSource{}, err
+		}
+		keyPath = keyPath[:idx]
+	}
+
 	// Step 2: Split the mainString (without KeyPath) by the first colon to get backend and the rest
 	parts := strings.SplitN(mainString, ":", 2)
 	if len(parts) < 2 {
@@ -40,8 +75,11 @@ func ParseSecretString(s string) (SecretSource, error) {
 	remaining := parts[1] // This segment contains the service and resource
 
 	secretSource := SecretSource{
-		Backend: backend,
-		KeyPath: keyPath, // Set the parsed KeyPath
+		Backend:    backend,
+		KeyPath:    keyPath, // Set the parsed KeyPath
+		Options:    options,
+		Default:    defaultValue,
+		HasDefault: hasDefault,
 	}
 
 	switch backend {
@@ -63,7 +101,49 @@ func ParseSecretString(s string) (SecretSource, error) {
 			return SecretSource{}, fmt.Errorf("invalid Git URL scheme for resource '%s'", secretSource.Resource)
 		}
 
-	case "aws", "gcp", "azure":
+	case "remote":
+		// Remote format: remote:ssh://[user@]host[:port]/ADDRESS[:::key_path]
+		// The ADDRESS is resolved by secretinit on the remote host.
+		rest, found := strings.CutPrefix(remaining, "ssh://")
+		if !found {
+			return SecretSource{}, fmt.Errorf("invalid remote secret string format: %s. Expected 'remote:ssh://host/ADDRESS'", mainString)
+		}
+		host, address, _ := strings.Cut(rest, "/")
+		if host == "" || address == "" {
+			return SecretSource{}, fmt.Errorf("invalid remote secret string format: %s. Expected 'remote:ssh://host/ADDRESS'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "wincred":
+		// Windows Credential Manager format: wincred:TargetName[:::key_path]
+		if remaining == "" {
+			return SecretSource{}, fmt.Errorf("invalid wincred secret string format: %s. Expected 'wincred:TargetName'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "exec":
+		// External helper format: exec:/path/to/helper[ NAME][:::key_path]
+		if strings.TrimSpace(remaining) == "" {
+			return SecretSource{}, fmt.Errorf("invalid exec secret string format: %s. Expected 'exec:/path/to/helper[ NAME]'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "auto":
+		// Cloud-detecting format: auto:NAME/SECRET[:::key_path]
+		if strings.TrimSpace(remaining) == "" {
+			return SecretSource{}, fmt.Errorf("invalid auto secret string format: %s. Expected 'auto:name/secret'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "file":
+		// File format: file:/path/to/file[:::key_path]
+		if strings.TrimSpace(remaining) == "" {
+			return SecretSource{}, fmt.Errorf("invalid file secret string format: %s. Expected 'file:/path/to/file'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "csv":
+		// CSV table format: csv:/path/to/file.csv:ROWKEY[:::column]
+		if idx := strings.LastIndex(remaining, ":"); idx <= 0 || idx == len(remaining)-1 {
+			return SecretSource{}, fmt.Errorf("invalid csv secret string format: %s. Expected 'csv:/path/to/file.csv:ROWKEY'", mainString)
+		}
+		secretSource.Resource = remaining
+	case "aws", "gcp", "azure", "bitwarden":
 		// These backends follow: backend:service:resource[:::key_path]
 		// First, split off the service from the 'remaining' string.
 		partsAfterBackend := strings.SplitN(remaining, ":", 2)
@@ -75,12 +155,34 @@ func ParseSecretString(s string) (SecretSource, error) {
 		// The ":::" delimiter already handled the KeyPath separation, so no further heuristics needed here.
 
 	default:
-		return SecretSource{}, fmt.Errorf("unsupported backend: %s", backend)
+		return SecretSource{}, fmt.Errorf("unsupported backend: %s%s", backend, DidYouMean(backend, Backends))
 	}
 
 	return secretSource, nil
 }
 
+// parseOptions parses the "name=value&name2=value2" options that may follow the KeyPath.
+// Values are taken verbatim (no URL decoding) so delimiters like ";" or "," work as-is.
+func parseOptions(raw string) (map[string]string, error) {
+	options := make(map[string]string)
+	for _, pair := range strings.Split(raw, "&") {
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		name := parts[0]
+		if name == "" {
+			return nil, fmt.Errorf("invalid option '%s': missing option name", pair)
+		}
+		value := ""
+		if len(parts) == 2 {
+			value = parts[1]
+		}
+		options[name] = value
+	}
+	return options, nil
+}
+
 // normalizeGitURL handles different git URL formats and normalizes them
 // Supports both full URLs (https://user@host/path) and short forms (user@host)
 func normalizeGitURL(rawURL string) string {
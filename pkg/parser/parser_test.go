@@ -222,6 +222,195 @@ func TestParseSecretString(t *testing.T) {
 			},
 		},
 
+		// Remote Tests
+		{
+			name:    "Remote: SSH with inner address",
+			input:   "remote:ssh://bastion/aws:sm:myapp/key",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "remote", Resource: "ssh://bastion/aws:sm:myapp/key", KeyPath: "",
+			},
+		},
+		{
+			name:    "Remote: SSH with user, port and KeyPath",
+			input:   "remote:ssh://deploy@bastion:2222/aws:sm:myapp/db:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "remote", Resource: "ssh://deploy@bastion:2222/aws:sm:myapp/db", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid Remote: Missing ssh scheme",
+			input:   "remote:bastion/aws:sm:myapp/key",
+			wantErr: true,
+		},
+		{
+			name:    "Invalid Remote: Missing inner address",
+			input:   "remote:ssh://bastion",
+			wantErr: true,
+		},
+
+		// Windows Credential Manager Tests
+		{
+			name:    "Wincred: Target with KeyPath",
+			input:   "wincred:git:https://github.com:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "wincred", Resource: "git:https://github.com", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid Wincred: Missing target",
+			input:   "wincred::::password",
+			wantErr: true,
+		},
+
+		// Bitwarden Tests
+		{
+			name:    "Bitwarden: Item with nested KeyPath",
+			input:   "bitwarden:item:GitHub Deploy:::login.password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "bitwarden", Service: "item", Resource: "GitHub Deploy", KeyPath: "login.password",
+			},
+		},
+		{
+			name:    "Invalid Bitwarden: Missing service",
+			input:   "bitwarden:my-item",
+			wantErr: true,
+		},
+
+		// Exec Tests
+		{
+			name:    "Exec: Helper with name and KeyPath",
+			input:   "exec:/usr/local/bin/vault-helper db/creds:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "exec", Resource: "/usr/local/bin/vault-helper db/creds", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid Exec: Missing helper",
+			input:   "exec::::password",
+			wantErr: true,
+		},
+
+		// Auto Tests
+		{
+			name:    "Auto: Name and secret with KeyPath",
+			input:   "auto:myapp/db:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "auto", Resource: "myapp/db", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid Auto: Missing resource",
+			input:   "auto:",
+			wantErr: true,
+		},
+
+		// File Tests
+		{
+			name:    "File: Path with KeyPath",
+			input:   "file:/var/run/secrets/app/creds.json:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "file", Resource: "/var/run/secrets/app/creds.json", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid File: Missing path",
+			input:   "file:",
+			wantErr: true,
+		},
+
+		// CSV Tests
+		{
+			name:    "CSV: Path, row key and column",
+			input:   "csv:/etc/creds.csv:prod-db:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "csv", Resource: "/etc/creds.csv:prod-db", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid CSV: Missing row key",
+			input:   "csv:/etc/creds.csv:::password",
+			wantErr: true,
+		},
+
+		// Options
+		{
+			name:    "Options: Join after KeyPath",
+			input:   "aws:sm:cfg:::hosts?join=;",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "aws", Service: "sm", Resource: "cfg", KeyPath: "hosts",
+				Options: map[string]string{"join": ";"},
+			},
+		},
+		{
+			name:    "Options: Without KeyPath",
+			input:   "aws:sm:cfg:::?join=,",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "aws", Service: "sm", Resource: "cfg", KeyPath: "",
+				Options: map[string]string{"join": ","},
+			},
+		},
+		{
+			name:    "Options: Question mark in resource is not an option",
+			input:   "git:https://api.example.com/path?ref=main:::password",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "git", Resource: "https://api.example.com/path?ref=main", KeyPath: "password",
+			},
+		},
+		{
+			name:    "Invalid Options: Missing option name",
+			input:   "aws:sm:cfg:::hosts?=;",
+			wantErr: true,
+		},
+
+		// Defaults
+		{
+			name:    "Default: After KeyPath",
+			input:   "aws:sm:myapp/flag:::enabled||false",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "aws", Service: "sm", Resource: "myapp/flag", KeyPath: "enabled",
+				Default: "false", HasDefault: true,
+			},
+		},
+		{
+			name:    "Default: Containing colons and question marks",
+			input:   "aws:sm:myapp/cfg:::url?wrap=prefix:x||https://localhost:8080/?debug=1",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "aws", Service: "sm", Resource: "myapp/cfg", KeyPath: "url",
+				Options: map[string]string{"wrap": "prefix:x"},
+				Default: "https://localhost:8080/?debug=1", HasDefault: true,
+			},
+		},
+		{
+			name:    "Default: Empty default without KeyPath",
+			input:   "gcp:sm:my-project/token:::||",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "gcp", Service: "sm", Resource: "my-project/token", KeyPath: "",
+				Default: "", HasDefault: true,
+			},
+		},
+		{
+			name:    "Default: Pipes in resource are not a default",
+			input:   "aws:sm:a||b",
+			wantErr: false,
+			expected: parser.SecretSource{
+				Backend: "aws", Service: "sm", Resource: "a||b",
+			},
+		},
+
 		// Error Cases
 		{
 			name:    "Invalid: Missing Backend",
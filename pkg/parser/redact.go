@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// RedactMask replaces sensitive parts of resources and addresses in reports
+const RedactMask = "****"
+
+// arnAccountPattern matches the account ID field of an ARN (arn:partition:service:region:ACCOUNT:...)
+var arnAccountPattern = regexp.MustCompile(`(arn:[^:@/\s]*:[^:@/\s]*:[^:@/\s]*:)(\d{12})(:)`)
+
+// urlPasswordPattern matches the password of "scheme://user:password@" in text that isn't a parsable URL
+var urlPasswordPattern = regexp.MustCompile(`(://[^:/@\s]+:)[^@/\s]+(@)`)
+
+// RedactResource returns source's resource with embedded credentials and sensitive identifiers
+// masked, for every report that shows where a secret comes from (errors, --dry-run, lint):
+// - passwords and query values in git URLs
+// - AWS account IDs in ARNs, including "ROLE_ARN@" prefixes
+// - the inner address of remote resources, redacted the same way
+func RedactResource(source SecretSource) string {
+	switch source.Backend {
+	case "git":
+		return redactURL(source.Resource)
+	case "remote":
+		host, address, found := strings.Cut(strings.TrimPrefix(source.Resource, "ssh://"), "/")
+		if !found {
+			return redactText(source.Resource)
+		}
+		return "ssh://" + redactText(host) + "/" + RedactAddress(address)
+	default:
+		return redactText(source.Resource)
+	}
+}
+
+// RedactAddress returns a secret address with its resource redacted by RedactResource.
+// KMS ciphertexts and "||default" values are masked, since they may hold the secret itself.
+// Addresses that don't parse are still masked on a best-effort basis.
+func RedactAddress(address string) string {
+	prefix := ""
+	if rest, found := strings.CutPrefix(address, "secretinit:"); found {
+		prefix, address = "secretinit:", rest
+	}
+
+	source, err := parseSecretString(address)
+	if err != nil {
+		return prefix + redactText(address)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(prefix)
+	sb.WriteString(source.Backend)
+	sb.WriteByte(':')
+	if source.Service != "" {
+		sb.WriteString(source.Service)
+		sb.WriteByte(':')
+	}
+	sb.WriteString(RedactResource(source))
+
+	if source.KeyPath != "" || len(source.Options) > 0 || source.HasDefault {
+		sb.WriteString(":::")
+	}
+	if source.Backend == "aws" && source.Service == "kms" && source.KeyPath != "" {
+		sb.WriteString(RedactMask)
+	} else {
+		sb.WriteString(source.KeyPath)
+	}
+	if len(source.Options) > 0 {
+		names := make([]string, 0, len(source.Options))
+		for name := range source.Options {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for i, name := range names {
+			if i == 0 {
+				sb.WriteByte('?')
+			} else {
+				sb.WriteByte('&')
+			}
+			sb.WriteString(name)
+			if value := source.Options[name]; value != "" {
+				sb.WriteByte('=')
+				sb.WriteString(value)
+			}
+		}
+	}
+	if source.HasDefault {
+		sb.WriteString("||")
+		sb.WriteString(RedactMask)
+	}
+	return sb.String()
+}
+
+// redactURL masks the password and query values of a URL
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return redactText(rawURL)
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), RedactMask)
+	}
+	if u.RawQuery != "" {
+		query := u.Query()
+		for name := range query {
+			query[name] = []string{RedactMask}
+		}
+		u.RawQuery = query.Encode()
+	}
+	// Keep the mask readable instead of percent-encoded
+	return strings.ReplaceAll(u.String(), url.QueryEscape(RedactMask), RedactMask)
+}
+
+// redactText masks ARN account IDs and URL passwords anywhere in text
+func redactText(text string) string {
+	text = arnAccountPattern.ReplaceAllString(text, "${1}"+RedactMask+"${3}")
+	return urlPasswordPattern.ReplaceAllString(text, "${1}"+RedactMask+"${2}")
+}
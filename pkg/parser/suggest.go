@@ -0,0 +1,50 @@
+package parser
+
+import "fmt"
+
+// Backends lists the backend names ParseSecretString accepts, used to suggest a fix for typos
+var Backends = []string{"git", "remote", "wincred", "exec", "auto", "file", "csv", "aws", "gcp", "azure", "bitwarden"}
+
+// DidYouMean returns a " (did you mean 'x'?)" hint naming the candidate closest to name, or "" when
+// no candidate is close enough. Up to half the characters of name (at most two) may differ, so
+// "awss" suggests "aws" and "gpc" suggests "gcp", but unrelated names get no hint.
+func DidYouMean(name string, candidates []string) string {
+	maxDistance := min(len(name)/2, 2)
+	best, bestDistance := "", maxDistance+1
+	for _, candidate := range candidates {
+		if d := editDistance(name, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" || best == name {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean '%s'?)", best)
+}
+
+// editDistance returns the number of single-character insertions, deletions, substitutions and
+// adjacent transpositions needed to turn a into b (optimal string alignment distance)
+func editDistance(a, b string) int {
+	// d[i][j] is the distance between the first i bytes of a and the first j bytes of b
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDidYouMean(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"awss", " (did you mean 'aws'?)"},
+		{"gpc", " (did you mean 'gcp'?)"},
+		{"azrue", " (did you mean 'azure'?)"},
+		{"AWS", ""}, // Case matters: three substitutions is too far
+		{"bitwardn", " (did you mean 'bitwarden'?)"},
+		{"wincerd", " (did you mean 'wincred'?)"},
+		{"vault", ""},
+		{"aws", ""},
+		{"x", ""},
+	}
+	for _, tt := range tests {
+		if got := DidYouMean(tt.name, Backends); got != tt.expected {
+			t.Errorf("DidYouMean(%q) = %q, want %q", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"aws", "aws", 0},
+		{"aws", "awss", 1},
+		{"gpc", "gcp", 1},
+		{"kv", "sm", 2},
+		{"", "gcp", 3},
+		{"vault", "auto", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.expected {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.expected)
+		}
+	}
+}
+
+func TestParseSecretString_SuggestsBackend(t *testing.T) {
+	for address, suggestion := range map[string]string{
+		"awss:sm:myapp/db":                "did you mean 'aws'?",
+		"gpc:sm:myapp/db":                 "did you mean 'gcp'?",
+		"gti:https://github.com/org/repo": "did you mean 'git'?",
+	} {
+		_, err := ParseSecretString(address)
+		if err == nil {
+			t.Fatalf("ParseSecretString(%q) succeeded, want an error", address)
+		}
+		if !strings.Contains(err.Error(), "unsupported backend") || !strings.Contains(err.Error(), suggestion) {
+			t.Errorf("ParseSecretString(%q) error = %q, want a suggestion %q", address, err, suggestion)
+		}
+	}
+}
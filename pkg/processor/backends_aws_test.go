@@ -0,0 +1,6 @@
+//go:build aws_only
+
+package processor
+
+// expectedBuildVariant is the variant BuildVariant must report for these build tags
+const expectedBuildVariant = "aws_only"
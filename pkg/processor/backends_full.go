@@ -9,9 +9,22 @@ import (
 // RegisterAllBackends registers all available backends
 func RegisterAllBackends() map[string]func() (backend.Backend, error) {
 	return map[string]func() (backend.Backend, error){
-		"git":   func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
-		"aws":   func() (backend.Backend, error) { return backend.NewAWSBackend() },
-		"gcp":   func() (backend.Backend, error) { return backend.NewGCPBackend() },
-		"azure": func() (backend.Backend, error) { return backend.NewAzureBackend() },
+		"git":       func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
+		"aws":       func() (backend.Backend, error) { return backend.NewAWSBackend() },
+		"gcp":       func() (backend.Backend, error) { return backend.NewGCPBackend() },
+		"azure":     func() (backend.Backend, error) { return backend.NewAzureBackend() },
+		"remote":    func() (backend.Backend, error) { return backend.NewRemoteBackend() },
+		"wincred":   func() (backend.Backend, error) { return backend.NewWinCredBackend() },
+		"bitwarden": func() (backend.Backend, error) { return backend.NewBitwardenBackend() },
+		"exec":      func() (backend.Backend, error) { return backend.NewExecBackend() },
+		"csv":       func() (backend.Backend, error) { return backend.NewCSVBackend() },
+		"file":      func() (backend.Backend, error) { return backend.NewFileBackend() },
+		"auto": func() (backend.Backend, error) {
+			return backend.NewAutoBackend(map[string]func() (backend.Backend, error){
+				"aws":   func() (backend.Backend, error) { return backend.NewAWSBackend() },
+				"gcp":   func() (backend.Backend, error) { return backend.NewGCPBackend() },
+				"azure": func() (backend.Backend, error) { return backend.NewAzureBackend() },
+			})
+		},
 	}
 }
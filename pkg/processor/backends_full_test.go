@@ -0,0 +1,6 @@
+//go:build !aws_only && !gcp_only && !azure_only && !git_only
+
+package processor
+
+// expectedBuildVariant is the variant BuildVariant must report for these build tags
+const expectedBuildVariant = "full"
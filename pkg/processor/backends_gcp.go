@@ -6,10 +6,21 @@ import (
 	"github.com/liifi/secretinit/pkg/backend"
 )
 
-// RegisterAllBackends registers only git and gcp backends
+// RegisterAllBackends registers only git and gcp backends, plus the SDK-free remote, wincred, bitwarden, exec, csv and file backends, and auto limited to gcp
 func RegisterAllBackends() map[string]func() (backend.Backend, error) {
 	return map[string]func() (backend.Backend, error){
-		"git": func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
-		"gcp": func() (backend.Backend, error) { return backend.NewGCPBackend() },
+		"git":       func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
+		"gcp":       func() (backend.Backend, error) { return backend.NewGCPBackend() },
+		"remote":    func() (backend.Backend, error) { return backend.NewRemoteBackend() },
+		"wincred":   func() (backend.Backend, error) { return backend.NewWinCredBackend() },
+		"bitwarden": func() (backend.Backend, error) { return backend.NewBitwardenBackend() },
+		"exec":      func() (backend.Backend, error) { return backend.NewExecBackend() },
+		"csv":       func() (backend.Backend, error) { return backend.NewCSVBackend() },
+		"file":      func() (backend.Backend, error) { return backend.NewFileBackend() },
+		"auto": func() (backend.Backend, error) {
+			return backend.NewAutoBackend(map[string]func() (backend.Backend, error){
+				"gcp": func() (backend.Backend, error) { return backend.NewGCPBackend() },
+			})
+		},
 	}
 }
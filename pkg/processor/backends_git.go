@@ -6,9 +6,15 @@ import (
 	"github.com/liifi/secretinit/pkg/backend"
 )
 
-// RegisterAllBackends registers only git backend for minimal builds
+// RegisterAllBackends registers only git backend for minimal builds, plus the SDK-free remote, wincred, bitwarden, exec, csv and file backends
 func RegisterAllBackends() map[string]func() (backend.Backend, error) {
 	return map[string]func() (backend.Backend, error){
-		"git": func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
+		"git":       func() (backend.Backend, error) { return &backend.GitBackend{}, nil },
+		"remote":    func() (backend.Backend, error) { return backend.NewRemoteBackend() },
+		"wincred":   func() (backend.Backend, error) { return backend.NewWinCredBackend() },
+		"bitwarden": func() (backend.Backend, error) { return backend.NewBitwardenBackend() },
+		"exec":      func() (backend.Backend, error) { return backend.NewExecBackend() },
+		"csv":       func() (backend.Backend, error) { return backend.NewCSVBackend() },
+		"file":      func() (backend.Backend, error) { return backend.NewFileBackend() },
 	}
 }
@@ -0,0 +1,6 @@
+//go:build git_only
+
+package processor
+
+// expectedBuildVariant is the variant BuildVariant must report for these build tags
+const expectedBuildVariant = "git_only"
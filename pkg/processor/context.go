@@ -0,0 +1,36 @@
+package processor
+
+import (
+	"context"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// retrieveSecret retrieves a secret from b, giving up once ctx is done.
+// Backends without context support keep running in the background after that,
+// but the caller no longer waits for them.
+func retrieveSecret(ctx context.Context, b backend.Backend, service, resource, keyPath string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if contextBackend, ok := b.(backend.ContextBackend); ok {
+		return contextBackend.RetrieveSecretCtx(ctx, service, resource, keyPath)
+	}
+
+	type result struct {
+		value string
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := b.RetrieveSecret(service, resource, keyPath)
+		done <- result{value, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
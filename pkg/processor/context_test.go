@@ -0,0 +1,82 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// slowBackend blocks until released, without context support
+type slowBackend struct {
+	release chan struct{}
+}
+
+func (b *slowBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	<-b.release
+	return "late", nil
+}
+
+// contextBackend blocks until its context is done
+type contextBackend struct{}
+
+func (b *contextBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	return b.RetrieveSecretCtx(context.Background(), service, resource, keyPath)
+}
+
+func (b *contextBackend) RetrieveSecretCtx(ctx context.Context, service, resource, keyPath string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestProcessSecretsCtx_Timeout(t *testing.T) {
+	slow := &slowBackend{release: make(chan struct{})}
+	defer close(slow.release)
+
+	tests := []struct {
+		name    string
+		backend backend.Backend
+	}{
+		{name: "backend without context support", backend: slow},
+		{name: "context-aware backend", backend: &contextBackend{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", tt.backend)
+			// A deadline must not fall back to the defaults file
+			proc.SetDefaults(map[string]string{"API_KEY": "default"})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			start := time.Now()
+			_, err := proc.ProcessSecretsCtx(ctx, map[string]string{"API_KEY": "aws:sm:myapp/api-key"})
+			if err == nil {
+				t.Fatal("expected a timeout error")
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("expected context.DeadlineExceeded, got %v", err)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("resolution took %v after the deadline", elapsed)
+			}
+		})
+	}
+}
+
+func TestProcessSecretsCtx_NoDeadline(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{secretValue: "value"})
+
+	result, err := proc.ProcessSecretsCtx(context.Background(), map[string]string{"API_KEY": "aws:sm:myapp/api-key"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result["API_KEY"] != "value" {
+		t.Errorf("expected value, got %q", result["API_KEY"])
+	}
+}
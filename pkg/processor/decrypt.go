@@ -0,0 +1,111 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// naclNonceSize is the size of the nonce prepended to NaCl secretbox ciphertexts
+const naclNonceSize = 24
+
+// decryptKeyVar returns the variable named by a "SCHEME:KEY_VAR" decrypt spec
+func decryptKeyVar(spec string) string {
+	_, keyVar, _ := strings.Cut(spec, ":")
+	return keyVar
+}
+
+// decryptValue decrypts a retrieved value for the "?decrypt=SCHEME:KEY_VAR" option.
+// The key is read from KEY_VAR with lookupVar. Supported schemes:
+//   - age:  KEY_VAR holds an age identity (AGE-SECRET-KEY-1...); the value is ASCII-armored or base64 age ciphertext
+//   - nacl: KEY_VAR holds a base64 32-byte key; the value is base64 of a 24-byte nonce followed by the secretbox
+func decryptValue(value, spec string, lookupVar func(string) (string, bool)) (string, error) {
+	if err := checkDecryptSpec(spec); err != nil {
+		return "", err
+	}
+	scheme, keyVar, _ := strings.Cut(spec, ":")
+
+	key, ok := lookupVar(keyVar)
+	if !ok || key == "" {
+		return "", fmt.Errorf("decryption key variable '%s' is not set", keyVar)
+	}
+
+	if scheme == "age" {
+		return decryptAge(value, key)
+	}
+	return decryptNaCl(value, key)
+}
+
+// checkDecryptSpec returns an error if a "?decrypt=" spec is not "age:KEY_VAR" or "nacl:KEY_VAR"
+func checkDecryptSpec(spec string) error {
+	scheme, keyVar, found := strings.Cut(spec, ":")
+	if !found || keyVar == "" {
+		return fmt.Errorf("invalid decrypt option '%s': expected 'age:KEY_VAR' or 'nacl:KEY_VAR'", spec)
+	}
+	if scheme != "age" && scheme != "nacl" {
+		return fmt.Errorf("unsupported decrypt scheme '%s': expected 'age' or 'nacl'", scheme)
+	}
+	return nil
+}
+
+// decryptAge decrypts ASCII-armored or base64-encoded age ciphertext with the given identities
+func decryptAge(value, key string) (string, error) {
+	identities, err := age.ParseIdentities(strings.NewReader(key))
+	if err != nil {
+		return "", fmt.Errorf("invalid age identity: %w", err)
+	}
+
+	value = strings.TrimSpace(value)
+	var ciphertext io.Reader
+	if strings.HasPrefix(value, armor.Header) {
+		ciphertext = armor.NewReader(strings.NewReader(value))
+	} else {
+		data, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("age ciphertext must be ASCII-armored or base64: %w", err)
+		}
+		ciphertext = bytes.NewReader(data)
+	}
+
+	reader, err := age.Decrypt(ciphertext, identities...)
+	if err != nil {
+		return "", fmt.Errorf("age decryption failed: %w", err)
+	}
+	plaintext, err := io.ReadAll(reader)
+	if err != nil {
+		return "", fmt.Errorf("age decryption failed: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// decryptNaCl decrypts a base64 NaCl secretbox (nonce followed by the sealed box)
+func decryptNaCl(value, key string) (string, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+	if err != nil || len(keyBytes) != 32 {
+		return "", fmt.Errorf("nacl key must be 32 bytes, base64-encoded")
+	}
+	var secretKey [32]byte
+	copy(secretKey[:], keyBytes)
+
+	box, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil {
+		return "", fmt.Errorf("nacl ciphertext must be base64: %w", err)
+	}
+	if len(box) < naclNonceSize+secretbox.Overhead {
+		return "", fmt.Errorf("nacl ciphertext is too short")
+	}
+
+	var nonce [naclNonceSize]byte
+	copy(nonce[:], box[:naclNonceSize])
+	plaintext, ok := secretbox.Open(nil, box[naclNonceSize:], &nonce, &secretKey)
+	if !ok {
+		return "", fmt.Errorf("nacl decryption failed: wrong key or corrupted ciphertext")
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,177 @@
+package processor
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// ageEncrypt encrypts plaintext to identity's recipient, ASCII-armored or base64-encoded
+func ageEncrypt(t *testing.T, identity *age.X25519Identity, plaintext string, armored bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var out io.WriteCloser = nopWriteCloser{&buf}
+	if armored {
+		out = armor.NewWriter(&buf)
+	}
+	w, err := age.Encrypt(out, identity.Recipient())
+	if err != nil {
+		t.Fatalf("age.Encrypt failed: %v", err)
+	}
+	io.WriteString(w, plaintext)
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close age writer: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("failed to close armor writer: %v", err)
+	}
+	if armored {
+		return buf.String()
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+// naclEncrypt seals plaintext with a random nonce and returns the base64 key and ciphertext
+func naclEncrypt(t *testing.T, plaintext string) (string, string) {
+	t.Helper()
+	var key [32]byte
+	var nonce [naclNonceSize]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		t.Fatal(err)
+	}
+	box := secretbox.Seal(nonce[:], []byte(plaintext), &nonce, &key)
+	return base64.StdEncoding.EncodeToString(key[:]), base64.StdEncoding.EncodeToString(box)
+}
+
+func TestProcessSecrets_DecryptOption(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+	otherIdentity, _ := age.GenerateX25519Identity()
+	naclKey, naclCiphertext := naclEncrypt(t, `["a","b"]`)
+
+	t.Setenv("APP_AGE_KEY", identity.String())
+	t.Setenv("OTHER_AGE_KEY", otherIdentity.String())
+	t.Setenv("APP_NACL_KEY", naclKey)
+
+	tests := []struct {
+		name        string
+		address     string
+		secretValue string
+		expected    string
+		errorMsg    string
+		errorKind   ErrorKind
+	}{
+		{
+			name:        "age armored",
+			address:     "aws:sm:db:::?decrypt=age:APP_AGE_KEY",
+			secretValue: ageEncrypt(t, identity, "s3cret", true),
+			expected:    "s3cret",
+		},
+		{
+			name:        "age base64",
+			address:     "aws:sm:db:::?decrypt=age:APP_AGE_KEY",
+			secretValue: ageEncrypt(t, identity, "s3cret", false),
+			expected:    "s3cret",
+		},
+		{
+			name:        "nacl decrypt then join",
+			address:     "aws:sm:db:::?decrypt=nacl:APP_NACL_KEY&join=,",
+			secretValue: naclCiphertext,
+			expected:    "a,b",
+		},
+		{
+			name:        "age with wrong identity fails",
+			address:     "aws:sm:db:::?decrypt=age:OTHER_AGE_KEY",
+			secretValue: ageEncrypt(t, identity, "s3cret", true),
+			errorMsg:    "age decryption failed",
+			errorKind:   ErrorKindRetrieval,
+		},
+		{
+			name:        "nacl with wrong key fails",
+			address:     "aws:sm:db:::?decrypt=nacl:APP_NACL_KEY",
+			secretValue: func() string { _, c := naclEncrypt(t, "x"); return c }(),
+			errorMsg:    "nacl decryption failed",
+			errorKind:   ErrorKindRetrieval,
+		},
+		{
+			name:        "missing key variable fails",
+			address:     "aws:sm:db:::?decrypt=age:NOT_SET_KEY",
+			secretValue: "irrelevant",
+			errorMsg:    "decryption key variable 'NOT_SET_KEY' is not set",
+			errorKind:   ErrorKindRetrieval,
+		},
+		{
+			name:        "unknown scheme fails",
+			address:     "aws:sm:db:::?decrypt=rot13:APP_AGE_KEY",
+			secretValue: "irrelevant",
+			errorMsg:    "unsupported decrypt scheme 'rot13'",
+			errorKind:   ErrorKindParse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", &MockAWSBackend{secretValue: tt.secretValue})
+
+			result, err := proc.ProcessSecrets(map[string]string{"VALUE": tt.address})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("Expected error containing '%s', got %v", tt.errorMsg, err)
+				}
+				// Only a malformed option is an address error, failing to decrypt the value is not
+				if kind := ErrorKindOf(err); kind != tt.errorKind {
+					t.Errorf("Expected error kind %v, got %v", tt.errorKind, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result["VALUE"] != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result["VALUE"])
+			}
+		})
+	}
+}
+
+func TestProcessSecrets_DecryptKeyFromSecret(t *testing.T) {
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatalf("failed to generate age identity: %v", err)
+	}
+
+	// The key itself is a secret variable, so it must be resolved before the value it decrypts
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", mapBackend{
+		"db":  ageEncrypt(t, identity, "s3cret", true),
+		"key": identity.String(),
+	})
+
+	result, err := proc.ProcessSecrets(map[string]string{
+		"DB_PASSWORD": "aws:sm:db:::?decrypt=age:ZZ_AGE_KEY",
+		"ZZ_AGE_KEY":  "aws:sm:key",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result["DB_PASSWORD"] != "s3cret" {
+		t.Errorf("Expected decrypted value, got %q", result["DB_PASSWORD"])
+	}
+}
@@ -0,0 +1,109 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+func TestProcessSecrets_DefaultsFallback(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{err: errors.New("unable to reach AWS")})
+	proc.SetDefaults(map[string]string{"DB_PASSWORD": "local-dev-password"})
+
+	var result map[string]string
+	var err error
+	stderr := captureStderr(t, func() {
+		result, err = proc.ProcessSecrets(map[string]string{"DB_PASSWORD": "aws:sm:myapp/db:::password"})
+	})
+	if err != nil {
+		t.Fatalf("Expected failed fetch to fall back to default, got error: %v", err)
+	}
+	if result["DB_PASSWORD"] != "local-dev-password" {
+		t.Errorf("Expected default value, got '%s'", result["DB_PASSWORD"])
+	}
+
+	// The fallback is logged by variable name, never with its value
+	if !strings.Contains(stderr, "using the defaults file value for variable 'DB_PASSWORD'") {
+		t.Errorf("Expected a warning about the default, got %q", stderr)
+	}
+	if strings.Contains(stderr, "local-dev-password") {
+		t.Errorf("Warning leaked the default value: %q", stderr)
+	}
+
+	// Variables without a default entry still fail
+	_, err = proc.ProcessSecrets(map[string]string{"API_KEY": "aws:sm:myapp/api-key"})
+	if err == nil {
+		t.Error("Expected error for variable without a default")
+	}
+}
+
+func TestProcessSecrets_DefaultsNotUsedOnSuccess(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{secretValue: "real-value"})
+	proc.SetDefaults(map[string]string{"API_KEY": "default-value"})
+
+	result, err := proc.ProcessSecrets(map[string]string{"API_KEY": "aws:sm:myapp/api-key"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result["API_KEY"] != "real-value" {
+		t.Errorf("Expected resolved value to win over default, got '%s'", result["API_KEY"])
+	}
+}
+
+func TestProcessSecrets_AddressDefault(t *testing.T) {
+	notFoundErr := fmt.Errorf("secret myapp/flag: %w", backend.ErrSecretNotFound)
+
+	tests := []struct {
+		name      string
+		backend   *MockAWSBackend
+		address   string
+		expected  string
+		expectErr bool
+	}{
+		{name: "not found uses default", backend: &MockAWSBackend{err: notFoundErr}, address: "aws:sm:myapp/flag:::enabled||false", expected: "false"},
+		{name: "empty default", backend: &MockAWSBackend{err: notFoundErr}, address: "aws:sm:myapp/flag:::||", expected: ""},
+		{name: "found value wins", backend: &MockAWSBackend{secretValue: "true"}, address: "aws:sm:myapp/flag||false", expected: "true"},
+		{name: "other errors still fail", backend: &MockAWSBackend{err: errors.New("access denied")}, address: "aws:sm:myapp/flag:::enabled||false", expectErr: true},
+		{name: "not found without default fails", backend: &MockAWSBackend{err: notFoundErr}, address: "aws:sm:myapp/flag:::enabled", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", tt.backend)
+
+			result, err := proc.ProcessSecrets(map[string]string{"FLAG": tt.address})
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("error = %v, expectErr %v", err, tt.expectErr)
+			}
+			if !tt.expectErr && result["FLAG"] != tt.expected {
+				t.Errorf("FLAG = %q, want %q", result["FLAG"], tt.expected)
+			}
+		})
+	}
+}
+
+// captureStderr returns what fn writes to stderr
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+	writer.Close()
+	output, _ := io.ReadAll(reader)
+	reader.Close()
+	return string(output)
+}
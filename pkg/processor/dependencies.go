@@ -0,0 +1,123 @@
+package processor
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// dependencyOptions lists the address options whose value names another secret variable
+// that must be resolved first.
+var dependencyOptions = []string{"client_cert", "client_key"}
+
+// resolutionOrder returns the variable names of secretVars ordered so that every variable
+// comes after the variables it depends on (via ?client_cert= / ?client_key=, or a ?decrypt= key
+// that is itself a secret variable).
+// Addresses that fail to parse are kept in the order so ProcessSecrets reports them.
+func resolutionOrder(secretVars map[string]string) ([]string, error) {
+	names := make([]string, 0, len(secretVars))
+	for name := range secretVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	deps := make(map[string][]string)
+	for _, name := range names {
+		secretSource, err := parser.ParseSecretString(secretVars[name])
+		if err != nil {
+			continue
+		}
+		for _, opt := range dependencyOptions {
+			dep, ok := secretSource.Options[opt]
+			if !ok {
+				continue
+			}
+			if _, exists := secretVars[dep]; !exists {
+				return nil, &SecretError{Kind: ErrorKindParse, Variable: name, Backend: secretSource.Backend, Err: fmt.Errorf("variable '%s' depends on '%s' (%s), which is not a secret variable", name, dep, opt)}
+			}
+			deps[name] = append(deps[name], dep)
+		}
+		// Decryption keys may come from the process environment, so only secret variables are dependencies
+		if spec, ok := secretSource.Options["decrypt"]; ok {
+			if keyVar := decryptKeyVar(spec); keyVar != "" {
+				if _, exists := secretVars[keyVar]; exists {
+					deps[name] = append(deps[name], keyVar)
+				}
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int)
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return &SecretError{Kind: ErrorKindParse, Variable: name, Err: fmt.Errorf("dependency cycle between secret variables: %v", append(path, name))}
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// withClientCertificate returns b configured with the TLS client certificate named by the
+// address's client_cert (and optional client_key) options, taken from already resolved secrets.
+// When only client_cert is given, its value must contain both the certificate and the key PEM blocks.
+// The returned kind tells option errors (ErrorKindParse) from unusable certificate secrets
+// (ErrorKindRetrieval) and backends failing to take the certificate (ErrorKindBackendUnavailable).
+func withClientCertificate(b backend.Backend, secretSource parser.SecretSource, resolvedSecrets map[string]string) (backend.Backend, ErrorKind, error) {
+	certVar, ok := secretSource.Options["client_cert"]
+	if !ok {
+		if _, hasKey := secretSource.Options["client_key"]; hasKey {
+			return nil, ErrorKindParse, fmt.Errorf("option 'client_key' requires 'client_cert'")
+		}
+		return b, 0, nil
+	}
+
+	certBackend, ok := b.(backend.ClientCertBackend)
+	if !ok {
+		return nil, ErrorKindParse, fmt.Errorf("backend '%s' does not support client certificates", secretSource.Backend)
+	}
+
+	certPEM := resolvedSecrets[certVar]
+	keyPEM := certPEM
+	if keyVar, ok := secretSource.Options["client_key"]; ok {
+		keyPEM = resolvedSecrets[keyVar]
+	}
+
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, ErrorKindRetrieval, fmt.Errorf("invalid client certificate from '%s': %w", certVar, err)
+	}
+
+	withCert, err := certBackend.WithClientCertificate(cert)
+	if err != nil {
+		return nil, ErrorKindBackendUnavailable, err
+	}
+	return withCert, 0, nil
+}
@@ -0,0 +1,228 @@
+package processor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// mapBackend returns secrets by resource name
+type mapBackend map[string]string
+
+func (m mapBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	value, ok := m[resource]
+	if !ok {
+		return "", fmt.Errorf("secret %s not found", resource)
+	}
+	return value, nil
+}
+
+// mtlsBackend only returns secrets once it has been given a client certificate
+type mtlsBackend struct {
+	secrets map[string]string
+	cert    *tls.Certificate
+}
+
+func (m *mtlsBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	if m.cert == nil {
+		return "", fmt.Errorf("tls: certificate required")
+	}
+	return m.secrets[resource] + "@" + m.cert.Leaf.Subject.CommonName, nil
+}
+
+func (m *mtlsBackend) WithClientCertificate(cert tls.Certificate) (backend.Backend, error) {
+	return &mtlsBackend{secrets: m.secrets, cert: &cert}, nil
+}
+
+// testClientCert generates a self-signed certificate and returns its certificate and key PEM blocks
+func testClientCert(t *testing.T, commonName string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return string(certPEM), string(keyPEM)
+}
+
+func TestResolutionOrder(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretVars map[string]string
+		expected   []string
+		errorMsg   string
+	}{
+		{
+			name: "independent variables are sorted",
+			secretVars: map[string]string{
+				"B": "aws:sm:b",
+				"A": "aws:sm:a",
+			},
+			expected: []string{"A", "B"},
+		},
+		{
+			name: "dependencies come first",
+			secretVars: map[string]string{
+				"API":  "gcp:sm:p/api:::?client_cert=CERT&client_key=KEY",
+				"CERT": "aws:sm:cert",
+				"KEY":  "aws:sm:key",
+			},
+			expected: []string{"CERT", "KEY", "API"},
+		},
+		{
+			name: "chained dependencies",
+			secretVars: map[string]string{
+				"A": "gcp:sm:p/a:::?client_cert=B",
+				"B": "gcp:sm:p/b:::?client_cert=C",
+				"C": "aws:sm:c",
+			},
+			expected: []string{"C", "B", "A"},
+		},
+		{
+			name: "cycle fails",
+			secretVars: map[string]string{
+				"A": "gcp:sm:p/a:::?client_cert=B",
+				"B": "gcp:sm:p/b:::?client_cert=A",
+			},
+			errorMsg: "dependency cycle",
+		},
+		{
+			name: "missing dependency fails",
+			secretVars: map[string]string{
+				"A": "gcp:sm:p/a:::?client_cert=NOPE",
+			},
+			errorMsg: "depends on 'NOPE'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := resolutionOrder(tt.secretVars)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("Expected error containing '%s', got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if strings.Join(order, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected order %v, got %v", tt.expected, order)
+			}
+		})
+	}
+}
+
+func TestProcessSecrets_ClientCert(t *testing.T) {
+	certPEM, keyPEM := testClientCert(t, "secretinit-test")
+
+	tests := []struct {
+		name       string
+		secretVars map[string]string
+		expected   string
+		errorMsg   string
+		errorKind  ErrorKind
+	}{
+		{
+			name: "combined cert and key",
+			secretVars: map[string]string{
+				"API_TOKEN":   "gcp:sm:p/token:::?client_cert=CLIENT_CERT",
+				"CLIENT_CERT": "aws:sm:combined",
+			},
+			expected: "token-value@secretinit-test",
+		},
+		{
+			name: "separate cert and key",
+			secretVars: map[string]string{
+				"API_TOKEN":  "gcp:sm:p/token:::?client_cert=CLIENT_CRT&client_key=CLIENT_KEY",
+				"CLIENT_CRT": "aws:sm:cert",
+				"CLIENT_KEY": "aws:sm:key",
+			},
+			expected: "token-value@secretinit-test",
+		},
+		{
+			name: "invalid certificate fails",
+			secretVars: map[string]string{
+				"API_TOKEN":  "gcp:sm:p/token:::?client_cert=CLIENT_KEY",
+				"CLIENT_KEY": "aws:sm:key",
+			},
+			errorMsg:  "invalid client certificate from 'CLIENT_KEY'",
+			errorKind: ErrorKindRetrieval,
+		},
+		{
+			name: "backend without client certificate support fails",
+			secretVars: map[string]string{
+				"OTHER":       "aws:sm:cert:::?client_cert=CLIENT_CERT",
+				"CLIENT_CERT": "aws:sm:combined",
+			},
+			errorMsg:  "backend 'aws' does not support client certificates",
+			errorKind: ErrorKindParse,
+		},
+		{
+			name: "client_key without client_cert fails",
+			secretVars: map[string]string{
+				"API_TOKEN":  "gcp:sm:p/token:::?client_key=CLIENT_KEY",
+				"CLIENT_KEY": "aws:sm:key",
+			},
+			errorMsg:  "option 'client_key' requires 'client_cert'",
+			errorKind: ErrorKindParse,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", mapBackend{
+				"cert":     certPEM,
+				"key":      keyPEM,
+				"combined": certPEM + keyPEM,
+			})
+			proc.RegisterBackend("gcp", &mtlsBackend{secrets: map[string]string{"p/token": "token-value"}})
+
+			result, err := proc.ProcessSecrets(tt.secretVars)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("Expected error containing '%s', got %v", tt.errorMsg, err)
+				}
+				if kind := ErrorKindOf(err); kind != tt.errorKind {
+					t.Errorf("Expected error kind %v, got %v", tt.errorKind, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result["API_TOKEN"] != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result["API_TOKEN"])
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"errors"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// ErrorKind classifies why a secret could not be resolved
+type ErrorKind int
+
+const (
+	// ErrorKindRetrieval means the backend failed to return the secret
+	ErrorKindRetrieval ErrorKind = iota
+	// ErrorKindParse means the secret address is invalid
+	ErrorKindParse
+	// ErrorKindBackendUnavailable means the backend is not compiled in or failed to initialize
+	ErrorKindBackendUnavailable
+)
+
+// String returns a short machine-readable name for the error kind
+func (k ErrorKind) String() string {
+	switch k {
+	case ErrorKindParse:
+		return "parse_error"
+	case ErrorKindBackendUnavailable:
+		return "backend_unavailable"
+	default:
+		return "retrieval_failed"
+	}
+}
+
+// SecretError is returned by the processor when a secret cannot be resolved.
+// The message is the wrapped error's message, so it reads the same as a plain error.
+type SecretError struct {
+	Kind     ErrorKind
+	Variable string // Variable name, empty when not tied to a single variable
+	Backend  string // Backend name, empty when the address could not be parsed
+	Err      error
+}
+
+func (e *SecretError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *SecretError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorKindOf returns the kind of the first SecretError in err's chain.
+// Errors that are not SecretErrors are reported as retrieval failures.
+func ErrorKindOf(err error) ErrorKind {
+	var secretErr *SecretError
+	if errors.As(err, &secretErr) {
+		return secretErr.Kind
+	}
+	return ErrorKindRetrieval
+}
+
+// isNotFound reports whether a backend error means the secret (or a key within it) doesn't exist
+func isNotFound(err error) bool {
+	return errors.Is(err, backend.ErrSecretNotFound)
+}
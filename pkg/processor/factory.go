@@ -1,6 +1,7 @@
 package processor
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
@@ -15,7 +16,9 @@ func NewProcessorForSecrets(secrets map[string]string) (*SecretProcessor, error)
 	return NewProcessorWithBackends(neededBackends)
 }
 
-// NewProcessorWithBackends creates a processor with the specified backends
+// NewProcessorWithBackends creates a processor with the specified backends.
+// Backends missing from this build fail immediately, but each backend is only
+// constructed when the first secret that needs it is resolved.
 func NewProcessorWithBackends(backendNames []string) (*SecretProcessor, error) {
 	proc := NewSecretProcessor()
 
@@ -25,15 +28,10 @@ func NewProcessorWithBackends(backendNames []string) (*SecretProcessor, error) {
 	for _, name := range backendNames {
 		factory, exists := backendFactories[name]
 		if !exists {
-			return nil, fmt.Errorf("backend not available in this build: %s", name)
+			return nil, &SecretError{Kind: ErrorKindBackendUnavailable, Backend: name, Err: fmt.Errorf("backend not available in this build: %s", name)}
 		}
 
-		backend, err := factory()
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize %s backend: %v", name, err)
-		}
-
-		proc.RegisterBackend(name, backend)
+		proc.RegisterBackendFactory(name, factory)
 	}
 
 	return proc, nil
@@ -74,6 +72,11 @@ func ScanForRequiredBackends(secrets map[string]string) []string {
 
 // ProcessSingleSecret is a convenience function for processing a single secret
 func ProcessSingleSecret(secretAddress string) (string, error) {
+	return ProcessSingleSecretCtx(context.Background(), secretAddress)
+}
+
+// ProcessSingleSecretCtx is ProcessSingleSecret bounded by ctx
+func ProcessSingleSecretCtx(ctx context.Context, secretAddress string) (string, error) {
 	// Remove secretinit: prefix if present, as the processor expects raw backend format
 	secretAddress = strings.TrimPrefix(secretAddress, "secretinit:")
 
@@ -81,11 +84,16 @@ func ProcessSingleSecret(secretAddress string) (string, error) {
 	// This improves user experience as users typically want the password when using --stdout
 	parsed, err := parser.ParseSecretString(secretAddress)
 	if err != nil {
-		return "", err
+		return "", &SecretError{Kind: ErrorKindParse, Err: err}
 	}
 
 	if parsed.Backend == "git" && parsed.KeyPath == "" {
-		secretAddress += ":::password"
+		if strings.Contains(secretAddress, ":::") {
+			// Only options were given (e.g. ":::?join=,"), insert the keyPath before them
+			secretAddress = strings.Replace(secretAddress, ":::", ":::password", 1)
+		} else {
+			secretAddress += ":::password"
+		}
 	}
 
 	secrets := map[string]string{"TEMP_KEY": secretAddress}
@@ -94,7 +102,7 @@ func ProcessSingleSecret(secretAddress string) (string, error) {
 		return "", err
 	}
 
-	retrievedSecrets, err := proc.ProcessSecrets(secrets)
+	retrievedSecrets, err := proc.ProcessSecretsCtx(ctx, secrets)
 	if err != nil {
 		return "", err
 	}
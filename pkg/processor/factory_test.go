@@ -0,0 +1,91 @@
+package processor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+func TestSecretProcessor_LazyBackendFactory(t *testing.T) {
+	proc := NewSecretProcessor()
+
+	awsCalls, gcpCalls := 0, 0
+	proc.RegisterBackendFactory("aws", func() (backend.Backend, error) {
+		awsCalls++
+		return &MockAWSBackend{secretValue: "aws-value"}, nil
+	})
+	proc.RegisterBackendFactory("gcp", func() (backend.Backend, error) {
+		gcpCalls++
+		return nil, errors.New("no GCP credentials")
+	})
+
+	if awsCalls != 0 || gcpCalls != 0 {
+		t.Fatalf("factories called on registration: aws=%d gcp=%d", awsCalls, gcpCalls)
+	}
+
+	// Only the backend of the resolved secret is constructed, and only once
+	for i := 0; i < 2; i++ {
+		result, err := proc.ProcessSecrets(map[string]string{"API_KEY": "aws:sm:myapp/api-key", "DB_PASSWORD": "aws:ps:/myapp/db"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result["API_KEY"] != "aws-value" {
+			t.Errorf("expected aws-value, got %q", result["API_KEY"])
+		}
+	}
+	if awsCalls != 1 {
+		t.Errorf("expected the aws factory to be called once, got %d", awsCalls)
+	}
+	if gcpCalls != 0 {
+		t.Errorf("expected the gcp factory not to be called, got %d", gcpCalls)
+	}
+
+	// A failing factory surfaces as an unavailable backend when its secret is resolved
+	_, err := proc.ProcessSecrets(map[string]string{"TOKEN": "gcp:sm:project/token"})
+	if err == nil {
+		t.Fatal("expected an error from the failing gcp factory")
+	}
+	if kind := ErrorKindOf(err); kind != ErrorKindBackendUnavailable {
+		t.Errorf("expected ErrorKindBackendUnavailable, got %v", kind)
+	}
+	if gcpCalls != 1 {
+		t.Errorf("expected the gcp factory to be called once, got %d", gcpCalls)
+	}
+}
+
+func TestNewProcessorWithBackends_Lazy(t *testing.T) {
+	// Backends missing from the build still fail up front
+	if _, err := NewProcessorWithBackends([]string{"not-a-backend"}); err == nil {
+		t.Error("expected an error for a backend missing from this build")
+	}
+
+	proc, err := NewProcessorWithBackends([]string{"git"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(proc.backends) != 0 {
+		t.Errorf("expected no backend to be constructed before use, got %v", proc.backends)
+	}
+}
+
+func TestSecretProcessor_GetCacheStats(t *testing.T) {
+	backend.ClearGlobalCache()
+	defer backend.ClearGlobalCache()
+
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{})
+	proc.RegisterBackend("git", &MockGitBackend{})
+
+	cache := backend.GetGlobalCache()
+	cache.Set("aws:sm:myapp/api-key", "value")
+	cache.Set("aws:ps:/myapp/db", "value")
+	cache.Set("git::https://api.example.com", "value")
+	cache.Set("gcp:sm:projects/p/secrets/s", "value") // gcp is not used by this processor
+
+	stats := proc.GetCacheStats()
+	expected := map[string]int{"aws": 2, "git": 1}
+	if len(stats) != len(expected) || stats["aws"] != 2 || stats["git"] != 1 {
+		t.Errorf("GetCacheStats() = %v, want %v", stats, expected)
+	}
+}
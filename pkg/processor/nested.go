@@ -0,0 +1,127 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/logging"
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// NestedMode is what ProcessSecrets does with a resolved value that is itself a secretinit: address
+type NestedMode int
+
+const (
+	// NestedWarn passes nested addresses on unresolved and warns about them (the default)
+	NestedWarn NestedMode = iota
+	// NestedResolve resolves nested addresses, up to maxNestedDepth levels deep
+	NestedResolve
+	// NestedError fails on a nested address
+	NestedError
+)
+
+// maxNestedDepth bounds how many levels of secretinit: indirection NestedResolve follows,
+// so an address that resolves to itself (directly or through others) can't loop forever
+const maxNestedDepth = 5
+
+// ParseNestedMode parses a --resolve-nested value: warn, resolve or error
+func ParseNestedMode(name string) (NestedMode, error) {
+	switch strings.ToLower(name) {
+	case "warn":
+		return NestedWarn, nil
+	case "resolve":
+		return NestedResolve, nil
+	case "error":
+		return NestedError, nil
+	}
+	return NestedWarn, fmt.Errorf("invalid nested mode '%s': expected warn, resolve or error", name)
+}
+
+// SetNestedMode sets what happens to resolved values that are secretinit: addresses themselves
+func (p *SecretProcessor) SetNestedMode(mode NestedMode) {
+	p.nestedMode = mode
+}
+
+// handleNested applies the nested mode to the resolved values, replacing them in resolved when
+// nested addresses are resolved. Returns the variables whose nested address failed and were
+// left out under --continue-on-error, with their error.
+func (p *SecretProcessor) handleNested(ctx context.Context, resolved map[string]string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for depth := 0; ; depth++ {
+		nested := nestedAddresses(resolved)
+		if len(nested) == 0 {
+			return failed, nil
+		}
+		varNames := make([]string, 0, len(nested))
+		for varName := range nested {
+			varNames = append(varNames, varName)
+		}
+		sort.Strings(varNames)
+
+		switch {
+		case p.nestedMode == NestedWarn:
+			for _, varName := range varNames {
+				logging.Printf(logging.LevelWarn, "Warning: secret for variable '%s' is itself a secretinit: address (%s), passed on unresolved; see --resolve-nested", varName, parser.RedactAddress(nested[varName]))
+			}
+			return failed, nil
+		case p.nestedMode == NestedError:
+			varName := varNames[0]
+			return nil, &SecretError{Kind: ErrorKindParse, Variable: varName, Err: fmt.Errorf("secret for variable '%s' is itself a secretinit: address (%s); use --resolve-nested resolve to follow it", varName, parser.RedactAddress(nested[varName]))}
+		case depth == maxNestedDepth:
+			varName := varNames[0]
+			return nil, &SecretError{Kind: ErrorKindParse, Variable: varName, Err: fmt.Errorf("secret for variable '%s' is still a secretinit: address after %d levels of nesting (%s), addresses may refer to each other", varName, maxNestedDepth, parser.RedactAddress(nested[varName]))}
+		}
+
+		if err := p.registerMissingBackends(nested); err != nil {
+			return nil, err
+		}
+		values, failedNested, err := p.resolveSecrets(ctx, nested)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(failed, failedNested)
+		// Git multi-credential addresses replace the variable with NAME_URL, NAME_USER, ...
+		for varName := range nested {
+			delete(resolved, varName)
+		}
+		for name, value := range values {
+			resolved[name] = value
+		}
+	}
+}
+
+// nestedAddresses returns the resolved values that are secretinit: addresses, without the prefix
+func nestedAddresses(resolved map[string]string) map[string]string {
+	nested := make(map[string]string)
+	for varName, value := range resolved {
+		if address, isAddress := strings.CutPrefix(value, "secretinit:"); isAddress {
+			nested[varName] = address
+		}
+	}
+	return nested
+}
+
+// registerMissingBackends registers the factories of backends a nested address needs that
+// were not needed by the original addresses
+func (p *SecretProcessor) registerMissingBackends(addresses map[string]string) error {
+	available := RegisterAllBackends()
+	p.backendsMutex.Lock()
+	defer p.backendsMutex.Unlock()
+	for _, name := range ScanForRequiredBackends(addresses) {
+		if _, exists := p.backends[name]; exists {
+			continue
+		}
+		if _, exists := p.factories[name]; exists {
+			continue
+		}
+		factory, exists := available[name]
+		if !exists {
+			return &SecretError{Kind: ErrorKindBackendUnavailable, Backend: name, Err: fmt.Errorf("backend not available in this build: %s", name)}
+		}
+		p.factories[name] = factory
+	}
+	return nil
+}
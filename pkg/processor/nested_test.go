@@ -0,0 +1,73 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+func TestParseNestedMode(t *testing.T) {
+	for name, want := range map[string]NestedMode{"warn": NestedWarn, "resolve": NestedResolve, "ERROR": NestedError} {
+		if mode, err := ParseNestedMode(name); err != nil || mode != want {
+			t.Errorf("ParseNestedMode(%q) = %v, %v, want %v", name, mode, err, want)
+		}
+	}
+	if _, err := ParseNestedMode("follow"); err == nil {
+		t.Error("ParseNestedMode(follow) succeeded, want an error")
+	}
+}
+
+func TestProcessSecrets_Nested(t *testing.T) {
+	secrets := mapBackend{
+		"pointer": "secretinit:aws:sm:real",
+		"real":    "s3cret",
+		"double":  "secretinit:aws:sm:pointer",
+		"loop":    "secretinit:aws:sm:loop",
+		"plain":   "value",
+	}
+	secretVars := map[string]string{"DB_PASS": "aws:sm:double", "PLAIN": "aws:sm:plain"}
+
+	tests := []struct {
+		name     string
+		mode     NestedMode
+		vars     map[string]string
+		expected map[string]string
+		errorMsg string
+	}{
+		{"warn leaves values untouched", NestedWarn, secretVars, map[string]string{"DB_PASS": "secretinit:aws:sm:pointer", "PLAIN": "value"}, ""},
+		{"resolve follows every level", NestedResolve, secretVars, map[string]string{"DB_PASS": "s3cret", "PLAIN": "value"}, ""},
+		{"error rejects nesting", NestedError, secretVars, nil, "secret for variable 'DB_PASS' is itself a secretinit: address"},
+		{"resolve stops loops", NestedResolve, map[string]string{"LOOP": "aws:sm:loop"}, nil, "after 5 levels of nesting"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.ClearGlobalCache()
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", secrets)
+			proc.SetNestedMode(tt.mode)
+
+			resolved, err := proc.ProcessSecrets(tt.vars)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("ProcessSecrets() error = %v, want %q", err, tt.errorMsg)
+				}
+				if ErrorKindOf(err) != ErrorKindParse {
+					t.Errorf("expected a parse error kind, got %v", ErrorKindOf(err))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessSecrets() error = %v", err)
+			}
+			if len(resolved) != len(tt.expected) {
+				t.Fatalf("ProcessSecrets() = %v, want %v", resolved, tt.expected)
+			}
+			for name, want := range tt.expected {
+				if resolved[name] != want {
+					t.Errorf("%s = %q, want %q", name, resolved[name], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package processor
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrorMode is what the processor does with a variable that fails to resolve
+type ErrorMode int
+
+const (
+	// ErrorModeFail stops at the first variable that fails (the default)
+	ErrorModeFail ErrorMode = iota
+	// ErrorModeRemove logs a warning and leaves the variable out of the result (--continue-on-error)
+	ErrorModeRemove
+	// ErrorModeKeep logs a warning and passes the variable on with its secretinit: address
+	// (--continue-on-error --keep-failed)
+	ErrorModeKeep
+)
+
+// SetErrorMode sets what happens to variables that fail to resolve. With ErrorModeRemove or
+// ErrorModeKeep, ProcessSecrets only fails when every variable failed, or when the context is done.
+func (p *SecretProcessor) SetErrorMode(mode ErrorMode) {
+	p.errorMode = mode
+}
+
+// FailedVariables returns the variables the last ProcessSecrets call skipped with ErrorModeRemove
+// or ErrorModeKeep, sorted, so they can be retried later (--retry-failed)
+func (p *SecretProcessor) FailedVariables() []string {
+	return p.failed
+}
+
+// keepFailed puts the secretinit: address of failed variables back into resolved for ErrorModeKeep
+func (p *SecretProcessor) keepFailed(resolved, secretVars map[string]string, failed map[string]error) {
+	if p.errorMode != ErrorModeKeep {
+		return
+	}
+	for varName := range failed {
+		if address, exists := secretVars[varName]; exists {
+			resolved[varName] = "secretinit:" + address
+		}
+	}
+}
+
+// withoutVariables returns a copy of secretVars without the failed variables
+func withoutVariables(secretVars map[string]string, failed map[string]error) map[string]string {
+	if len(failed) == 0 {
+		return secretVars
+	}
+	remaining := make(map[string]string, len(secretVars))
+	for varName, address := range secretVars {
+		if _, hasFailed := failed[varName]; !hasFailed {
+			remaining[varName] = address
+		}
+	}
+	return remaining
+}
+
+// combinedError joins the errors of the failed variables in name order
+func combinedError(failed map[string]error) error {
+	varNames := sortedNames(failed)
+	errs := make([]error, 0, len(varNames))
+	for _, varName := range varNames {
+		errs = append(errs, failed[varName])
+	}
+	return errors.Join(errs...)
+}
+
+// sortedNames returns the variable names of failed in name order
+func sortedNames(failed map[string]error) []string {
+	varNames := make([]string, 0, len(failed))
+	for varName := range failed {
+		varNames = append(varNames, varName)
+	}
+	sort.Strings(varNames)
+	return varNames
+}
@@ -0,0 +1,125 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProcessSecrets_ContinueOnError(t *testing.T) {
+	secrets := mapBackend{"db": "s3cret", "pointer": "secretinit:aws:sm:gone"}
+	secretVars := map[string]string{
+		"DB_PASS":  "aws:sm:db",
+		"OPTIONAL": "aws:sm:missing",
+		"BAD":      "aws:xx:db",
+		"INDIRECT": "aws:sm:pointer",
+	}
+
+	tests := []struct {
+		name     string
+		mode     ErrorMode
+		expected map[string]string
+	}{
+		{"remove", ErrorModeRemove, map[string]string{"DB_PASS": "s3cret"}},
+		{"keep", ErrorModeKeep, map[string]string{
+			"DB_PASS":  "s3cret",
+			"OPTIONAL": "secretinit:aws:sm:missing",
+			"BAD":      "secretinit:aws:xx:db",
+			"INDIRECT": "secretinit:aws:sm:pointer",
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", secrets)
+			proc.SetNestedMode(NestedResolve)
+			proc.SetErrorMode(tt.mode)
+
+			result, err := proc.ProcessSecrets(secretVars)
+			if err != nil {
+				t.Fatalf("ProcessSecrets() error = %v", err)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("ProcessSecrets() = %v, want %v", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestProcessSecrets_ContinueOnErrorAllFailed(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", mapBackend{})
+	proc.SetErrorMode(ErrorModeRemove)
+
+	_, err := proc.ProcessSecrets(map[string]string{"A": "aws:sm:one", "B": "aws:sm:two"})
+	if err == nil {
+		t.Fatal("expected an error when every variable failed")
+	}
+	for _, varName := range []string{"'A'", "'B'"} {
+		if !strings.Contains(err.Error(), varName) {
+			t.Errorf("expected the combined error to name %s, got %v", varName, err)
+		}
+	}
+	if kind := ErrorKindOf(err); kind != ErrorKindRetrieval {
+		t.Errorf("ErrorKindOf() = %v, want %v", kind, ErrorKindRetrieval)
+	}
+}
+
+func TestProcessSecrets_ContinueOnErrorStopsWhenCancelled(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", mapBackend{"db": "s3cret"})
+	proc.SetErrorMode(ErrorModeRemove)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := proc.ProcessSecretsCtx(ctx, map[string]string{"A": "aws:sm:missing", "B": "aws:sm:db"})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected the cancellation to stop resolution, got %v", err)
+	}
+}
+
+func TestProcessSecrets_ContinueOnErrorRequiredKeys(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", mapBackend{"db": `{"password": "s3cret"}`})
+	proc.SetErrorMode(ErrorModeRemove)
+	proc.SetRequiredKeys(map[string][]string{"DB": {"password"}, "OPTIONAL": {"token"}})
+
+	result, err := proc.ProcessSecrets(map[string]string{"DB": "aws:sm:db", "OPTIONAL": "aws:sm:missing"})
+	if err != nil {
+		t.Fatalf("expected the skipped variable's keys not to be checked, got %v", err)
+	}
+	if _, exists := result["OPTIONAL"]; exists {
+		t.Errorf("expected OPTIONAL to be removed, got %v", result)
+	}
+}
+
+func TestFailedVariables_RetryAfterBackendRecovers(t *testing.T) {
+	secrets := mapBackend{"db": "s3cret"}
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", secrets)
+	proc.SetErrorMode(ErrorModeRemove)
+	secretVars := map[string]string{"DB_PASS": "aws:sm:db", "API_KEY": "aws:sm:api", "TOKEN": "aws:sm:token"}
+
+	if _, err := proc.ProcessSecrets(secretVars); err != nil {
+		t.Fatalf("ProcessSecrets() error = %v", err)
+	}
+	if got := proc.FailedVariables(); !reflect.DeepEqual(got, []string{"API_KEY", "TOKEN"}) {
+		t.Fatalf("FailedVariables() = %v, want [API_KEY TOKEN]", got)
+	}
+
+	// The backend comes back for one of the secrets
+	secrets["api"] = "key"
+	result, err := proc.ProcessSecrets(secretVars)
+	if err != nil {
+		t.Fatalf("ProcessSecrets() retry error = %v", err)
+	}
+	if result["API_KEY"] != "key" {
+		t.Errorf("expected API_KEY to resolve on retry, got %v", result)
+	}
+	if got := proc.FailedVariables(); !reflect.DeepEqual(got, []string{"TOKEN"}) {
+		t.Errorf("FailedVariables() after retry = %v, want [TOKEN]", got)
+	}
+}
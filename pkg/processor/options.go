@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// applyOptions applies the "?name=value" options of a secret address to a retrieved value.
+// Options are applied in a fixed order so results don't depend on how they were written:
+// decrypt, then join, then wrap. lookupVar resolves variables named by options (e.g. decryption keys).
+func applyOptions(value string, secretSource parser.SecretSource, lookupVar func(string) (string, bool)) (string, error) {
+	if err := checkOptionNames(secretSource.Options); err != nil {
+		return "", err
+	}
+
+	if spec, ok := secretSource.Options["decrypt"]; ok {
+		decrypted, err := decryptValue(value, spec, lookupVar)
+		if err != nil {
+			return "", err
+		}
+		value = decrypted
+	}
+
+	if delimiter, ok := secretSource.Options["join"]; ok {
+		joined, err := joinJSONArray(value, unescapeOptionValue(delimiter))
+		if err != nil {
+			return "", err
+		}
+		value = joined
+	}
+
+	if wrap, ok := secretSource.Options["wrap"]; ok {
+		wrapped, err := wrapValue(value, unescapeOptionValue(wrap))
+		if err != nil {
+			return "", err
+		}
+		value = wrapped
+	}
+
+	return value, nil
+}
+
+// checkOptions returns an error for the first address option applyOptions could never apply:
+// an unsupported name, or a decrypt or wrap spec with the wrong syntax. Errors applyOptions
+// returns beyond these come from the retrieved value, such as a wrong key or a value that is no JSON array.
+func checkOptions(options map[string]string) error {
+	if err := checkOptionNames(options); err != nil {
+		return err
+	}
+	if spec, ok := options["decrypt"]; ok {
+		if err := checkDecryptSpec(spec); err != nil {
+			return err
+		}
+	}
+	if wrap, ok := options["wrap"]; ok {
+		if _, err := wrapValue("", unescapeOptionValue(wrap)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOptionNames returns an error for the first unsupported address option
+func checkOptionNames(options map[string]string) error {
+	for name := range options {
+		switch name {
+		case "decrypt", "join", "wrap":
+		case "client_cert", "client_key":
+			// Consumed when selecting the backend, see withClientCertificate
+		case "retries", "backoff":
+			// Consumed when retrieving the value, see retryPolicyFor
+		default:
+			return fmt.Errorf("unsupported option '%s'", name)
+		}
+	}
+	return nil
+}
+
+// checkMultiCredentialOptions returns an error for the address features that transform or
+// replace a single value, which a git address expanding into several variables can't apply
+func checkMultiCredentialOptions(secretSource parser.SecretSource) error {
+	if err := checkOptionNames(secretSource.Options); err != nil {
+		return err
+	}
+	for _, name := range []string{"decrypt", "join", "wrap"} {
+		if _, ok := secretSource.Options[name]; ok {
+			return fmt.Errorf("option '%s' applies to a single value: add :::username or :::password to the git address", name)
+		}
+	}
+	if secretSource.HasDefault {
+		return fmt.Errorf("a '||default' applies to a single value: add :::username or :::password to the git address")
+	}
+	return nil
+}
+
+// joinJSONArray joins a JSON array of scalar values into a delimited string
+func joinJSONArray(value, delimiter string) (string, error) {
+	var items []interface{}
+	if err := json.Unmarshal([]byte(value), &items); err != nil {
+		return "", fmt.Errorf("join option requires a JSON array value: %w", err)
+	}
+
+	parts := make([]string, 0, len(items))
+	for i, item := range items {
+		switch v := item.(type) {
+		case string:
+			parts = append(parts, v)
+		case float64, bool:
+			jsonBytes, _ := json.Marshal(v)
+			parts = append(parts, string(jsonBytes))
+		case nil:
+			return "", fmt.Errorf("join option cannot join null array element at index %d", i)
+		default:
+			return "", fmt.Errorf("join option can only join scalar values, array element at index %d is %T", i, v)
+		}
+	}
+
+	return strings.Join(parts, delimiter), nil
+}
+
+// wrapValue adds a prefix or suffix to a value. The wrap spec is "prefix:TEXT" or "suffix:TEXT".
+func wrapValue(value, spec string) (string, error) {
+	mode, text, found := strings.Cut(spec, ":")
+	if !found {
+		return "", fmt.Errorf("invalid wrap option '%s': expected 'prefix:TEXT' or 'suffix:TEXT'", spec)
+	}
+
+	switch mode {
+	case "prefix":
+		return text + value, nil
+	case "suffix":
+		return value + text, nil
+	default:
+		return "", fmt.Errorf("invalid wrap mode '%s': expected 'prefix' or 'suffix'", mode)
+	}
+}
+
+// unescapeOptionValue converts escape sequences like "\n" and "\t" into their characters
+func unescapeOptionValue(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\r`, "\r", `\\`, `\`).Replace(value)
+}
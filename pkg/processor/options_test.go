@@ -0,0 +1,116 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProcessSecrets_JoinOption(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		secretValue string
+		expected    string
+		errorMsg    string
+	}{
+		{
+			name:        "join with semicolon",
+			address:     "aws:sm:cfg:::hosts?join=;",
+			secretValue: `{"hosts":["a","b","c"]}`,
+			expected:    "a;b;c",
+		},
+		{
+			name:        "join with comma",
+			address:     "aws:sm:cfg:::hosts?join=,",
+			secretValue: `{"hosts":["a","b","c"]}`,
+			expected:    "a,b,c",
+		},
+		{
+			name:        "join with newline",
+			address:     `aws:sm:cfg:::hosts?join=\n`,
+			secretValue: `{"hosts":["a","b","c"]}`,
+			expected:    "a\nb\nc",
+		},
+		{
+			name:        "join with multi-character delimiter and scalars",
+			address:     "aws:sm:cfg:::ports?join=, ",
+			secretValue: `{"ports":[80,443,true]}`,
+			expected:    "80, 443, true",
+		},
+		{
+			name:        "join whole raw array without keyPath",
+			address:     "aws:sm:cfg:::?join=|",
+			secretValue: `["x","y"]`,
+			expected:    "x|y",
+		},
+		{
+			name:        "join array of objects fails",
+			address:     "aws:sm:cfg:::servers?join=,",
+			secretValue: `{"servers":[{"host":"a"},{"host":"b"}]}`,
+			errorMsg:    "can only join scalar values",
+		},
+		{
+			name:        "join non-array fails",
+			address:     "aws:sm:cfg:::host?join=,",
+			secretValue: `{"host":"a"}`,
+			errorMsg:    "requires a JSON array",
+		},
+		{
+			name:        "wrap with prefix",
+			address:     "aws:sm:token:::value?wrap=prefix:Bearer ",
+			secretValue: `{"value":"abc123"}`,
+			expected:    "Bearer abc123",
+		},
+		{
+			name:        "wrap with suffix",
+			address:     "aws:sm:cfg:::host?wrap=suffix::5432",
+			secretValue: `{"host":"db.internal"}`,
+			expected:    "db.internal:5432",
+		},
+		{
+			name:        "join combined with wrap",
+			address:     "aws:sm:cfg:::hosts?join=,&wrap=prefix:hosts=",
+			secretValue: `{"hosts":["a","b"]}`,
+			expected:    "hosts=a,b",
+		},
+		{
+			name:        "wrap with invalid mode fails",
+			address:     "aws:sm:cfg:::host?wrap=around:x",
+			secretValue: `{"host":"a"}`,
+			errorMsg:    "invalid wrap mode 'around'",
+		},
+		{
+			name:        "wrap without mode fails",
+			address:     "aws:sm:cfg:::host?wrap=x",
+			secretValue: `{"host":"a"}`,
+			errorMsg:    "invalid wrap option",
+		},
+		{
+			name:        "unknown option fails",
+			address:     "aws:sm:cfg:::host?bogus=1",
+			secretValue: `{"host":"a"}`,
+			errorMsg:    "unsupported option 'bogus'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", &MockAWSBackend{secretValue: tt.secretValue})
+
+			result, err := proc.ProcessSecrets(map[string]string{"VALUE": tt.address})
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Fatalf("Expected error containing '%s', got %v", tt.errorMsg, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result["VALUE"] != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result["VALUE"])
+			}
+		})
+	}
+}
@@ -1,107 +1,306 @@
 package processor
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"sync"
 
 	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/logging"
 	"github.com/liifi/secretinit/pkg/parser"
 )
 
 // SecretProcessor handles the processing of secret environment variables
 type SecretProcessor struct {
-	backends map[string]backend.Backend
+	backendsMutex sync.Mutex
+	backends      map[string]backend.Backend
+	factories     map[string]func() (backend.Backend, error) // Backends constructed on first use
+	defaults      map[string]string                          // Fallback values used when a secret fails to resolve
+	suffixes      Suffixes                                   // Variable name suffixes for git multi-credential expansion
+	requiredKeys  map[string][]string                        // JSON keys a variable's resolved value must contain (--require-keys)
+	nestedMode    NestedMode                                 // What to do with resolved values that are secretinit: addresses
+	errorMode     ErrorMode                                  // What to do with a variable that fails to resolve (--continue-on-error)
+	failed        []string                                   // Variables the last ProcessSecrets call skipped, sorted (see FailedVariables)
 }
 
 // NewSecretProcessor creates a new SecretProcessor with the given backends
 func NewSecretProcessor() *SecretProcessor {
 	return &SecretProcessor{
-		backends: make(map[string]backend.Backend),
+		backends:  make(map[string]backend.Backend),
+		factories: make(map[string]func() (backend.Backend, error)),
+		suffixes:  DefaultSuffixes,
 	}
 }
 
 // RegisterBackend registers a backend for a specific backend type
 func (p *SecretProcessor) RegisterBackend(backendType string, b backend.Backend) {
+	p.backendsMutex.Lock()
+	defer p.backendsMutex.Unlock()
 	p.backends[backendType] = b
 }
 
+// RegisterBackendFactory registers a factory for a backend type. The factory is only called when
+// the first secret for that backend is resolved, and the instance is reused afterwards.
+func (p *SecretProcessor) RegisterBackendFactory(backendType string, factory func() (backend.Backend, error)) {
+	p.backendsMutex.Lock()
+	defer p.backendsMutex.Unlock()
+	p.factories[backendType] = factory
+}
+
+// getBackend returns the backend for backendType, constructing it from its factory on first use.
+// A failed construction is not cached, so a later secret retries it.
+func (p *SecretProcessor) getBackend(backendType string) (backend.Backend, bool, error) {
+	p.backendsMutex.Lock()
+	defer p.backendsMutex.Unlock()
+
+	if b, exists := p.backends[backendType]; exists {
+		return b, true, nil
+	}
+	factory, exists := p.factories[backendType]
+	if !exists {
+		return nil, false, nil
+	}
+	b, err := factory()
+	if err != nil {
+		return nil, true, err
+	}
+	p.backends[backendType] = b
+	return b, true, nil
+}
+
+// SetDefaults sets fallback values (keyed by variable name) used when a secret fails to resolve.
+// This is typically loaded from a secretinit.defaults.env file for local development.
+func (p *SecretProcessor) SetDefaults(defaults map[string]string) {
+	p.defaults = defaults
+}
+
+// SetSuffixes sets the variable name suffixes used by git multi-credential expansion
+func (p *SecretProcessor) SetSuffixes(suffixes Suffixes) {
+	p.suffixes = suffixes
+}
+
 // ClearCache clears all caches for all registered backends
 func (p *SecretProcessor) ClearCache() {
 	backend.ClearGlobalCache()
 }
 
-// GetCacheStats returns cache statistics for all backends
+// GetCacheStats returns the number of cached entries for each backend used so far.
+// Backends prefix their cache keys with their name, so entries are counted by "<backend>:".
 func (p *SecretProcessor) GetCacheStats() map[string]int {
 	stats := make(map[string]int)
-	// Since we're using a global cache, return total cache size for all backends
-	totalSize := backend.GetGlobalCacheSize()
+	p.backendsMutex.Lock()
+	defer p.backendsMutex.Unlock()
 	for backendType := range p.backends {
-		// We can't easily separate cache sizes by backend type with global cache
-		// So we'll show total for each backend type
-		stats[backendType] = totalSize
+		stats[backendType] = backend.GetGlobalCacheSizeByPrefix(backendType + ":")
 	}
 	return stats
 }
 
 // ProcessSecrets processes a map of secret environment variables and returns resolved values
 func (p *SecretProcessor) ProcessSecrets(secretVars map[string]string) (map[string]string, error) {
+	return p.ProcessSecretsCtx(context.Background(), secretVars)
+}
+
+// ProcessSecretsCtx is ProcessSecrets bounded by ctx: once ctx is done, the pending backend call
+// is abandoned and an error wrapping ctx.Err() is returned instead of falling back to defaults
+func (p *SecretProcessor) ProcessSecretsCtx(ctx context.Context, secretVars map[string]string) (map[string]string, error) {
+	resolvedSecrets, failed, err := p.resolveSecrets(ctx, secretVars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values that are themselves secretinit: addresses are handled according to the nested mode
+	failedNested, err := p.handleNested(ctx, resolvedSecrets)
+	if err != nil {
+		return nil, err
+	}
+	maps.Copy(failed, failedNested)
+	p.failed = sortedNames(failed)
+
+	// Tolerated failures only stop the launch when nothing resolved at all
+	if len(failed) > 0 && len(failed) == len(secretVars) {
+		return nil, combinedError(failed)
+	}
+
+	// Variables skipped by --continue-on-error have no value to check
+	if err := checkRequiredKeys(resolvedSecrets, withoutVariables(secretVars, failed), p.requiredKeys); err != nil {
+		return nil, err
+	}
+
+	p.keepFailed(resolvedSecrets, secretVars, failed)
+	return resolvedSecrets, nil
+}
+
+// resolveSecrets resolves each address of secretVars once, without looking at the resolved values.
+// Unless the error mode is ErrorModeFail, variables that fail are left out and returned with their error.
+func (p *SecretProcessor) resolveSecrets(ctx context.Context, secretVars map[string]string) (map[string]string, map[string]error, error) {
 	resolvedSecrets := make(map[string]string)
 
-	for varName, secretAddress := range secretVars {
-		// Parse the secret address using the parser package
-		secretSource, err := parser.ParseSecretString(secretAddress)
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse secret address for variable '%s': %w", varName, err)
+	// Resolve variables after the secrets they depend on (e.g. ?client_cert=OTHER_VAR)
+	order, err := resolutionOrder(secretVars)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	failed := make(map[string]error)
+	for _, varName := range order {
+		err := p.resolveVariable(ctx, varName, secretVars[varName], resolvedSecrets)
+		if err == nil {
+			continue
+		}
+		// Out of time or interrupted: stop even with --continue-on-error
+		if p.errorMode == ErrorModeFail || ctx.Err() != nil {
+			return nil, nil, err
 		}
+		logging.Printf(logging.LevelWarn, "Warning: skipping variable '%s' (--continue-on-error): %v", varName, err)
+		failed[varName] = err
+	}
+	return resolvedSecrets, failed, nil
+}
+
+// resolveVariable resolves the address of one variable into resolvedSecrets, which already holds
+// the variables resolved before it
+func (p *SecretProcessor) resolveVariable(ctx context.Context, varName, secretAddress string, resolvedSecrets map[string]string) error {
+	// Parse the secret address using the parser package
+	secretSource, err := parser.ParseSecretString(secretAddress)
+	if err != nil {
+		return &SecretError{Kind: ErrorKindParse, Variable: varName, Err: fmt.Errorf("failed to parse secret address for variable '%s': %w", varName, err)}
+	}
 
-		// Check if we have a backend registered for this backend type
-		backend, exists := p.backends[secretSource.Backend]
-		if !exists {
-			return nil, fmt.Errorf("unsupported backend '%s' for variable '%s'", secretSource.Backend, varName)
+	// Validate service field for specific backends
+	if secretSource.Backend == "aws" && !backend.IsAWSService(secretSource.Service) {
+		return &SecretError{Kind: ErrorKindParse, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("unsupported AWS service '%s' for variable '%s'%s. Supported services: %s", secretSource.Service, varName, parser.DidYouMean(secretSource.Service, backend.AWSServiceNames), backend.AWSServices)}
+	}
+
+	// Retry flaky retrievals as configured by ?retries=N&backoff=DURATION
+	policy, err := retryPolicyFor(secretSource.Options)
+	if err != nil {
+		return &SecretError{Kind: ErrorKindParse, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("invalid options for variable '%s': %w", varName, err)}
+	}
+
+	// Check if we have a backend registered for this backend type
+	backend, exists, err := p.getBackend(secretSource.Backend)
+	if !exists {
+		return &SecretError{Kind: ErrorKindBackendUnavailable, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("unsupported backend '%s' for variable '%s'", secretSource.Backend, varName)}
+	}
+	if err != nil {
+		return &SecretError{Kind: ErrorKindBackendUnavailable, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to initialize %s backend: %v", secretSource.Backend, err)}
+	}
+
+	// Use a TLS client certificate resolved from another variable for this backend's calls
+	backend, kind, err := withClientCertificate(backend, secretSource, resolvedSecrets)
+	if err != nil {
+		return &SecretError{Kind: kind, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to configure client certificate for variable '%s': %w", varName, err)}
+	}
+
+	// Handle git backend multi-credential expansion when no keyPath is specified
+	if secretSource.Backend == "git" && secretSource.KeyPath == "" {
+		// Multi-credential mode: create _URL, _USER, _PASS, _HOST and _PROTOCOL variables (see SetSuffixes)
+		// Don't keep the original variable with secretinit: prefix
+		if err := checkMultiCredentialOptions(secretSource); err != nil {
+			return &SecretError{Kind: ErrorKindParse, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("invalid options for variable '%s': %w", varName, err)}
+		}
+		if _, hasDefault := p.defaults[varName]; hasDefault {
+			return &SecretError{Kind: ErrorKindParse, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("defaults file entry for variable '%s' can't replace its git credential: add :::username or :::password to the git address", varName)}
 		}
 
-		// Validate service field for specific backends
-		if secretSource.Backend == "aws" && secretSource.Service != "sm" && secretSource.Service != "ps" {
-			return nil, fmt.Errorf("unsupported AWS service '%s' for variable '%s'. Supported services: 'sm' (Secrets Manager), 'ps' (Parameter Store)", secretSource.Service, varName)
+		// Retrieve both username and password
+		username, err := retrieveWithRetry(ctx, backend, policy, secretSource.Service, secretSource.Resource, "username")
+		if err != nil {
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to retrieve username for variable '%s' (%s): %w", varName, parser.RedactAddress(secretAddress), err)}
 		}
 
-		// Handle git backend multi-credential expansion when no keyPath is specified
-		if secretSource.Backend == "git" && secretSource.KeyPath == "" {
-			// Multi-credential mode: create _URL, _USER, _PASS variables
-			// Don't keep the original variable with secretinit: prefix
+		password, err := retrieveWithRetry(ctx, backend, policy, secretSource.Service, secretSource.Resource, "password")
+		if err != nil {
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to retrieve password for variable '%s' (%s): %w", varName, parser.RedactAddress(secretAddress), err)}
+		}
 
-			// Retrieve both username and password
-			username, err := backend.RetrieveSecret(secretSource.Service, secretSource.Resource, "username")
-			if err != nil {
-				return nil, fmt.Errorf("failed to retrieve username for variable '%s' (%s): %w", varName, secretAddress, err)
-			}
+		// Create the additional environment variables
+		// *_URL gets the clean parsed URL (without username)
+		if p.suffixes.URL != "" {
+			cleanURL, _ := parser.ParseGitURL(secretSource.Resource)
+			resolvedSecrets[varName+p.suffixes.URL] = cleanURL
+		}
+		resolvedSecrets[varName+p.suffixes.User] = username
+		resolvedSecrets[varName+p.suffixes.Pass] = password
 
-			password, err := backend.RetrieveSecret(secretSource.Service, secretSource.Resource, "password")
-			if err != nil {
-				return nil, fmt.Errorf("failed to retrieve password for variable '%s' (%s): %w", varName, secretAddress, err)
+		// *_HOST and *_PROTOCOL come from the credential response's host= and protocol= lines,
+		// falling back to the address for helpers that leave them out
+		if p.suffixes.Host != "" || p.suffixes.Protocol != "" {
+			protocol, host := gitURLParts(secretSource.Resource)
+			if value, err := retrieveSecret(ctx, backend, secretSource.Service, secretSource.Resource, "host"); err == nil && value != "" {
+				host = value
 			}
-
-			// Create the additional environment variables
-			// *_URL gets the clean parsed URL (without username)
-			cleanURL, _ := parser.ParseGitURL(secretSource.Resource)
-			resolvedSecrets[varName+"_URL"] = cleanURL
-			resolvedSecrets[varName+"_USER"] = username
-			resolvedSecrets[varName+"_PASS"] = password
-		} else {
-			// Single credential mode (existing logic)
-			keyPath := secretSource.KeyPath
-			if secretSource.Backend == "git" && keyPath == "" {
-				keyPath = "password"
+			if value, err := retrieveSecret(ctx, backend, secretSource.Service, secretSource.Resource, "protocol"); err == nil && value != "" {
+				protocol = value
+			}
+			if p.suffixes.Host != "" {
+				resolvedSecrets[varName+p.suffixes.Host] = host
 			}
+			if p.suffixes.Protocol != "" {
+				resolvedSecrets[varName+p.suffixes.Protocol] = protocol
+			}
+		}
+	} else {
+		// Single credential mode (existing logic)
+		keyPath := secretSource.KeyPath
+		if secretSource.Backend == "git" && keyPath == "" {
+			keyPath = "password"
+		}
+		if err := checkOptions(secretSource.Options); err != nil {
+			return &SecretError{Kind: ErrorKindParse, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("invalid options for variable '%s': %w", varName, err)}
+		}
 
-			// Retrieve the secret value from the backend
-			secretValue, err := backend.RetrieveSecret(secretSource.Service, secretSource.Resource, keyPath)
-			if err != nil {
-				return nil, fmt.Errorf("failed to retrieve secret for variable '%s' (%s): %w", varName, secretAddress, err)
+		// Retrieve the secret value from the backend
+		secretValue, err := retrieveWithRetry(ctx, backend, policy, secretSource.Service, secretSource.Resource, keyPath)
+		if err != nil && ctx.Err() != nil {
+			// Out of time: don't mask the deadline with a default
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("gave up retrieving secret for variable '%s' (%s): %w", varName, parser.RedactAddress(secretAddress), ctx.Err())}
+		}
+		if err != nil && secretSource.HasDefault && isNotFound(err) {
+			// Use the address's own "||default" for a secret (or key) that doesn't exist
+			resolvedSecrets[varName] = secretSource.Default
+			return nil
+		}
+		if err != nil {
+			// Fall back to the defaults file entry for this variable if there is one
+			defaultValue, hasDefault := p.defaults[varName]
+			if !hasDefault {
+				return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to retrieve secret for variable '%s' (%s): %w", varName, parser.RedactAddress(secretAddress), err)}
 			}
+			// Say so, without the value, so running on a fallback doesn't go unnoticed
+			logging.Printf(logging.LevelWarn, "Warning: using the defaults file value for variable '%s', its secret could not be retrieved (%s): %v", varName, parser.RedactAddress(secretAddress), err)
+			resolvedSecrets[varName] = defaultValue
+			return nil
+		}
 
-			resolvedSecrets[varName] = secretValue
+		// Apply address options (e.g. ?join=,) to the retrieved value. Their syntax was checked above,
+		// so a failure here (wrong key, corrupt ciphertext, no JSON array) is about the value
+		secretValue, err = applyOptions(secretValue, secretSource, func(name string) (string, bool) {
+			// Variables resolved earlier in this run win over the process environment
+			if value, ok := resolvedSecrets[name]; ok {
+				return value, true
+			}
+			return os.LookupEnv(name)
+		})
+		if err != nil {
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("failed to apply options for variable '%s' (%s): %w", varName, parser.RedactAddress(secretAddress), err)}
 		}
+
+		resolvedSecrets[varName] = secretValue
 	}
+	return nil
+}
 
-	return resolvedSecrets, nil
+// gitURLParts returns the protocol and host (with port) of a git address, as git credential would
+func gitURLParts(resource string) (protocol, host string) {
+	cleanURL, _ := parser.ParseGitURL(resource)
+	u, err := url.Parse(cleanURL)
+	if err != nil {
+		return "", ""
+	}
+	return u.Scheme, u.Host
 }
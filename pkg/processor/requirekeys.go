@@ -0,0 +1,71 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// ParseRequiredKeys parses a --require-keys value "VAR=key1,key2" into the variable and its keys.
+// Keys use the same paths as a ":::keyPath" address, so nested keys like "db.password" work too.
+func ParseRequiredKeys(spec string) (string, []string, error) {
+	varName, list, found := strings.Cut(spec, "=")
+	varName = strings.TrimSpace(varName)
+	if !found || varName == "" {
+		return "", nil, fmt.Errorf("invalid --require-keys value '%s': expected VAR=key1,key2", spec)
+	}
+
+	var keys []string
+	for _, key := range strings.Split(list, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return "", nil, fmt.Errorf("invalid --require-keys value '%s': no keys given for %s", spec, varName)
+	}
+	return varName, keys, nil
+}
+
+// SetRequiredKeys sets the JSON keys (keyed by variable name) that a variable's resolved value
+// must contain. ProcessSecrets fails with the missing keys instead of handing the command a
+// secret whose shape is wrong; variables that are not being resolved are not checked.
+func (p *SecretProcessor) SetRequiredKeys(required map[string][]string) {
+	p.requiredKeys = required
+}
+
+// checkRequiredKeys checks the resolved value of each variable in required that is part of secretVars
+func checkRequiredKeys(resolved, secretVars map[string]string, required map[string][]string) error {
+	varNames := make([]string, 0, len(required))
+	for varName := range required {
+		if _, resolving := secretVars[varName]; resolving {
+			varNames = append(varNames, varName)
+		}
+	}
+	sort.Strings(varNames)
+
+	for _, varName := range varNames {
+		secretSource, _ := parser.ParseSecretString(secretVars[varName])
+		value, ok := resolved[varName]
+		if !ok {
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("--require-keys: variable '%s' was not resolved to a single value (git multi-credential addresses expand to several variables)", varName)}
+		}
+
+		var missing []string
+		for _, key := range required[varName] {
+			if _, err := backend.ExtractJSONKey(value, key); err != nil {
+				if !isNotFound(err) {
+					return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("secret for variable '%s' (%s) does not have the required JSON keys %s: %w", varName, parser.RedactAddress(secretVars[varName]), strings.Join(required[varName], ", "), err)}
+				}
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			return &SecretError{Kind: ErrorKindRetrieval, Variable: varName, Backend: secretSource.Backend, Err: fmt.Errorf("secret for variable '%s' (%s) is missing required JSON keys: %s", varName, parser.RedactAddress(secretVars[varName]), strings.Join(missing, ", "))}
+		}
+	}
+	return nil
+}
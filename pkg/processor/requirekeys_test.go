@@ -0,0 +1,55 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+func TestParseRequiredKeys(t *testing.T) {
+	varName, keys, err := ParseRequiredKeys("DB_CREDS=username, password,")
+	if err != nil || varName != "DB_CREDS" || strings.Join(keys, "|") != "username|password" {
+		t.Errorf("ParseRequiredKeys() = %q, %q, %v", varName, keys, err)
+	}
+	for _, spec := range []string{"DB_CREDS", "=username", "DB_CREDS=", "DB_CREDS= , "} {
+		if _, _, err := ParseRequiredKeys(spec); err == nil {
+			t.Errorf("ParseRequiredKeys(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestProcessSecrets_RequiredKeys(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		required []string
+		errorMsg string
+	}{
+		{"all keys present", `{"username":"app","password":"s3cret","db":{"host":"db"}}`, []string{"username", "password", "db.host"}, ""},
+		{"missing keys", `{"usernme":"alice"}`, []string{"username", "password"}, "is missing required JSON keys: username, password"},
+		{"not JSON", "plain-text", []string{"username"}, "does not have the required JSON keys username"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend.ClearGlobalCache()
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", &MockAWSBackend{secretValue: tt.value})
+			proc.SetRequiredKeys(map[string][]string{"DB_CREDS": tt.required, "NOT_RESOLVED": {"x"}})
+
+			secrets, err := proc.ProcessSecrets(map[string]string{"DB_CREDS": "aws:sm:myapp/db"})
+			if tt.errorMsg == "" {
+				if err != nil || secrets["DB_CREDS"] != tt.value {
+					t.Fatalf("ProcessSecrets() = %v, %v", secrets, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Fatalf("ProcessSecrets() error = %v, want %q", err, tt.errorMsg)
+			}
+			if !strings.Contains(err.Error(), "'DB_CREDS'") || strings.Contains(err.Error(), "alice") || strings.Contains(err.Error(), tt.value) {
+				t.Errorf("error should name the variable without exposing values: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// defaultBackoff is the delay before the first retry when ?retries is given without ?backoff
+const defaultBackoff = 200 * time.Millisecond
+
+// maxRetries bounds ?retries so a typo can't make a run retry for hours
+const maxRetries = 20
+
+// retryPolicy is how often a secret's retrieval is retried, from its ?retries and ?backoff options
+type retryPolicy struct {
+	retries int           // Additional attempts after the first one
+	backoff time.Duration // Delay before the first retry, doubled for each further retry
+}
+
+// retryPolicyFor parses the ?retries=N&backoff=DURATION options of a secret address.
+// Without ?retries a secret is only tried once.
+func retryPolicyFor(options map[string]string) (retryPolicy, error) {
+	policy := retryPolicy{backoff: defaultBackoff}
+
+	if raw, ok := options["retries"]; ok {
+		retries, err := strconv.Atoi(raw)
+		if err != nil || retries < 0 || retries > maxRetries {
+			return retryPolicy{}, fmt.Errorf("invalid retries '%s': expected a number from 0 to %d", raw, maxRetries)
+		}
+		policy.retries = retries
+	}
+	if raw, ok := options["backoff"]; ok {
+		backoff, err := time.ParseDuration(raw)
+		if err != nil || backoff < 0 {
+			return retryPolicy{}, fmt.Errorf("invalid backoff '%s': expected a duration like 500ms or 2s", raw)
+		}
+		policy.backoff = backoff
+	}
+	return policy, nil
+}
+
+// retrieveWithRetry retrieves a secret, retrying failures according to policy.
+// Secrets that don't exist are not retried, and waiting stops as soon as ctx is done.
+func retrieveWithRetry(ctx context.Context, b backend.Backend, policy retryPolicy, service, resource, keyPath string) (string, error) {
+	delay := policy.backoff
+	for attempt := 0; ; attempt++ {
+		value, err := retrieveSecret(ctx, b, service, resource, keyPath)
+		if err == nil || attempt >= policy.retries || isNotFound(err) || ctx.Err() != nil {
+			return value, err
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+		delay *= 2
+	}
+}
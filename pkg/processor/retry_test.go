@@ -0,0 +1,100 @@
+package processor
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/liifi/secretinit/pkg/backend"
+)
+
+// flakyBackend fails a number of times before returning its value
+type flakyBackend struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (b *flakyBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	b.calls++
+	if b.calls <= b.failures {
+		return "", b.err
+	}
+	return "value", nil
+}
+
+func TestProcessSecrets_RetryOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		address       string
+		failures      int
+		err           error
+		expectedCalls int
+		wantErr       bool
+	}{
+		{name: "succeeds after configured retries", address: "aws:sm:app/key:::?retries=2&backoff=1ms", failures: 2, err: errors.New("throttled"), expectedCalls: 3},
+		{name: "fails when retries run out", address: "aws:sm:app/key:::?retries=1&backoff=1ms", failures: 2, err: errors.New("throttled"), expectedCalls: 2, wantErr: true},
+		{name: "no retries by default", address: "aws:sm:app/key", failures: 1, err: errors.New("throttled"), expectedCalls: 1, wantErr: true},
+		{name: "missing secrets are not retried", address: "aws:sm:app/key:::?retries=3&backoff=1ms", failures: 1, err: fmt.Errorf("gone: %w", backend.ErrSecretNotFound), expectedCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flaky := &flakyBackend{failures: tt.failures, err: tt.err}
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("aws", flaky)
+
+			result, err := proc.ProcessSecrets(map[string]string{"API_KEY": tt.address})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProcessSecrets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && result["API_KEY"] != "value" {
+				t.Errorf("expected value, got %q", result["API_KEY"])
+			}
+			if flaky.calls != tt.expectedCalls {
+				t.Errorf("expected %d attempts, got %d", tt.expectedCalls, flaky.calls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyFor(t *testing.T) {
+	tests := []struct {
+		options  map[string]string
+		expected retryPolicy
+		wantErr  bool
+	}{
+		{options: nil, expected: retryPolicy{backoff: defaultBackoff}},
+		{options: map[string]string{"retries": "5", "backoff": "500ms"}, expected: retryPolicy{retries: 5, backoff: 500 * time.Millisecond}},
+		{options: map[string]string{"retries": "3"}, expected: retryPolicy{retries: 3, backoff: defaultBackoff}},
+		{options: map[string]string{"retries": "-1"}, wantErr: true},
+		{options: map[string]string{"retries": "many"}, wantErr: true},
+		{options: map[string]string{"retries": "100"}, wantErr: true},
+		{options: map[string]string{"backoff": "soon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := retryPolicyFor(tt.options)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("retryPolicyFor(%v) error = %v, wantErr %v", tt.options, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.expected {
+			t.Errorf("retryPolicyFor(%v) = %+v, want %+v", tt.options, got, tt.expected)
+		}
+	}
+}
+
+func TestProcessSecrets_InvalidRetryOptions(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{secretValue: "value"})
+
+	_, err := proc.ProcessSecrets(map[string]string{"API_KEY": "aws:sm:app/key:::?retries=lots"})
+	if err == nil {
+		t.Fatal("expected an error for invalid retries")
+	}
+	if kind := ErrorKindOf(err); kind != ErrorKindParse {
+		t.Errorf("expected ErrorKindParse, got %v", kind)
+	}
+}
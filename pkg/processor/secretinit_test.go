@@ -91,7 +91,19 @@ func TestSecretProcessor_ProcessSecrets_AWS(t *testing.T) {
 			},
 			expected:    nil,
 			expectError: true,
-			errorMsg:    "unsupported AWS service 'invalid' for variable 'DB_PASSWORD'. Supported services: 'sm' (Secrets Manager), 'ps' (Parameter Store)",
+			errorMsg:    "unsupported AWS service 'invalid' for variable 'DB_PASSWORD'. Supported services: 'sm' (Secrets Manager), 'ps' (Parameter Store), 'kms' (KMS decrypt)",
+		},
+		{
+			name: "AWS - misspelled service",
+			secretVars: map[string]string{
+				"DB_PASSWORD": "aws:smm:myapp/db-creds:::password",
+			},
+			mockBackend: &MockAWSBackend{
+				secretValue: "secret123",
+			},
+			expected:    nil,
+			expectError: true,
+			errorMsg:    "unsupported AWS service 'smm' for variable 'DB_PASSWORD' (did you mean 'sm'?). Supported services: 'sm' (Secrets Manager), 'ps' (Parameter Store), 'kms' (KMS decrypt)",
 		},
 		{
 			name: "AWS Parameter Store - valid service",
@@ -375,3 +387,21 @@ func TestGitMultiCredentialMode_OriginalVariableNotLeftBehind(t *testing.T) {
 		})
 	}
 }
+
+func TestProcessSecrets_ErrorsRedactAddress(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("aws", &MockAWSBackend{err: errors.New("access denied")})
+
+	_, err := proc.ProcessSecrets(map[string]string{
+		"DB_PASSWORD": "aws:sm:arn:aws:secretsmanager:us-east-1:123456789012:secret:myapp/db:::password",
+	})
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if strings.Contains(err.Error(), "123456789012") {
+		t.Errorf("Expected the account ID to be redacted, got: %v", err)
+	}
+	if !strings.Contains(err.Error(), "arn:aws:secretsmanager:us-east-1:****:secret:myapp/db:::password") {
+		t.Errorf("Expected the redacted address in the error, got: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package processor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Suffixes are appended to the variable name when a git address without a keyPath
+// expands into several variables. An empty URL, Host or Protocol suffix skips that variable.
+type Suffixes struct {
+	URL      string
+	User     string
+	Pass     string
+	Host     string
+	Protocol string
+}
+
+// DefaultSuffixes produce the VAR_URL, VAR_USER, VAR_PASS, VAR_HOST and VAR_PROTOCOL variables
+var DefaultSuffixes = Suffixes{URL: "_URL", User: "_USER", Pass: "_PASS", Host: "_HOST", Protocol: "_PROTOCOL"}
+
+// ParseSuffixes parses a "url=_URL,user=_USERNAME,pass=_PASSWORD,host=_HOST,protocol=_PROTOCOL" spec.
+// Kinds left out keep their default suffix, and an empty url, host or protocol drops that variable.
+func ParseSuffixes(spec string) (Suffixes, error) {
+	suffixes := DefaultSuffixes
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		kind, suffix, found := strings.Cut(pair, "=")
+		if !found {
+			return Suffixes{}, fmt.Errorf("invalid suffix '%s': expected KIND=SUFFIX", pair)
+		}
+		suffix = strings.TrimSpace(suffix)
+		switch strings.TrimSpace(kind) {
+		case "url":
+			suffixes.URL = suffix
+		case "user":
+			suffixes.User = suffix
+		case "pass":
+			suffixes.Pass = suffix
+		case "host":
+			suffixes.Host = suffix
+		case "protocol":
+			suffixes.Protocol = suffix
+		default:
+			return Suffixes{}, fmt.Errorf("unknown suffix kind '%s' (supported: url, user, pass, host, protocol)", kind)
+		}
+	}
+
+	if suffixes.User == "" || suffixes.Pass == "" {
+		return Suffixes{}, fmt.Errorf("user and pass suffixes cannot be empty")
+	}
+	seen := make(map[string]bool)
+	for _, suffix := range []string{suffixes.URL, suffixes.User, suffixes.Pass, suffixes.Host, suffixes.Protocol} {
+		if suffix != "" && seen[suffix] {
+			return Suffixes{}, fmt.Errorf("suffixes must be distinct, got url=%s user=%s pass=%s host=%s protocol=%s",
+				suffixes.URL, suffixes.User, suffixes.Pass, suffixes.Host, suffixes.Protocol)
+		}
+		seen[suffix] = true
+	}
+	return suffixes, nil
+}
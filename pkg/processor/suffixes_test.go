@@ -0,0 +1,144 @@
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSuffixes(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected Suffixes
+		wantErr  bool
+	}{
+		{spec: "", expected: DefaultSuffixes},
+		{spec: "user=_USERNAME,pass=_PASSWORD", expected: Suffixes{URL: "_URL", User: "_USERNAME", Pass: "_PASSWORD", Host: "_HOST", Protocol: "_PROTOCOL"}},
+		{spec: "url=, user=_U, pass=_P", expected: Suffixes{URL: "", User: "_U", Pass: "_P", Host: "_HOST", Protocol: "_PROTOCOL"}},
+		{spec: "host=_SERVER,protocol=", expected: Suffixes{URL: "_URL", User: "_USER", Pass: "_PASS", Host: "_SERVER"}},
+		{spec: "url=,host=,protocol=", expected: Suffixes{User: "_USER", Pass: "_PASS"}},
+		{spec: "port=_PORT", wantErr: true},
+		{spec: "host=_URL", wantErr: true},
+		{spec: "user", wantErr: true},
+		{spec: "pass=", wantErr: true},
+		{spec: "user=_X,pass=_X", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := ParseSuffixes(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSuffixes(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.expected {
+				t.Errorf("ParseSuffixes(%q) = %+v, want %+v", tt.spec, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitMultiCredentialMode_CustomSuffixes(t *testing.T) {
+	proc := NewSecretProcessor()
+	proc.RegisterBackend("git", &MockGitBackend{username: "testuser", password: "testpass123"})
+	proc.SetSuffixes(Suffixes{User: "_USERNAME", Pass: "_PASSWORD"})
+
+	resolvedSecrets, err := proc.ProcessSecrets(map[string]string{"API": "git:https://api.example.com"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{"API_USERNAME": "testuser", "API_PASSWORD": "testpass123"}
+	if !reflect.DeepEqual(resolvedSecrets, expected) {
+		t.Errorf("ProcessSecrets() = %v, want %v", resolvedSecrets, expected)
+	}
+}
+
+// credentialResponseGitBackend answers keyPaths from a raw git credential response
+type credentialResponseGitBackend struct {
+	response string
+}
+
+func (b *credentialResponseGitBackend) RetrieveSecret(service, resource, keyPath string) (string, error) {
+	for _, line := range strings.Split(b.response, "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok && key == keyPath {
+			return value, nil
+		}
+	}
+	return "", errors.New("key not found")
+}
+
+func TestGitMultiCredentialMode_HostAndProtocol(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		address  string
+		expected map[string]string
+	}{
+		{
+			name:     "from the credential response",
+			response: "protocol=https\nhost=git.example.com:8443\nusername=ci\npassword=s3cret\n",
+			address:  "git:https://git.example.com:8443/org/repo.git",
+			expected: map[string]string{
+				"REPO_URL": "https://git.example.com:8443/org/repo.git", "REPO_USER": "ci", "REPO_PASS": "s3cret",
+				"REPO_HOST": "git.example.com:8443", "REPO_PROTOCOL": "https",
+			},
+		},
+		{
+			name:     "from the address when the helper leaves them out",
+			response: "username=ci\npassword=s3cret\n",
+			address:  "git:ci@api.example.com",
+			expected: map[string]string{
+				"REPO_URL": "https://api.example.com", "REPO_USER": "ci", "REPO_PASS": "s3cret",
+				"REPO_HOST": "api.example.com", "REPO_PROTOCOL": "https",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("git", &credentialResponseGitBackend{response: tt.response})
+
+			resolvedSecrets, err := proc.ProcessSecrets(map[string]string{"REPO": tt.address})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(resolvedSecrets, tt.expected) {
+				t.Errorf("ProcessSecrets() = %v, want %v", resolvedSecrets, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGitMultiCredentialMode_RejectsSingleValueOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		address  string
+		defaults map[string]string
+		expected string
+	}{
+		{name: "wrap", address: "git:https://api.example.com:::?wrap=base64", expected: "option 'wrap' applies to a single value"},
+		{name: "join", address: "git:https://api.example.com:::?join=,", expected: "option 'join' applies to a single value"},
+		{name: "decrypt", address: "git:https://api.example.com:::?decrypt=age:KEY", expected: "option 'decrypt' applies to a single value"},
+		{name: "unknown option", address: "git:https://api.example.com:::?bogus=1", expected: "unsupported option 'bogus'"},
+		{name: "inline default", address: "git:https://api.example.com:::||fallback", expected: "'||default' applies to a single value"},
+		{name: "defaults file", address: "git:https://api.example.com", defaults: map[string]string{"API": "fallback"}, expected: "defaults file entry for variable 'API'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proc := NewSecretProcessor()
+			proc.RegisterBackend("git", &MockGitBackend{username: "user", password: "pass"})
+			proc.SetDefaults(tt.defaults)
+
+			_, err := proc.ProcessSecrets(map[string]string{"API": tt.address})
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Fatalf("Expected error containing %q, got %v", tt.expected, err)
+			}
+			if kind := ErrorKindOf(err); kind != ErrorKindParse {
+				t.Errorf("Expected ErrorKindParse, got %v", kind)
+			}
+		})
+	}
+}
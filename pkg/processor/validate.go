@@ -0,0 +1,65 @@
+package processor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/liifi/secretinit/pkg/backend"
+	"github.com/liifi/secretinit/pkg/parser"
+)
+
+// ValidationResult is the outcome of validating one secret variable without retrieving it
+type ValidationResult struct {
+	Variable string
+	Address  string
+	Err      error // nil when the address is valid
+}
+
+// ValidateSecrets checks every secret address the way ProcessSecrets would, without creating
+// backends or retrieving anything: the address must parse, its backend must be available in
+// this build, AWS addresses must use a supported service and options must be known.
+// Results are sorted by variable name.
+func ValidateSecrets(secretVars map[string]string) []ValidationResult {
+	available := RegisterAllBackends()
+
+	names := make([]string, 0, len(secretVars))
+	for name := range secretVars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	results := make([]ValidationResult, 0, len(names))
+	for _, name := range names {
+		address := secretVars[name]
+		results = append(results, ValidationResult{
+			Variable: name,
+			Address:  address,
+			Err:      validateAddress(strings.TrimPrefix(address, "secretinit:"), available),
+		})
+	}
+	return results
+}
+
+// validateAddress checks a single address against the available backends
+func validateAddress(address string, available map[string]func() (backend.Backend, error)) error {
+	secretSource, err := parser.ParseSecretString(address)
+	if err != nil {
+		return fmt.Errorf("invalid secret address: %w", err)
+	}
+	if _, exists := available[secretSource.Backend]; !exists {
+		return fmt.Errorf("backend not available in this build: %s", secretSource.Backend)
+	}
+	if secretSource.Backend == "aws" && !backend.IsAWSService(secretSource.Service) {
+		return fmt.Errorf("unsupported AWS service '%s'%s. Supported services: %s", secretSource.Service, parser.DidYouMean(secretSource.Service, backend.AWSServiceNames), backend.AWSServices)
+	}
+	if secretSource.Backend == "git" && secretSource.KeyPath == "" {
+		if err := checkMultiCredentialOptions(secretSource); err != nil {
+			return err
+		}
+	} else if err := checkOptions(secretSource.Options); err != nil {
+		return err
+	}
+	_, err = retryPolicyFor(secretSource.Options)
+	return err
+}
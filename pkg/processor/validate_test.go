@@ -0,0 +1,61 @@
+package processor
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateSecrets(t *testing.T) {
+	results := ValidateSecrets(map[string]string{
+		"GIT_TOKEN": "secretinit:git:https://api.example.com:::password",
+		"DB_PASS":   "remote:ssh://bastion/aws:sm:myapp/db:::password",
+		"BAD_SVC":   "aws:kv:myapp/db",
+		"BAD_ADDR":  "nope",
+		"BAD_OPT":   "git:https://api.example.com:::password?bogus=1",
+		"BAD_WRAP":  "git:https://api.example.com:::?wrap=base64",
+		"UNKNOWN":   "vault:kv:secret/data/app",
+	})
+
+	expected := map[string]string{
+		"BAD_ADDR":  "invalid secret address",
+		"BAD_OPT":   "unsupported option 'bogus'",
+		"BAD_WRAP":  "option 'wrap' applies to a single value",
+		"BAD_SVC":   "unsupported AWS service 'kv'",
+		"DB_PASS":   "",
+		"GIT_TOKEN": "",
+		"UNKNOWN":   "unsupported backend: vault",
+	}
+	if _, ok := RegisterAllBackends()["aws"]; !ok {
+		expected["BAD_SVC"] = "backend not available in this build: aws"
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i, result := range results {
+		if i > 0 && results[i-1].Variable > result.Variable {
+			t.Errorf("Expected results sorted by variable, got %s before %s", results[i-1].Variable, result.Variable)
+		}
+		want := expected[result.Variable]
+		if want == "" {
+			if result.Err != nil {
+				t.Errorf("%s: unexpected error: %v", result.Variable, result.Err)
+			}
+			continue
+		}
+		if result.Err == nil || !strings.Contains(result.Err.Error(), want) {
+			t.Errorf("%s: expected error containing %q, got %v", result.Variable, want, result.Err)
+		}
+	}
+}
+
+func TestValidateSecrets_DoesNotRetrieve(t *testing.T) {
+	// A valid AWS address validates in every build that includes AWS, without credentials or network access
+	if _, ok := RegisterAllBackends()["aws"]; !ok {
+		t.Skip("AWS backend not in this build")
+	}
+	results := ValidateSecrets(map[string]string{"DB": "aws:sm:myapp/db:::password"})
+	if results[0].Err != nil {
+		t.Errorf("Unexpected error: %v", results[0].Err)
+	}
+}
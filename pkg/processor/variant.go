@@ -0,0 +1,51 @@
+package processor
+
+import (
+	"sort"
+)
+
+// cloudBackends are the SDK backends that distinguish the build variants
+var cloudBackends = []string{"aws", "gcp", "azure"}
+
+// AvailableBackends returns the sorted names of the backends compiled into this build
+func AvailableBackends() []string {
+	var names []string
+	for name := range RegisterAllBackends() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuildVariant returns the build variant ("full", "aws_only", "gcp_only", "azure_only" or "git_only")
+// derived from the backends compiled into this build
+func BuildVariant() string {
+	return buildVariantFor(AvailableBackends())
+}
+
+// buildVariantFor derives the build variant from a list of backend names.
+// Returns "custom" for combinations that don't match a known build tag.
+func buildVariantFor(backendNames []string) string {
+	available := make(map[string]bool)
+	for _, name := range backendNames {
+		available[name] = true
+	}
+
+	var clouds []string
+	for _, name := range cloudBackends {
+		if available[name] {
+			clouds = append(clouds, name)
+		}
+	}
+
+	switch len(clouds) {
+	case len(cloudBackends):
+		return "full"
+	case 1:
+		return clouds[0] + "_only"
+	case 0:
+		return "git_only"
+	default:
+		return "custom"
+	}
+}
@@ -0,0 +1,45 @@
+package processor
+
+import (
+	"testing"
+)
+
+func TestBuildVariant(t *testing.T) {
+	if got := BuildVariant(); got != expectedBuildVariant {
+		t.Errorf("BuildVariant() = %s, want %s for the compiled build tags", got, expectedBuildVariant)
+	}
+}
+
+func TestBuildVariantFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		backends []string
+		expected string
+	}{
+		{"full", []string{"azure", "aws", "gcp", "git", "remote"}, "full"},
+		{"aws only", []string{"aws", "git", "remote"}, "aws_only"},
+		{"gcp only", []string{"gcp", "git", "remote"}, "gcp_only"},
+		{"azure only", []string{"azure", "git", "remote"}, "azure_only"},
+		{"git only", []string{"git", "remote"}, "git_only"},
+		{"git only with bitwarden", []string{"bitwarden", "git", "remote"}, "git_only"},
+		{"unknown combination", []string{"aws", "gcp", "git"}, "custom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buildVariantFor(tt.backends); got != tt.expected {
+				t.Errorf("buildVariantFor(%v) = %s, want %s", tt.backends, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegisterAllBackends_SDKFreeBackends(t *testing.T) {
+	// Backends without a cloud SDK are in every build variant
+	available := RegisterAllBackends()
+	for _, name := range []string{"git", "remote", "wincred", "bitwarden", "exec", "csv", "file"} {
+		if _, ok := available[name]; !ok {
+			t.Errorf("Expected backend %s in the %s build", name, BuildVariant())
+		}
+	}
+}